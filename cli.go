@@ -0,0 +1,393 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cjeanner/kustomap/internal/build"
+	"github.com/cjeanner/kustomap/internal/cache"
+	"github.com/cjeanner/kustomap/internal/export"
+	"github.com/cjeanner/kustomap/internal/fetcher"
+	"github.com/cjeanner/kustomap/internal/parser"
+	"github.com/cjeanner/kustomap/internal/repository"
+	"github.com/cjeanner/kustomap/internal/types"
+	"github.com/cjeanner/kustomap/internal/validation"
+)
+
+// Exit codes of the analyze and build commands.
+const (
+	exitOK         = 0 // success (analyze: no error nodes)
+	exitFailure    = 1 // the analysis or build failed
+	exitUsage      = 2 // invalid command line
+	exitErrorNodes = 3 // analyze: the graph has error nodes (unresolved or unreadable references)
+)
+
+const usage = `Usage:
+  kustomap [serve] [flags]                  start the web UI and API server
+  kustomap analyze [flags] <url|path>       print the dependency graph of an overlay
+  kustomap build [flags] <node-id>          print the kustomize build of a node
+
+Tokens are read from the environment: GITHUB_TOKEN, GITLAB_TOKEN, BITBUCKET_TOKEN,
+GITEA_TOKEN, AZURE_DEVOPS_TOKEN and GIT_TOKEN.
+
+analyze exits with 0 when the graph is complete, 3 when it has error nodes,
+1 when the analysis fails and 2 on invalid arguments.
+
+Run "kustomap <command> -h" for the flags of a command.
+`
+
+// tokenEnv maps repository types to the environment variable holding their token.
+var tokenEnv = map[repository.RepositoryType]string{
+	repository.GitHub:      "GITHUB_TOKEN",
+	repository.GitLab:      "GITLAB_TOKEN",
+	repository.Bitbucket:   "BITBUCKET_TOKEN",
+	repository.Gitea:       "GITEA_TOKEN",
+	repository.AzureDevOps: "AZURE_DEVOPS_TOKEN",
+	repository.Git:         "GIT_TOKEN",
+}
+
+// tokensFromEnv returns the tokens set in the environment, by repository type.
+func tokensFromEnv() map[repository.RepositoryType]string {
+	tokens := make(map[repository.RepositoryType]string)
+	for repoType, name := range tokenEnv {
+		if token := os.Getenv(name); token != "" {
+			tokens[repoType] = token
+		}
+	}
+	return tokens
+}
+
+// parseArgs parses flags and positional arguments in any order (e.g. "analyze <url> -format
+// mermaid"), which the flag package alone stops parsing at the first positional argument.
+func parseArgs(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		if flags.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
+
+// commandContext returns the context of a command: canceled on interrupt (e.g. a CI job
+// being stopped) and, when timeout > 0, at the deadline.
+func commandContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	if timeout <= 0 {
+		return ctx, stop
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
+// setVerbose sends fetch and parse logs to stderr when verbose, and drops them otherwise
+// so that only results and problems are printed.
+func setVerbose(verbose bool, stderr io.Writer) {
+	if verbose {
+		log.SetOutput(stderr)
+	} else {
+		log.SetOutput(io.Discard)
+	}
+}
+
+// writeOutput writes data to the file at path, or to stdout when path is empty or "-".
+func writeOutput(path string, stdout io.Writer, data []byte) error {
+	if path == "" || path == "-" {
+		_, err := stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// runAnalyze implements "kustomap analyze" and returns the exit code.
+func runAnalyze(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("analyze", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", "json", "Output format: json, "+strings.Join(export.FormatNames(), ", "))
+	direction := flags.String("direction", "TD", "Diagram direction for mermaid, plantuml and d2: TD or LR")
+	hideFiles := flags.Bool("hide-files", false, "Leave plain-file resource nodes out of mermaid, plantuml and d2 diagrams")
+	output := flags.String("o", "", "Write the graph to this file instead of stdout")
+	timeout := flags.Duration("timeout", 0, "Give up after this long (e.g. 5m; default: no limit)")
+	verbose := flags.Bool("v", false, "Log fetches and parsing to stderr")
+	fetch := addFetchFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: kustomap analyze [flags] <url|path>")
+		flags.PrintDefaults()
+	}
+
+	positional, err := parseArgs(flags, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil {
+		return exitUsage
+	}
+	if len(positional) != 1 {
+		flags.Usage()
+		return exitUsage
+	}
+	*format = strings.ToLower(*format)
+	exporter, exported := export.Formats[*format]
+	if *format != "json" && !exported {
+		fmt.Fprintf(stderr, "unsupported format %q (want json, %s)\n", *format, strings.Join(export.FormatNames(), ", "))
+		return exitUsage
+	}
+	exportOpts := export.Options{HideFiles: *hideFiles}
+	if exportOpts.Direction, err = validation.ValidateDirection(*direction); err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return exitUsage
+	}
+	hostLimits, err := parseHostLimits(*fetch.hostLimits)
+	if err != nil {
+		fmt.Fprintf(stderr, "invalid host concurrency: %v\n", err)
+		return exitUsage
+	}
+	setVerbose(*verbose, stderr)
+
+	opts := analyzeOptions{tokens: tokensFromEnv(), workers: *fetch.workers, hostLimits: hostLimits}
+	if dir := fetch.cacheDirOrEnv(); dir != "" {
+		if opts.cache, err = cache.New(dir, *fetch.cacheMaxMB<<20); err != nil {
+			fmt.Fprintf(stderr, "warning: content cache disabled: %v\n", err)
+		}
+	}
+
+	ctx, cancel := commandContext(*timeout)
+	defer cancel()
+	graph, err := analyzeTarget(ctx, positional[0], opts)
+	if err != nil {
+		fmt.Fprintf(stderr, "analyze: %v\n", err)
+		return exitFailure
+	}
+
+	var data []byte
+	if exported {
+		out, err := exporter.Render(graph, exportOpts)
+		if err != nil {
+			fmt.Fprintf(stderr, "analyze: %v\n", err)
+			return exitFailure
+		}
+		data = []byte(out)
+	} else {
+		if data, err = json.MarshalIndent(graph, "", "  "); err != nil {
+			fmt.Fprintf(stderr, "analyze: encode graph: %v\n", err)
+			return exitFailure
+		}
+		data = append(data, '\n')
+	}
+	if err := writeOutput(*output, stdout, data); err != nil {
+		fmt.Fprintf(stderr, "analyze: %v\n", err)
+		return exitFailure
+	}
+
+	if n := reportErrorNodes(graph, stderr); n > 0 {
+		return exitErrorNodes
+	}
+	return exitOK
+}
+
+// analyzeOptions configures analyzeTarget.
+type analyzeOptions struct {
+	tokens     map[repository.RepositoryType]string
+	cache      *cache.Store // may be nil
+	workers    int
+	hostLimits map[string]int
+}
+
+// analyzeTarget builds the graph of the overlay at target: a local directory or a
+// repository URL. Unlike the server, any host and any local path is allowed: the CLI
+// only reaches what the user running it can reach.
+func analyzeTarget(ctx context.Context, target string, opts analyzeOptions) (*types.Graph, error) {
+	repoInfo, err := detectTarget(ctx, target, opts.tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := fetcher.NewFetcher(repoInfo, opts.tokens[repoInfo.Type])
+	if err != nil {
+		return nil, fmt.Errorf("create fetcher: %w", err)
+	}
+	p := parser.NewParser(f, repoInfo)
+	for repoType, token := range opts.tokens {
+		p.SetToken(repoType, token)
+	}
+	p.SetCache(opts.cache)
+	p.SetConcurrency(opts.workers, opts.hostLimits)
+
+	graph, err := p.Parse(ctx, repoInfo.Path)
+	if err != nil {
+		return nil, err
+	}
+	graph.ID = uuid.New().String()
+	graph.Created = time.Now().Format(time.RFC3339)
+	if repoInfo.Type == repository.Local {
+		graph.LocalBranch = repoInfo.Ref
+		graph.LocalRootPath = repoInfo.RootPath
+	}
+	return graph, nil
+}
+
+// detectTarget returns the repository of target, resolving ambiguous branch/path URLs and
+// the default branch of URLs without a ref.
+func detectTarget(ctx context.Context, target string, tokens map[repository.RepositoryType]string) (*repository.RepositoryInfo, error) {
+	if dir, ok := localDir(target); ok {
+		return repository.DetectLocalRepository(dir)
+	}
+
+	repoInfo, err := repository.DetectRepository(ctx, target, "")
+	if err != nil {
+		return nil, err
+	}
+	token := tokens[repoInfo.Type]
+	if repoInfo.AmbiguousPath != "" {
+		branch, path, err := repository.ResolveBranchAndPath(ctx, repoInfo, repoInfo.AmbiguousPath, token)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve branch: %w", err)
+		}
+		repoInfo.Ref = branch
+		repoInfo.Path = path
+	}
+	if err := repository.ResolveDefaultBranch(ctx, repoInfo, token); err != nil {
+		log.Printf("Warning: could not resolve default branch, using %s: %v", repoInfo.Ref, err)
+	}
+	return repoInfo, nil
+}
+
+// localDir returns the absolute path of target when it names an existing local directory
+// (a path, ~/path or file:// URL).
+func localDir(target string) (string, bool) {
+	path := strings.TrimPrefix(target, "file://")
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", false
+		}
+		path = filepath.Join(home, strings.TrimPrefix(path, "~"))
+	}
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		return "", false
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	return abs, true
+}
+
+// reportErrorNodes prints the error nodes of graph to w and returns how many there are.
+func reportErrorNodes(graph *types.Graph, w io.Writer) int {
+	n := 0
+	for _, e := range graph.Elements {
+		if e.Group != "nodes" || e.Data.Type != "error" {
+			continue
+		}
+		n++
+		msg, _ := e.Data.Content["error"].(string)
+		fmt.Fprintf(w, "error node %s: %s\n", e.Data.ID, msg)
+	}
+	if n > 0 {
+		fmt.Fprintf(w, "%d error node(s) in the graph\n", n)
+	}
+	return n
+}
+
+// runBuild implements "kustomap build" and returns the exit code.
+func runBuild(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("build", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	graphFile := flags.String("graph", "", "Graph JSON written by analyze: builds the node at the commit it was analyzed at")
+	baseURL := flags.String("base-url", "", "Base URL of the node's repository (e.g. https://gitlab.example.com); default from -graph")
+	cloneURL := flags.String("clone-url", "", "Clone URL of a plain git node's repository (e.g. git@host:group/sub/repo.git); default from -graph")
+	commit := flags.String("commit", "", "Commit to build instead of the node ref; default from -graph")
+	root := flags.String("root", ".", "Repository root of local nodes")
+	output := flags.String("o", "", "Write the YAML to this file instead of stdout")
+	timeout := flags.Duration("timeout", 0, "Give up after this long (e.g. 5m; default: no limit)")
+	verbose := flags.Bool("v", false, "Log downloads to stderr")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: kustomap build [flags] <node-id>")
+		flags.PrintDefaults()
+	}
+
+	positional, err := parseArgs(flags, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil {
+		return exitUsage
+	}
+	if len(positional) != 1 {
+		flags.Usage()
+		return exitUsage
+	}
+	nodeID := positional[0]
+	setVerbose(*verbose, stderr)
+
+	if *graphFile != "" {
+		graph, err := readGraph(*graphFile)
+		if err != nil {
+			fmt.Fprintf(stderr, "build: %v\n", err)
+			return exitUsage
+		}
+		if *baseURL == "" {
+			*baseURL = graph.BaseURLs[nodeID]
+		}
+		if *cloneURL == "" {
+			*cloneURL = graph.CloneURLs[nodeID]
+		}
+		if *commit == "" {
+			*commit = graph.CommitSHAs[nodeID]
+		}
+	}
+
+	tokens := tokensFromEnv()
+	b := build.NewBuilder(tokens[repository.GitHub], tokens[repository.GitLab])
+	for repoType, token := range tokens {
+		b.SetToken(repoType, token)
+	}
+
+	ctx, cancel := commandContext(*timeout)
+	defer cancel()
+	yamlOut, err := b.Build(ctx, nodeID, *baseURL, *cloneURL, *root, *commit)
+	if err != nil {
+		fmt.Fprintf(stderr, "build: %v\n", err)
+		return exitFailure
+	}
+	if err := writeOutput(*output, stdout, []byte(yamlOut)); err != nil {
+		fmt.Fprintf(stderr, "build: %v\n", err)
+		return exitFailure
+	}
+	return exitOK
+}
+
+// readGraph reads a graph written by "kustomap analyze -format json".
+func readGraph(path string) (*types.Graph, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var graph types.Graph
+	if err := json.Unmarshal(data, &graph); err != nil {
+		return nil, fmt.Errorf("read graph %s: %w", path, err)
+	}
+	return &graph, nil
+}
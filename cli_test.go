@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cjeanner/kustomap/internal/types"
+)
+
+// writeOverlay writes files (path -> content) under a new directory and returns it.
+func writeOverlay(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestRunAnalyze_JSON(t *testing.T) {
+	dir := writeOverlay(t, map[string]string{
+		"kustomization.yaml":      "resources:\n  - base\n",
+		"base/kustomization.yaml": "resources:\n  - deployment.yaml\n",
+		"base/deployment.yaml":    "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: app\n",
+	})
+	var stdout, stderr bytes.Buffer
+	// Flags after the positional argument are parsed too
+	if code := runAnalyze([]string{dir, "-format", "json"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("exit code = %d, want %d; stderr: %s", code, exitOK, stderr.String())
+	}
+	var graph types.Graph
+	if err := json.Unmarshal(stdout.Bytes(), &graph); err != nil {
+		t.Fatalf("output is not a graph: %v", err)
+	}
+	if graph.ID == "" || len(graph.Elements) == 0 {
+		t.Errorf("graph = %+v, want an ID and elements", graph)
+	}
+}
+
+func TestRunAnalyze_ErrorNodes(t *testing.T) {
+	dir := writeOverlay(t, map[string]string{
+		"kustomization.yaml": "resources:\n  - missing\n",
+	})
+	var stdout, stderr bytes.Buffer
+	code := runAnalyze([]string{"-format", "mermaid", dir}, &stdout, &stderr)
+	if code != exitErrorNodes {
+		t.Fatalf("exit code = %d, want %d; stderr: %s", code, exitErrorNodes, stderr.String())
+	}
+	if !strings.HasPrefix(stdout.String(), "flowchart") {
+		t.Errorf("stdout = %q, want a mermaid flowchart", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "1 error node(s)") {
+		t.Errorf("stderr = %q, want an error node summary", stderr.String())
+	}
+}
+
+func TestRunAnalyze_Usage(t *testing.T) {
+	for _, args := range [][]string{
+		nil,
+		{"a", "b"},
+		{"-format", "svg", "."},
+		{"-no-such-flag", "."},
+	} {
+		var stdout, stderr bytes.Buffer
+		if code := runAnalyze(args, &stdout, &stderr); code != exitUsage {
+			t.Errorf("runAnalyze(%q) = %d, want %d", args, code, exitUsage)
+		}
+	}
+}
+
+func TestRunAnalyze_Failure(t *testing.T) {
+	dir := writeOverlay(t, map[string]string{"README.md": "no kustomization here"})
+	var stdout, stderr bytes.Buffer
+	if code := runAnalyze([]string{dir}, &stdout, &stderr); code != exitFailure {
+		t.Errorf("exit code = %d, want %d", code, exitFailure)
+	}
+}
+
+func TestRunBuild_Local(t *testing.T) {
+	dir := writeOverlay(t, map[string]string{
+		"app/kustomization.yaml": "resources:\n  - cm.yaml\n",
+		"app/cm.yaml":            "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\n",
+	})
+	out := filepath.Join(t.TempDir(), "out.yaml")
+	var stdout, stderr bytes.Buffer
+	if code := runBuild([]string{"local:app@main", "-root", dir, "-o", out}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("exit code = %d, want %d; stderr: %s", code, exitOK, stderr.String())
+	}
+	if _, err := os.Stat(out); err != nil {
+		t.Errorf("output file not written: %v", err)
+	}
+	if code := runBuild([]string{"not-a-node-id"}, &stdout, &stderr); code != exitFailure {
+		t.Errorf("build of an invalid node ID: exit code = %d, want %d", code, exitFailure)
+	}
+}
+
+func TestParseArgs_Interspersed(t *testing.T) {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	a := flags.String("a", "", "")
+	b := flags.Bool("b", false, "")
+	positional, err := parseArgs(flags, []string{"-a", "x", "one", "-b", "two"})
+	if err != nil {
+		t.Fatalf("parseArgs: %v", err)
+	}
+	if want := []string{"one", "two"}; !reflect.DeepEqual(positional, want) || *a != "x" || !*b {
+		t.Errorf("parseArgs = %q (a=%q, b=%v), want %q (a=x, b=true)", positional, *a, *b, want)
+	}
+}
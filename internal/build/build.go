@@ -2,7 +2,9 @@ package build
 
 import (
 	"archive/tar"
+	"archive/zip"
 	"compress/gzip"
+	"context"
 	"fmt"
 	"io"
 	"log"
@@ -14,6 +16,8 @@ import (
 	"time"
 
 	"github.com/cjeanner/kustomap/internal/repository"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
 	"sigs.k8s.io/kustomize/api/krusty"
 	"sigs.k8s.io/kustomize/kyaml/filesys"
 )
@@ -21,16 +25,18 @@ import (
 // Builder runs kustomize build for a node (overlay/base) by fetching the repo
 // and running the kustomize API.
 type Builder struct {
-	githubToken string
-	gitlabToken string
-	client      *http.Client
+	tokens map[repository.RepositoryType]string
+	client *http.Client
 }
 
 // NewBuilder creates a Builder with optional tokens for private repos.
+// Tokens for other repository types are set with SetToken.
 func NewBuilder(githubToken, gitlabToken string) *Builder {
 	return &Builder{
-		githubToken: githubToken,
-		gitlabToken: gitlabToken,
+		tokens: map[repository.RepositoryType]string{
+			repository.GitHub: githubToken,
+			repository.GitLab: gitlabToken,
+		},
 		client: &http.Client{
 			Timeout:   120 * time.Second,
 			Transport: &http.Transport{MaxIdleConns: 2},
@@ -38,15 +44,29 @@ func NewBuilder(githubToken, gitlabToken string) *Builder {
 	}
 }
 
+// SetToken sets the authentication token for a repository type
+func (b *Builder) SetToken(repoType repository.RepositoryType, token string) {
+	b.tokens[repoType] = token
+}
+
 // Build fetches the repo for the given node ID, runs kustomize build at the node path,
 // and returns the built YAML as a string. The node ID must be in format
 // type:owner/repo/path@ref (e.g. github:foo/bar/deploy/overlay@main) or local:path@ref.
 // baseURL is the repo base URL for remote; for local nodes, localRootPath must be set.
-func (b *Builder) Build(nodeID, baseURL, localRootPath string) (yamlOut string, err error) {
+// cloneURL is the clone URL of plain git nodes (Graph.CloneURLs); when empty it is derived
+// from baseURL and the owner/repo of the node ID. commitSHA, when set, is the commit fetched instead of the node ID ref (the commit the
+// graph was analyzed at), so the build matches the graph even if the branch moved.
+// Downloads and clones stop when ctx is done.
+func (b *Builder) Build(ctx context.Context, nodeID, baseURL, cloneURL, localRootPath, commitSHA string) (yamlOut string, err error) {
 	parts, err := ParseNodeID(nodeID)
 	if err != nil {
 		return "", fmt.Errorf("parse node ID: %w", err)
 	}
+	if parts.Type == repository.Git && cloneURL != "" {
+		if err := parts.splitGitRepo(cloneURL); err != nil {
+			return "", fmt.Errorf("parse node ID: %w", err)
+		}
+	}
 
 	var buildPath string
 
@@ -74,14 +94,31 @@ func (b *Builder) Build(nodeID, baseURL, localRootPath string) (yamlOut string,
 			}
 		}()
 
-		archivePath, err := b.downloadArchive(dir, parts, baseURL)
-		if err != nil {
-			return "", err
+		ref := parts.Ref
+		if commitSHA != "" {
+			ref = commitSHA
 		}
+		var rootDir string
+		if parts.Type == repository.Git {
+			// No archive API on plain git hosts: clone the ref instead
+			rootDir, err = b.cloneRepo(ctx, dir, parts, baseURL, cloneURL, ref)
+			if err != nil {
+				return "", err
+			}
+		} else {
+			archivePath, err := b.downloadArchive(ctx, dir, parts, baseURL, ref)
+			if err != nil {
+				return "", err
+			}
 
-		rootDir, err := extractTarGz(archivePath, dir)
-		if err != nil {
-			return "", fmt.Errorf("extract archive: %w", err)
+			if filepath.Ext(archivePath) == ".zip" {
+				rootDir, err = extractZip(archivePath, dir)
+			} else {
+				rootDir, err = extractTarGz(archivePath, dir)
+			}
+			if err != nil {
+				return "", fmt.Errorf("extract archive: %w", err)
+			}
 		}
 
 		buildPath = filepath.Join(dir, rootDir, parts.Path)
@@ -98,6 +135,10 @@ func (b *Builder) Build(nodeID, baseURL, localRootPath string) (yamlOut string,
 		}
 	}
 
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
 	fs := filesys.MakeFsOnDisk()
 	k := krusty.MakeKustomizer(krusty.MakeDefaultOptions())
 	resMap, err := k.Run(fs, buildPath)
@@ -113,7 +154,8 @@ func (b *Builder) Build(nodeID, baseURL, localRootPath string) (yamlOut string,
 	return string(yamlBytes), nil
 }
 
-func (b *Builder) downloadArchive(dir string, parts *NodeIDParts, baseURL string) (string, error) {
+// downloadArchive downloads the repository archive at ref (branch, tag or commit SHA) into dir.
+func (b *Builder) downloadArchive(ctx context.Context, dir string, parts *NodeIDParts, baseURL, ref string) (string, error) {
 	var archiveURL string
 	var req *http.Request
 
@@ -127,10 +169,10 @@ func (b *Builder) downloadArchive(dir string, parts *NodeIDParts, baseURL string
 				apiBase = strings.TrimSuffix(baseURL, "/") + "/api/v3"
 			}
 		}
-		archiveURL = fmt.Sprintf("%s/repos/%s/%s/tarball/%s", apiBase, parts.Owner, parts.Repo, parts.Ref)
-		req, _ = http.NewRequest(http.MethodGet, archiveURL, nil)
-		if b.githubToken != "" {
-			req.Header.Set("Authorization", "Bearer "+b.githubToken)
+		archiveURL = fmt.Sprintf("%s/repos/%s/%s/tarball/%s", apiBase, parts.Owner, parts.Repo, ref)
+		req, _ = http.NewRequestWithContext(ctx, http.MethodGet, archiveURL, nil)
+		if token := b.tokens[repository.GitHub]; token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
 		}
 		req.Header.Set("Accept", "application/vnd.github.v3+json")
 	case repository.GitLab:
@@ -139,11 +181,41 @@ func (b *Builder) downloadArchive(dir string, parts *NodeIDParts, baseURL string
 			apiBase = strings.TrimSuffix(baseURL, "/")
 		}
 		projectID := parts.Owner + "%2F" + parts.Repo
-		archiveURL = fmt.Sprintf("%s/api/v4/projects/%s/repository/archive.tar.gz?sha=%s", apiBase, projectID, url.QueryEscape(parts.Ref))
-		req, _ = http.NewRequest(http.MethodGet, archiveURL, nil)
-		if b.gitlabToken != "" {
-			req.Header.Set("PRIVATE-TOKEN", b.gitlabToken)
+		archiveURL = fmt.Sprintf("%s/api/v4/projects/%s/repository/archive.tar.gz?sha=%s", apiBase, projectID, url.QueryEscape(ref))
+		req, _ = http.NewRequestWithContext(ctx, http.MethodGet, archiveURL, nil)
+		if token := b.tokens[repository.GitLab]; token != "" {
+			req.Header.Set("PRIVATE-TOKEN", token)
+		}
+	case repository.Bitbucket:
+		info := &repository.RepositoryInfo{BaseURL: baseURL, Owner: parts.Owner, Repo: parts.Repo}
+		if baseURL == "" || repository.IsBitbucketCloud(baseURL) {
+			// Cloud archives are served by the website, not the API
+			archiveURL = fmt.Sprintf("https://bitbucket.org/%s/%s/get/%s.tar.gz", parts.Owner, parts.Repo, url.PathEscape(ref))
+		} else {
+			// Server archives have no top-level directory unless a prefix is given
+			archiveURL = fmt.Sprintf("%s/archive?at=%s&format=tar.gz&prefix=%s",
+				repository.BitbucketRepoAPI(info), url.QueryEscape(ref), url.QueryEscape(parts.Repo+"/"))
+		}
+		req, _ = http.NewRequestWithContext(ctx, http.MethodGet, archiveURL, nil)
+		repository.SetBitbucketAuth(req, b.tokens[repository.Bitbucket])
+	case repository.Gitea:
+		info := &repository.RepositoryInfo{BaseURL: baseURL, Owner: parts.Owner, Repo: parts.Repo}
+		archiveURL = fmt.Sprintf("%s/archive/%s.tar.gz", repository.GiteaRepoAPI(info), url.PathEscape(ref))
+		req, _ = http.NewRequestWithContext(ctx, http.MethodGet, archiveURL, nil)
+		repository.SetGiteaAuth(req, b.tokens[repository.Gitea])
+	case repository.AzureDevOps:
+		// Azure DevOps only serves zip archives, through the Items API
+		info := &repository.RepositoryInfo{BaseURL: baseURL, Owner: parts.Owner, Repo: parts.Repo, Ref: ref}
+		if info.BaseURL == "" {
+			info.BaseURL = "https://dev.azure.com"
 		}
+		query := repository.AzureVersion(ref, repository.AzureRefType(ctx, info, b.tokens[repository.AzureDevOps]))
+		query.Set("path", "/")
+		query.Set("$format", "zip")
+		query.Set("download", "true")
+		archiveURL = repository.AzureAPIURL(info, "items", query)
+		req, _ = http.NewRequestWithContext(ctx, http.MethodGet, archiveURL, nil)
+		repository.SetAzureAuth(req, b.tokens[repository.AzureDevOps])
 	default:
 		return "", fmt.Errorf("unsupported repo type: %s", parts.Type)
 	}
@@ -160,6 +232,9 @@ func (b *Builder) downloadArchive(dir string, parts *NodeIDParts, baseURL string
 	}
 
 	ext := ".tar.gz"
+	if parts.Type == repository.AzureDevOps {
+		ext = ".zip"
+	}
 	if parts.Type == repository.GitLab && strings.Contains(resp.Header.Get("Content-Disposition"), "filename=") {
 		// GitLab may return a different extension; we expect tar.gz
 		ext = ".tar.gz"
@@ -179,6 +254,109 @@ func (b *Builder) downloadArchive(dir string, parts *NodeIDParts, baseURL string
 	return archivePath, nil
 }
 
+// zipRootDir is the directory zip archives are extracted to: Azure DevOps archives have
+// no top-level directory.
+const zipRootDir = "repo"
+
+// extractTarget returns the path an archive entry named name is written to under dest.
+// It reports false for the archive root itself and for entries that would land outside
+// dest, such as absolute paths or ones climbing out with "..".
+func extractTarget(dest, name string) (string, bool) {
+	if filepath.IsAbs(name) {
+		return "", false
+	}
+	target := filepath.Join(dest, name)
+	rel, err := filepath.Rel(dest, target)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return target, true
+}
+
+// extractZip extracts a .zip file into dir/zipRootDir and returns zipRootDir.
+func extractZip(archivePath, dir string) (string, error) {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return "", err
+	}
+	defer zr.Close()
+	root := filepath.Join(dir, zipRootDir)
+	for _, zf := range zr.File {
+		target, ok := extractTarget(root, zf.Name)
+		if !ok {
+			continue
+		}
+		if zf.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return "", err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return "", err
+		}
+		r, err := zf.Open()
+		if err != nil {
+			return "", err
+		}
+		w, err := os.Create(target)
+		if err != nil {
+			r.Close()
+			return "", err
+		}
+		_, err = io.Copy(w, r)
+		w.Close()
+		r.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+	return zipRootDir, nil
+}
+
+// cloneRepo clones a plain git repository at ref (branch, tag or commit SHA) into a
+// subdirectory of dir and returns the subdirectory name. The repository is cloned from
+// cloneURL, or from the HTTPS URL derived from baseURL and parts when it is empty.
+func (b *Builder) cloneRepo(ctx context.Context, dir string, parts *NodeIDParts, baseURL, cloneURL, ref string) (string, error) {
+	const rootDir = "repo"
+	if cloneURL == "" {
+		cloneURL = repository.GitCloneURL(&repository.RepositoryInfo{BaseURL: baseURL, Owner: parts.Owner, Repo: parts.Repo})
+	}
+	auth := repository.GitAuth(cloneURL, b.tokens[repository.Git])
+	target := filepath.Join(dir, rootDir)
+
+	if repository.IsCommitHash(ref) {
+		repo, err := git.PlainCloneContext(ctx, target, false, &git.CloneOptions{URL: cloneURL, Auth: auth, NoCheckout: true})
+		if err != nil {
+			return "", fmt.Errorf("clone %s: %w", cloneURL, err)
+		}
+		wt, err := repo.Worktree()
+		if err != nil {
+			return "", err
+		}
+		if err := wt.Checkout(&git.CheckoutOptions{Hash: plumbing.NewHash(ref)}); err != nil {
+			return "", fmt.Errorf("checkout %s: %w", ref, err)
+		}
+		return rootDir, nil
+	}
+
+	var err error
+	for _, name := range []plumbing.ReferenceName{plumbing.NewBranchReferenceName(ref), plumbing.NewTagReferenceName(ref)} {
+		_, err = git.PlainCloneContext(ctx, target, false, &git.CloneOptions{
+			URL:           cloneURL,
+			Auth:          auth,
+			ReferenceName: name,
+			SingleBranch:  true,
+			Depth:         1,
+		})
+		if err == nil {
+			return rootDir, nil
+		}
+		os.RemoveAll(target)
+	}
+	return "", fmt.Errorf("clone %s @ %s: %w", cloneURL, ref, err)
+}
+
 // paxGlobalHeader is a tar metadata file (PAX extended header); not a real path. Skip it.
 const paxGlobalHeader = "pax_global_header"
 
@@ -205,10 +383,11 @@ func extractTarGz(archivePath, dir string) (string, error) {
 		if err != nil {
 			return "", err
 		}
-		name := filepath.Clean(h.Name)
-		if name == "." || name == ".." || strings.Contains(name, "..") {
+		target, ok := extractTarget(dir, h.Name)
+		if !ok {
 			continue
 		}
+		name := filepath.Clean(h.Name)
 		// Skip PAX global header and any path under it (not a real directory).
 		if name == paxGlobalHeader || strings.HasPrefix(name, paxGlobalHeader+"/") {
 			continue
@@ -220,7 +399,6 @@ func extractTarGz(archivePath, dir string) (string, error) {
 				topDir = name
 			}
 		}
-		target := filepath.Join(dir, name)
 		switch h.Typeflag {
 		case tar.TypeDir:
 			if err := os.MkdirAll(target, 0755); err != nil {
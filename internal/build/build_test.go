@@ -2,16 +2,23 @@ package build
 
 import (
 	"archive/tar"
+	"archive/zip"
+	"bytes"
 	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/cjeanner/kustomap/internal/repository"
 )
 
 func TestBuild_InvalidNodeID_ReturnsParseError(t *testing.T) {
 	b := NewBuilder("", "")
-	_, err := b.Build("not-a-valid-node-id", "", "")
+	_, err := b.Build(context.Background(), "not-a-valid-node-id", "", "", "", "")
 	if err == nil {
 		t.Fatal("Build() expected error for invalid node ID")
 	}
@@ -22,7 +29,7 @@ func TestBuild_InvalidNodeID_ReturnsParseError(t *testing.T) {
 
 func TestBuild_InvalidNodeID_WithBaseURL_ReturnsParseError(t *testing.T) {
 	b := NewBuilder("", "")
-	_, err := b.Build("missing-at:foo/bar/path", "https://gitlab.example.com", "")
+	_, err := b.Build(context.Background(), "missing-at:foo/bar/path", "https://gitlab.example.com", "", "", "")
 	if err == nil {
 		t.Fatal("Build() expected error for invalid node ID")
 	}
@@ -139,3 +146,206 @@ func TestExtractTarGz_NoTopLevelDir_ReturnsError(t *testing.T) {
 		t.Errorf("extractTarGz() error = %v, want containing 'no top-level directory'", err)
 	}
 }
+
+func TestExtractTarGz_KeepsEntriesInsideDir(t *testing.T) {
+	dir := t.TempDir()
+	archivePath := filepath.Join(dir, "test.tar.gz")
+
+	f, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+	for _, name := range []string{"repo-dir/foo..bar.yaml", "repo-dir/../../evil.yaml", "/abs.yaml"} {
+		h := &tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: 1}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte("x")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	extractDir := filepath.Join(dir, "extract")
+	os.MkdirAll(extractDir, 0755)
+
+	topDir, err := extractTarGz(archivePath, extractDir)
+	if err != nil {
+		t.Fatalf("extractTarGz() error = %v", err)
+	}
+	if topDir != "repo-dir" {
+		t.Errorf("extractTarGz() topDir = %q, want %q", topDir, "repo-dir")
+	}
+	if _, err := os.Stat(filepath.Join(extractDir, "repo-dir", "foo..bar.yaml")); err != nil {
+		t.Errorf("foo..bar.yaml should be extracted: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "evil.yaml")); err == nil {
+		t.Error("entry escaping the destination should not be extracted")
+	}
+	if _, err := os.Stat(filepath.Join(extractDir, "abs.yaml")); err == nil {
+		t.Error("absolute entry should not be extracted")
+	}
+}
+
+func TestExtractZip_KeepsEntriesInsideRoot(t *testing.T) {
+	dir := t.TempDir()
+	archivePath := filepath.Join(dir, "test.zip")
+
+	f, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for _, name := range []string{"base/foo..bar.yaml", "../evil.yaml", "base/../../evil.yaml"} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte("x"))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	extractDir := filepath.Join(dir, "extract")
+	os.MkdirAll(extractDir, 0755)
+
+	rootDir, err := extractZip(archivePath, extractDir)
+	if err != nil {
+		t.Fatalf("extractZip() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(extractDir, rootDir, "base", "foo..bar.yaml")); err != nil {
+		t.Errorf("foo..bar.yaml should be extracted: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(extractDir, "evil.yaml")); err == nil {
+		t.Error("entry escaping the destination should not be extracted")
+	}
+}
+
+func TestDownloadArchive_FetchesGivenRef(t *testing.T) {
+	const sha = "0123456789abcdef0123456789abcdef01234567"
+	cases := []struct {
+		name     string
+		repoType repository.RepositoryType
+		wantPath string
+		wantSHA  string
+	}{
+		{"github", repository.GitHub, "/api/v3/repos/o/r/tarball/" + sha, ""},
+		{"gitlab", repository.GitLab, "/api/v4/projects/o%2Fr/repository/archive.tar.gz", sha},
+		{"gitea", repository.Gitea, "/api/v1/repos/o/r/archive/" + sha + ".tar.gz", ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.EscapedPath() != c.wantPath {
+					t.Errorf("request path = %s, want %s", r.URL.EscapedPath(), c.wantPath)
+				}
+				if got := r.URL.Query().Get("sha"); got != c.wantSHA {
+					t.Errorf("sha query = %q, want %q", got, c.wantSHA)
+				}
+				w.Write([]byte("archive"))
+			}))
+			defer srv.Close()
+
+			b := NewBuilder("", "")
+			parts := &NodeIDParts{Type: c.repoType, Owner: "o", Repo: "r", Path: "overlay", Ref: "main"}
+			archivePath, err := b.downloadArchive(context.Background(), t.TempDir(), parts, srv.URL, sha)
+			if err != nil {
+				t.Fatalf("downloadArchive: %v", err)
+			}
+			if data, _ := os.ReadFile(archivePath); string(data) != "archive" {
+				t.Errorf("archive content = %q, want archive", data)
+			}
+		})
+	}
+}
+
+func TestDownloadArchive_BitbucketServer(t *testing.T) {
+	const sha = "0123456789abcdef0123456789abcdef01234567"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if want := "/rest/api/1.0/projects/PRJ/repos/r/archive"; r.URL.Path != want {
+			t.Errorf("request path = %s, want %s", r.URL.Path, want)
+		}
+		q := r.URL.Query()
+		if q.Get("at") != sha || q.Get("format") != "tar.gz" || q.Get("prefix") != "r/" {
+			t.Errorf("query = %s, want at=%s format=tar.gz prefix=r/", r.URL.RawQuery, sha)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q, want bearer token", got)
+		}
+		w.Write([]byte("archive"))
+	}))
+	defer srv.Close()
+
+	b := NewBuilder("", "")
+	b.SetToken(repository.Bitbucket, "secret")
+	parts := &NodeIDParts{Type: repository.Bitbucket, Owner: "PRJ", Repo: "r", Path: "overlay", Ref: "main"}
+	archivePath, err := b.downloadArchive(context.Background(), t.TempDir(), parts, srv.URL, sha)
+	if err != nil {
+		t.Fatalf("downloadArchive: %v", err)
+	}
+	if data, _ := os.ReadFile(archivePath); string(data) != "archive" {
+		t.Errorf("archive content = %q, want archive", data)
+	}
+}
+
+func TestDownloadArchive_AzureDevOpsZip(t *testing.T) {
+	const sha = "0123456789abcdef0123456789abcdef01234567"
+	var zipData bytes.Buffer
+	zw := zip.NewWriter(&zipData)
+	for name, content := range map[string]string{
+		"deploy/overlay/kustomization.yaml": "resources: []\n",
+		"README.md":                         "readme\n",
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("zip Create: %v", err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zip Close: %v", err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if want := "/contoso/platform/_apis/git/repositories/gitops/items"; r.URL.Path != want {
+			t.Errorf("request path = %s, want %s", r.URL.Path, want)
+		}
+		q := r.URL.Query()
+		if q.Get("versionDescriptor.version") != sha || q.Get("versionDescriptor.versionType") != "commit" || q.Get("$format") != "zip" {
+			t.Errorf("query = %s, want commit %s as zip", r.URL.RawQuery, sha)
+		}
+		w.Write(zipData.Bytes())
+	}))
+	defer srv.Close()
+
+	b := NewBuilder("", "")
+	parts := &NodeIDParts{Type: repository.AzureDevOps, Owner: "contoso/platform", Repo: "gitops", Path: "deploy/overlay", Ref: "main"}
+	dir := t.TempDir()
+	archivePath, err := b.downloadArchive(context.Background(), dir, parts, srv.URL, sha)
+	if err != nil {
+		t.Fatalf("downloadArchive: %v", err)
+	}
+	rootDir, err := extractZip(archivePath, dir)
+	if err != nil {
+		t.Fatalf("extractZip: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, rootDir, "deploy", "overlay", "kustomization.yaml"))
+	if err != nil {
+		t.Fatalf("extracted kustomization: %v", err)
+	}
+	if string(data) != "resources: []\n" {
+		t.Errorf("extracted content = %q", data)
+	}
+}
@@ -19,7 +19,8 @@ type NodeIDParts struct {
 
 // ParseNodeID parses a node ID into repo type, owner, repo, path and ref.
 // Returns an error if the format is invalid.
-// Formats: github:owner/repo/path@ref, gitlab:owner/repo/path@ref, local:path@ref
+// Formats: github:owner/repo/path@ref, gitlab:owner/repo/path@ref, bitbucket:owner/repo/path@ref,
+// gitea:owner/repo/path@ref, azure:org/project/repo/path@ref, git:owner/repo/path@ref, local:path@ref
 func ParseNodeID(nodeID string) (*NodeIDParts, error) {
 	colon := strings.Index(nodeID, ":")
 	if colon <= 0 || colon == len(nodeID)-1 {
@@ -52,10 +53,37 @@ func ParseNodeID(nodeID string) (*NodeIDParts, error) {
 		repoType = repository.GitHub
 	case "gitlab":
 		repoType = repository.GitLab
+	case "bitbucket":
+		repoType = repository.Bitbucket
+	case "gitea":
+		repoType = repository.Gitea
+	case "azure":
+		repoType = repository.AzureDevOps
+	case "git":
+		repoType = repository.Git
 	default:
 		return nil, fmt.Errorf("unsupported repository type in node ID: %s", typStr)
 	}
 
+	if repoType == repository.AzureDevOps {
+		// Azure DevOps owners are organization/project
+		parts := strings.SplitN(beforeRef, "/", 4)
+		if len(parts) < 3 {
+			return nil, fmt.Errorf("invalid node ID: expected org/project/repo[/path]")
+		}
+		path := ""
+		if len(parts) == 4 {
+			path = parts[3]
+		}
+		return &NodeIDParts{
+			Type:  repoType,
+			Owner: parts[0] + "/" + parts[1],
+			Repo:  parts[2],
+			Path:  strings.Trim(path, "/"),
+			Ref:   ref,
+		}, nil
+	}
+
 	parts := strings.SplitN(beforeRef, "/", 3) // owner, repo, path (path may contain /)
 	if len(parts) < 2 {
 		return nil, fmt.Errorf("invalid node ID: expected owner/repo[/path]")
@@ -75,3 +103,25 @@ func ParseNodeID(nodeID string) (*NodeIDParts, error) {
 		Ref:   ref,
 	}, nil
 }
+
+// splitGitRepo re-splits the owner, repo and path of a plain git node with the clone URL
+// of its repository (see types.Graph.CloneURLs): git owners may contain "/" (e.g.
+// group/subgroup), which ParseNodeID cannot tell apart from the path.
+func (n *NodeIDParts) splitGitRepo(cloneURL string) error {
+	info, err := repository.NewGitRepository(cloneURL)
+	if err != nil {
+		return err
+	}
+	repoPath := info.Owner + "/" + info.Repo
+	full := n.Owner + "/" + n.Repo
+	if n.Path != "" {
+		full += "/" + n.Path
+	}
+	if full != repoPath && !strings.HasPrefix(full, repoPath+"/") {
+		return fmt.Errorf("node is not in repository %s", repoPath)
+	}
+	n.Owner = info.Owner
+	n.Repo = info.Repo
+	n.Path = strings.Trim(full[len(repoPath):], "/")
+	return nil
+}
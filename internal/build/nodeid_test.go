@@ -35,6 +35,55 @@ func TestParseNodeID(t *testing.T) {
 				Ref:   "v1.0",
 			},
 		},
+		{
+			name:   "plain git with path",
+			nodeID: "git:org/repo/deploy/base@main",
+			want: &NodeIDParts{
+				Type:  repository.Git,
+				Owner: "org",
+				Repo:  "repo",
+				Path:  "deploy/base",
+				Ref:   "main",
+			},
+		},
+		{
+			name:   "bitbucket server project",
+			nodeID: "bitbucket:PRJ/repo/deploy/overlay@develop",
+			want: &NodeIDParts{
+				Type:  repository.Bitbucket,
+				Owner: "PRJ",
+				Repo:  "repo",
+				Path:  "deploy/overlay",
+				Ref:   "develop",
+			},
+		},
+		{
+			name:   "gitea",
+			nodeID: "gitea:org/repo/deploy@v2",
+			want: &NodeIDParts{
+				Type:  repository.Gitea,
+				Owner: "org",
+				Repo:  "repo",
+				Path:  "deploy",
+				Ref:   "v2",
+			},
+		},
+		{
+			name:   "azure devops org/project owner",
+			nodeID: "azure:contoso/platform/gitops/deploy/overlay@main",
+			want: &NodeIDParts{
+				Type:  repository.AzureDevOps,
+				Owner: "contoso/platform",
+				Repo:  "gitops",
+				Path:  "deploy/overlay",
+				Ref:   "main",
+			},
+		},
+		{
+			name:    "azure devops missing repo",
+			nodeID:  "azure:contoso/platform@main",
+			wantErr: true,
+		},
 		{
 			name:    "missing colon",
 			nodeID:  "githubfoo/bar@main",
@@ -47,7 +96,7 @@ func TestParseNodeID(t *testing.T) {
 		},
 		{
 			name:    "unsupported type",
-			nodeID:  "bitbucket:foo/bar@main",
+			nodeID:  "svn:foo/bar@main",
 			wantErr: true,
 		},
 		{
@@ -94,3 +143,33 @@ func TestParseNodeID(t *testing.T) {
 		})
 	}
 }
+
+func TestSplitGitRepo_NestedOwner(t *testing.T) {
+	tests := []struct {
+		name     string
+		cloneURL string
+	}{
+		{"scp", "git@git.example.com:group/sub/app.git"},
+		{"ssh", "ssh://git@git.example.com/group/sub/app.git"},
+		{"https without .git", "https://git.example.com/group/sub/app"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts, err := ParseNodeID("git:group/sub/app/deploy/overlay@main")
+			if err != nil {
+				t.Fatalf("ParseNodeID: %v", err)
+			}
+			if err := parts.splitGitRepo(tt.cloneURL); err != nil {
+				t.Fatalf("splitGitRepo: %v", err)
+			}
+			if parts.Owner != "group/sub" || parts.Repo != "app" || parts.Path != "deploy/overlay" {
+				t.Errorf("got owner %q, repo %q, path %q; want group/sub, app, deploy/overlay", parts.Owner, parts.Repo, parts.Path)
+			}
+		})
+	}
+
+	parts, _ := ParseNodeID("git:other/app/deploy@main")
+	if err := parts.splitGitRepo("git@git.example.com:group/sub/app.git"); err == nil {
+		t.Error("expected error for a node outside the clone URL's repository")
+	}
+}
@@ -0,0 +1,217 @@
+// Package cache stores fetched repository content on disk, keyed by host, owner, repo,
+// commit SHA and path. Content at a commit never changes, so entries never expire; the
+// store is bounded by size and evicts the least recently used entries first.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultMaxBytes is the default size limit of a Store (512 MB).
+const DefaultMaxBytes = 512 << 20
+
+// Key identifies a cached entry: content of Path in Owner/Repo on Host at commit SHA.
+// Path may carry a kind prefix (e.g. "list:") to cache derived content of the commit.
+type Key struct {
+	Host  string
+	Owner string
+	Repo  string
+	SHA   string
+	Path  string
+}
+
+// String returns the key as host/owner/repo@sha:path (for logs).
+func (k Key) String() string {
+	return fmt.Sprintf("%s/%s/%s@%s:%s", k.Host, k.Owner, k.Repo, k.SHA, k.Path)
+}
+
+// Store is a size-bounded on-disk content cache. It is safe for concurrent use.
+type Store struct {
+	dir      string
+	maxBytes int64 // <= 0: unlimited
+
+	// mu protects size and serializes writes and evictions.
+	mu   sync.Mutex
+	size int64
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// New opens (creating it if needed) a Store in dir, limited to maxBytes (<= 0 for no limit).
+// Entries left by a previous run are kept and count toward the limit.
+func New(dir string, maxBytes int64) (*Store, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("cache directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	s := &Store{dir: dir, maxBytes: maxBytes}
+	entries, err := s.entries()
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan cache directory: %w", err)
+	}
+	for _, e := range entries {
+		s.size += e.size
+	}
+	log.Printf("Content cache: %s (%d entries, %d bytes)", dir, len(entries), s.size)
+	s.evict()
+	return s, nil
+}
+
+// segment escapes a key component for use as a single directory name.
+func segment(s string) string {
+	switch s {
+	case "", ".", "..":
+		return "_" + s
+	}
+	return url.PathEscape(s)
+}
+
+// path returns the file of key: dir/host/owner/repo/sha/sha256(path).
+func (s *Store) path(key Key) string {
+	sum := sha256.Sum256([]byte(key.Path))
+	return filepath.Join(s.dir, segment(key.Host), segment(key.Owner), segment(key.Repo),
+		segment(key.SHA), hex.EncodeToString(sum[:]))
+}
+
+// Get returns the content stored for key. A hit marks the entry as recently used.
+func (s *Store) Get(key Key) ([]byte, bool) {
+	p := s.path(key)
+	data, err := os.ReadFile(p)
+	if err != nil {
+		s.misses.Add(1)
+		return nil, false
+	}
+	now := time.Now()
+	_ = os.Chtimes(p, now, now)
+	s.hits.Add(1)
+	return data, true
+}
+
+// Put stores data for key, evicting least recently used entries when over the size limit.
+// Entries larger than the limit are not stored.
+func (s *Store) Put(key Key, data []byte) error {
+	if s.maxBytes > 0 && int64(len(data)) > s.maxBytes {
+		return nil
+	}
+	p := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var previous int64
+	if info, err := os.Stat(p); err == nil {
+		previous = info.Size()
+	}
+	// Write then rename so concurrent readers never see a partial entry
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	s.size += int64(len(data)) - previous
+	s.evict()
+	return nil
+}
+
+// Stats returns the number of hits and misses since the Store was opened.
+func (s *Store) Stats() (hits, misses int64) {
+	return s.hits.Load(), s.misses.Load()
+}
+
+// Size returns the total size in bytes of the stored entries.
+func (s *Store) Size() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.size
+}
+
+// entry is a cached file with its size and last use.
+type entry struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// entries lists the cached files.
+func (s *Store) entries() ([]entry, error) {
+	var entries []entry
+	err := filepath.WalkDir(s.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil // removed meanwhile
+		}
+		entries = append(entries, entry{path: path, size: info.Size(), modTime: info.ModTime()})
+		return nil
+	})
+	return entries, err
+}
+
+// evictTarget is the size eviction brings a full store down to: 90% of maxBytes, so that
+// the Puts following an eviction do not each walk the cache directory again.
+func (s *Store) evictTarget() int64 {
+	return s.maxBytes - s.maxBytes/10
+}
+
+// evict removes the least recently used entries once the store exceeds its limit, down to
+// evictTarget. Callers hold mu (or own the Store exclusively).
+func (s *Store) evict() {
+	if s.maxBytes <= 0 || s.size <= s.maxBytes {
+		return
+	}
+	entries, err := s.entries()
+	if err != nil {
+		log.Printf("Warning: content cache eviction failed: %v", err)
+		return
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].modTime.Before(entries[j].modTime) })
+
+	// Recount from disk: entries may have been removed outside the store
+	s.size = 0
+	for _, e := range entries {
+		s.size += e.size
+	}
+	evicted := 0
+	for _, e := range entries {
+		if s.size <= s.evictTarget() {
+			break
+		}
+		if err := os.Remove(e.path); err != nil {
+			continue
+		}
+		s.size -= e.size
+		evicted++
+	}
+	log.Printf("Content cache: evicted %d entries (%d bytes left)", evicted, s.size)
+}
@@ -0,0 +1,148 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testKey(path string) Key {
+	return Key{Host: "github.com", Owner: "org", Repo: "repo", SHA: strings.Repeat("a", 40), Path: path}
+}
+
+func TestStoreGetPut(t *testing.T) {
+	s, err := New(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, ok := s.Get(testKey("file:a.yaml")); ok {
+		t.Fatal("Get on empty store should miss")
+	}
+	if err := s.Put(testKey("file:a.yaml"), []byte("a: 1")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	data, ok := s.Get(testKey("file:a.yaml"))
+	if !ok || string(data) != "a: 1" {
+		t.Errorf("Get = %q, %v; want %q, true", data, ok, "a: 1")
+	}
+	// Another commit is another entry
+	other := testKey("file:a.yaml")
+	other.SHA = strings.Repeat("b", 40)
+	if _, ok := s.Get(other); ok {
+		t.Error("Get at another commit should miss")
+	}
+	if hits, misses := s.Stats(); hits != 1 || misses != 2 {
+		t.Errorf("Stats = %d hits, %d misses; want 1, 2", hits, misses)
+	}
+	if got := s.Size(); got != 4 {
+		t.Errorf("Size = %d, want 4", got)
+	}
+}
+
+func TestStorePersists(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New(dir, 0)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := s.Put(testKey("file:a.yaml"), []byte("content")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	reopened, err := New(dir, 0)
+	if err != nil {
+		t.Fatalf("New (reopen): %v", err)
+	}
+	if data, ok := reopened.Get(testKey("file:a.yaml")); !ok || string(data) != "content" {
+		t.Errorf("Get after reopen = %q, %v", data, ok)
+	}
+	if got := reopened.Size(); got != int64(len("content")) {
+		t.Errorf("Size after reopen = %d, want %d", got, len("content"))
+	}
+}
+
+func TestStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	s, err := New(t.TempDir(), 10)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	old := time.Now().Add(-time.Hour)
+	for i, name := range []string{"first", "second"} {
+		if err := s.Put(testKey(name), []byte("1234")); err != nil {
+			t.Fatalf("Put(%s): %v", name, err)
+		}
+		// Distinct, ordered use times regardless of filesystem timestamp granularity
+		at := old.Add(time.Duration(i) * time.Minute)
+		os.Chtimes(s.path(testKey(name)), at, at)
+	}
+	// Using "first" makes "second" the least recently used entry
+	if _, ok := s.Get(testKey("first")); !ok {
+		t.Fatal("Get(first) should hit")
+	}
+	if err := s.Put(testKey("third"), []byte("1234")); err != nil {
+		t.Fatalf("Put(third): %v", err)
+	}
+
+	if _, ok := s.Get(testKey("second")); ok {
+		t.Error("second should have been evicted")
+	}
+	for _, name := range []string{"first", "third"} {
+		if _, ok := s.Get(testKey(name)); !ok {
+			t.Errorf("%s should still be cached", name)
+		}
+	}
+	if got := s.Size(); got != 8 {
+		t.Errorf("Size = %d, want 8", got)
+	}
+}
+
+func TestStoreEvictsBelowLimit(t *testing.T) {
+	s, err := New(t.TempDir(), 100)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for i := 0; i < 11; i++ {
+		if err := s.Put(testKey(fmt.Sprintf("entry%d", i)), []byte("0123456789")); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+	// Eviction frees room for the next Puts instead of stopping at the limit
+	if got := s.Size(); got != 90 {
+		t.Errorf("Size = %d, want 90 (evicted down to 90%% of the limit)", got)
+	}
+	if err := s.Put(testKey("entry11"), []byte("0123456789")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if got := s.Size(); got != 100 {
+		t.Errorf("Size = %d, want 100 (no eviction while within the limit)", got)
+	}
+}
+
+func TestStoreSkipsOversizedEntries(t *testing.T) {
+	s, err := New(t.TempDir(), 4)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := s.Put(testKey("big"), []byte("12345")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if _, ok := s.Get(testKey("big")); ok {
+		t.Error("entry larger than the limit should not be stored")
+	}
+}
+
+func TestStorePathStaysInDir(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New(dir, 0)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	p := s.path(Key{Host: "..", Owner: "../..", Repo: ".", SHA: "", Path: "../../etc/passwd"})
+	rel, err := filepath.Rel(dir, p)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		t.Errorf("path %q escapes cache directory %q", p, dir)
+	}
+}
@@ -0,0 +1,58 @@
+package export
+
+import (
+	"github.com/cjeanner/kustomap/internal/types"
+)
+
+// nodeAttrs are the node attributes carried by the graph tooling formats (GraphML, JGF).
+type nodeAttrs struct {
+	Label         string   `json:"-"`
+	Type          string   `json:"type,omitempty"`
+	Path          string   `json:"path,omitempty"`
+	Repo          string   `json:"repo,omitempty"`
+	Ref           string   `json:"ref,omitempty"`
+	Commit        string   `json:"commit,omitempty"`
+	BaseURL       string   `json:"baseURL,omitempty"`
+	Error         string   `json:"error,omitempty"`
+	ErrorCategory string   `json:"errorCategory,omitempty"`
+	FloatingRef   bool     `json:"floatingRef,omitempty"`
+	Warnings      []string `json:"warnings,omitempty"`
+}
+
+// attrsOf returns the attributes of node data in graph.
+func attrsOf(graph *types.Graph, data *types.ElementData) nodeAttrs {
+	repo, ref := nodeRepo(graph, data.ID)
+	label := data.Label
+	if label == "" {
+		label = data.ID
+	}
+	errMsg, _ := data.Content["error"].(string)
+	return nodeAttrs{
+		Label:         label,
+		Type:          data.Type,
+		Path:          data.Path,
+		Repo:          repo,
+		Ref:           ref,
+		Commit:        graph.CommitSHAs[data.ID],
+		BaseURL:       graph.BaseURLs[data.ID],
+		Error:         errMsg,
+		ErrorCategory: data.ErrorCategory,
+		FloatingRef:   data.FloatingRef,
+		Warnings:      data.Warnings,
+	}
+}
+
+// uniqueNodes returns the node data of graph in element order, without duplicate IDs.
+func uniqueNodes(graph *types.Graph) []*types.ElementData {
+	seen := make(map[string]bool)
+	var nodes []*types.ElementData
+	for i := range graph.Elements {
+		e := &graph.Elements[i]
+		if e.Group != "nodes" || seen[e.Data.ID] {
+			continue
+		}
+		seen[e.Data.ID] = true
+		nodes = append(nodes, &e.Data)
+	}
+	return nodes
+}
@@ -0,0 +1,79 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cjeanner/kustomap/internal/types"
+)
+
+// ToD2 converts a kustomize dependency graph to a D2 diagram (https://d2lang.com), with
+// the same layout as ToMermaidWithOptions: one container per repository@ref, a class per
+// node type (error nodes in red), edge types as labels (dashed and dotted edge types
+// dashed) and GitHub/GitLab nodes linked to their tree URL.
+func ToD2(graph *types.Graph, opts Options) string {
+	var b strings.Builder
+	if opts.Direction == "LR" {
+		b.WriteString("direction: right\n")
+	} else {
+		b.WriteString("direction: down\n")
+	}
+	if graph == nil || len(graph.Elements) == 0 {
+		b.WriteString("empty: \"empty graph\"\n")
+		return b.String()
+	}
+
+	d := newDiagram(graph, opts)
+	b.WriteString("classes: {\n")
+	for _, class := range d.classOrder {
+		s := d.classes[class]
+		fmt.Fprintf(&b, "  %s: {style: {fill: \"%s\"; stroke: \"%s\"; font-color: \"%s\"}}\n", class, s.fill, s.border, s.font)
+	}
+	b.WriteString("}\n")
+
+	// Edges between containers reference nodes by their path (g0.n0)
+	paths := make(map[string]string)
+	for i, group := range d.groupOrder {
+		indent := ""
+		if group != "" {
+			fmt.Fprintf(&b, "g%d: \"%s\" {\n", i, escapeD2(group))
+			indent = "  "
+		}
+		for _, data := range d.groups[group] {
+			id := d.safeIDs[data.ID]
+			paths[id] = id
+			if group != "" {
+				paths[id] = fmt.Sprintf("g%d.%s", i, id)
+			}
+			fmt.Fprintf(&b, "%s%s: \"%s\" {class: %s", indent, id, escapeD2(nodeLabel(data)), nodeClass(*data))
+			if link := treeURL(graph, data); link != "" {
+				fmt.Fprintf(&b, "; link: \"%s\"", escapeD2(link))
+			}
+			b.WriteString("}\n")
+		}
+		if group != "" {
+			b.WriteString("}\n")
+		}
+	}
+
+	for _, e := range d.edges(graph) {
+		fmt.Fprintf(&b, "%s -> %s", paths[e.source], paths[e.target])
+		if e.edgeType != "" {
+			fmt.Fprintf(&b, ": \"%s\"", escapeD2(e.edgeType))
+		}
+		if !e.solid() {
+			b.WriteString(" {style.stroke-dash: 3}")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// escapeD2 escapes backslashes, double quotes and newlines for use inside "..." in D2.
+func escapeD2(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"\n", `\n`,
+	).Replace(s)
+}
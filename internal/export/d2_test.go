@@ -0,0 +1,51 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cjeanner/kustomap/internal/types"
+)
+
+func TestToD2_NilOrEmpty(t *testing.T) {
+	for _, g := range []*types.Graph{nil, {Elements: []types.Element{}}} {
+		if got := ToD2(g, Options{}); !strings.Contains(got, "empty graph") {
+			t.Errorf("ToD2(%v) = %q, want substring 'empty graph'", g, got)
+		}
+	}
+}
+
+func TestToD2(t *testing.T) {
+	want := `direction: down
+classes: {
+  overlay: {style: {fill: "#3498db"; stroke: "#333333"; font-color: "black"}}
+  resource: {style: {fill: "#3498db"; stroke: "#333333"; font-color: "black"}}
+  base: {style: {fill: "#2ecc71"; stroke: "#333333"; font-color: "black"}}
+  error: {style: {fill: "#e74c3c"; stroke: "#c0392b"; font-color: "white"}}
+}
+g0: "github:o/r@main" {
+  n0: "prod" {class: overlay; link: "https://github.com/o/r/tree/main/overlays/prod"}
+  n1: "deploy.yaml" {class: resource; link: "https://github.com/o/r/blob/main/overlays/prod/deploy.yaml"}
+}
+g1: "gitlab:g/p@v1" {
+  n2: "base \"v1\"" {class: base; link: "https://gitlab.example.com/g/p/-/tree/v1/base"}
+}
+n3: "missing" {class: error}
+g0.n0 -> g0.n1: "resource"
+g0.n0 -> g1.n2: "patch" {style.stroke-dash: 3}
+g0.n0 -> n3
+`
+	if got := ToD2(diagramTestGraph(), Options{}); got != want {
+		t.Errorf("ToD2 mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestToD2_Options(t *testing.T) {
+	got := ToD2(diagramTestGraph(), Options{Direction: "LR", HideFiles: true})
+	if !strings.Contains(got, "direction: right") {
+		t.Errorf("expected left-right direction: %s", got)
+	}
+	if strings.Contains(got, "deploy.yaml") {
+		t.Errorf("expected plain-file resource to be hidden: %s", got)
+	}
+}
@@ -0,0 +1,152 @@
+package export
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/cjeanner/kustomap/internal/types"
+)
+
+// diagram is the node layout shared by the diagram exporters (Mermaid, DOT, PlantUML, D2):
+// nodes get a safe ID (n0, n1, ...) and are grouped by repository@ref, and each node
+// class (see nodeClass) is styled once.
+type diagram struct {
+	safeIDs    map[string]string // node ID -> safe ID
+	groups     map[string][]*types.ElementData
+	groupOrder []string // "" groups nodes that do not name a repository
+	classes    map[string]nodeStyle
+	classOrder []string
+}
+
+// newDiagram lays out the nodes of graph in element order, leaving out plain files when
+// opts.HideFiles is set.
+func newDiagram(graph *types.Graph, opts Options) *diagram {
+	d := &diagram{
+		safeIDs: make(map[string]string),
+		groups:  make(map[string][]*types.ElementData),
+		classes: make(map[string]nodeStyle),
+	}
+	for _, data := range uniqueNodes(graph) {
+		if opts.HideFiles && isPlainFile(data) {
+			continue
+		}
+		d.safeIDs[data.ID] = fmt.Sprintf("n%d", len(d.safeIDs))
+		group := repoGroup(graph, data.ID)
+		if _, ok := d.groups[group]; !ok {
+			d.groupOrder = append(d.groupOrder, group)
+		}
+		d.groups[group] = append(d.groups[group], data)
+		class := nodeClass(*data)
+		if _, ok := d.classes[class]; !ok {
+			d.classes[class] = styleForNode(*data)
+			d.classOrder = append(d.classOrder, class)
+		}
+	}
+	return d
+}
+
+// edges returns the edges of graph between laid out nodes, as safe IDs.
+func (d *diagram) edges(graph *types.Graph) []diagramEdge {
+	var edges []diagramEdge
+	for i := range graph.Elements {
+		e := &graph.Elements[i]
+		if e.Group != "edges" {
+			continue
+		}
+		src := d.safeIDs[e.Data.Source]
+		tgt := d.safeIDs[e.Data.Target]
+		if src == "" || tgt == "" {
+			continue
+		}
+		edges = append(edges, diagramEdge{source: src, target: tgt, edgeType: e.Data.EdgeType})
+	}
+	return edges
+}
+
+type diagramEdge struct {
+	source, target, edgeType string
+}
+
+// solid reports whether the edge is drawn with a solid line (dashed and dotted edge types
+// are drawn dashed in diagrams).
+func (e diagramEdge) solid() bool {
+	return styleForEdge(e.edgeType).line == "solid"
+}
+
+// nodeLabel returns the label of a node, or its ID when it has none.
+func nodeLabel(data *types.ElementData) string {
+	if data.Label == "" {
+		return data.ID
+	}
+	return data.Label
+}
+
+// nodeClass returns the style class of a node: its type, or rateLimited for errors
+// caused by an exhausted API quota (styled apart, as in the UI).
+func nodeClass(data types.ElementData) string {
+	if data.ErrorCategory == types.ErrorRateLimited {
+		return "rateLimited"
+	}
+	if _, ok := nodeStyles[data.Type]; ok {
+		return data.Type
+	}
+	return "other"
+}
+
+// isPlainFile reports whether a node is a plain file rather than a kustomization or a
+// generated object: manifest resources (YAML files listed in resources) and file nodes.
+func isPlainFile(data *types.ElementData) bool {
+	switch data.Type {
+	case "file":
+		return true
+	case "resource":
+		ext := strings.ToLower(path.Ext(data.Path))
+		return ext == ".yaml" || ext == ".yml" || ext == ".json"
+	}
+	return false
+}
+
+// treeURL returns the web URL of a GitHub or GitLab node (tree for directories, blob for
+// files), or "" for other hosts, error nodes and nodes without a path of their own
+// (generators, charts, inline patches and plugins).
+func treeURL(graph *types.Graph, data *types.ElementData) string {
+	switch data.Type {
+	case "error", "generator", "chart":
+		return ""
+	}
+	if strings.Contains(data.Path, "[") {
+		return "" // inline entry, e.g. overlay/patches[0]
+	}
+	repo, ref := nodeRepo(graph, data.ID)
+	typ, ownerRepo, ok := strings.Cut(repo, ":")
+	if !ok {
+		return ""
+	}
+	baseURL := strings.TrimSuffix(graph.BaseURLs[data.ID], "/")
+	kind := "tree"
+	if path.Ext(data.Path) != "" {
+		kind = "blob"
+	}
+	var prefix string
+	switch typ {
+	case "github":
+		if baseURL == "" {
+			baseURL = "https://github.com"
+		}
+		prefix = fmt.Sprintf("%s/%s/%s/", baseURL, ownerRepo, kind)
+	case "gitlab":
+		if baseURL == "" {
+			baseURL = "https://gitlab.com"
+		}
+		prefix = fmt.Sprintf("%s/%s/-/%s/", baseURL, ownerRepo, kind)
+	default:
+		return ""
+	}
+	link := prefix + url.PathEscape(ref)
+	if p := strings.Trim(data.Path, "/"); p != "" {
+		link += "/" + (&url.URL{Path: p}).EscapedPath()
+	}
+	return link
+}
@@ -0,0 +1,72 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cjeanner/kustomap/internal/types"
+)
+
+// ToDOT converts a kustomize dependency graph to Graphviz DOT (render with e.g.
+// `dot -Tsvg`), with the same layout as ToMermaidWithOptions: one cluster per
+// repository@ref, node types as shapes and colors (error nodes in red), edge types as
+// labels and line styles, and GitHub/GitLab nodes linked to their tree URL. Graphviz lays
+// out large graphs more readably than Mermaid.
+func ToDOT(graph *types.Graph, opts Options) string {
+	var b strings.Builder
+	b.WriteString("digraph kustomap {\n")
+	if opts.Direction == "LR" {
+		b.WriteString("  rankdir=LR;\n")
+	}
+	if graph == nil || len(graph.Elements) == 0 {
+		b.WriteString("  empty [label=\"empty graph\"];\n}\n")
+		return b.String()
+	}
+
+	d := newDiagram(graph, opts)
+	b.WriteString("  node [style=\"rounded,filled\", fontname=\"Helvetica\", fontsize=10];\n")
+	b.WriteString("  edge [fontname=\"Helvetica\", fontsize=9];\n")
+
+	for i, group := range d.groupOrder {
+		indent := "  "
+		if group != "" {
+			fmt.Fprintf(&b, "  subgraph cluster_%d {\n", i)
+			fmt.Fprintf(&b, "    label=\"%s\";\n", escapeDOT(group))
+			b.WriteString("    style=\"rounded,dashed\";\n    color=\"#7f8c8d\";\n")
+			indent = "    "
+		}
+		for _, data := range d.groups[group] {
+			s := d.classes[nodeClass(*data)]
+			fmt.Fprintf(&b, "%s%s [label=\"%s\", tooltip=\"%s\", shape=%s, fillcolor=\"%s\", color=\"%s\", fontcolor=\"%s\"",
+				indent, d.safeIDs[data.ID], escapeDOT(nodeLabel(data)), escapeDOT(data.ID), s.dotShape, s.fill, s.border, s.font)
+			if link := treeURL(graph, data); link != "" {
+				fmt.Fprintf(&b, ", URL=\"%s\"", escapeDOT(link))
+			}
+			b.WriteString("];\n")
+		}
+		if group != "" {
+			b.WriteString("  }\n")
+		}
+	}
+
+	for _, e := range d.edges(graph) {
+		s := styleForEdge(e.edgeType)
+		attrs := fmt.Sprintf("style=%s, color=\"%s\"", s.line, s.color)
+		if e.edgeType != "" {
+			attrs = fmt.Sprintf("label=\"%s\", %s", escapeDOT(e.edgeType), attrs)
+		}
+		fmt.Fprintf(&b, "  %s -> %s [%s];\n", e.source, e.target, attrs)
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// escapeDOT escapes backslashes, double quotes and newlines for use inside "..." in DOT.
+func escapeDOT(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"\n", `\n`,
+	).Replace(s)
+}
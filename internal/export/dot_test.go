@@ -0,0 +1,102 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cjeanner/kustomap/internal/types"
+)
+
+func TestToDOT_NilOrEmpty(t *testing.T) {
+	for _, g := range []*types.Graph{nil, {Elements: []types.Element{}}} {
+		if got := ToDOT(g, Options{}); !strings.HasPrefix(got, "digraph") || !strings.Contains(got, "empty graph") {
+			t.Errorf("ToDOT(%v) = %q, want a digraph with 'empty graph'", g, got)
+		}
+	}
+}
+
+func TestToDOT_ClustersAndStyles(t *testing.T) {
+	g := &types.Graph{
+		Elements: []types.Element{
+			{Group: "nodes", Data: types.ElementData{ID: "github:o/r/overlay@main", Label: "overlay", Type: "overlay", Path: "overlay"}},
+			{Group: "nodes", Data: types.ElementData{ID: "github:o/r/overlay/patch.yaml@main", Label: "patch.yaml", Type: "patch", Path: "overlay/patch.yaml"}},
+			{Group: "nodes", Data: types.ElementData{ID: "gitlab:g/lib/base@v1", Label: `base "v1"`, Type: "resource", Path: "base"}},
+			{Group: "nodes", Data: types.ElementData{ID: "gitlab:g/lib/missing@v1", Label: "missing", Type: "error"}},
+			{Group: "edges", Data: types.ElementData{Source: "github:o/r/overlay@main", Target: "gitlab:g/lib/base@v1", EdgeType: "resource"}},
+			{Group: "edges", Data: types.ElementData{Source: "github:o/r/overlay@main", Target: "github:o/r/overlay/patch.yaml@main", EdgeType: "patch"}},
+			{Group: "edges", Data: types.ElementData{Source: "github:o/r/overlay@main", Target: "gitlab:g/lib/missing@v1"}},
+		},
+	}
+	want := `digraph kustomap {
+  node [style="rounded,filled", fontname="Helvetica", fontsize=10];
+  edge [fontname="Helvetica", fontsize=9];
+  subgraph cluster_0 {
+    label="github:o/r@main";
+    style="rounded,dashed";
+    color="#7f8c8d";
+    n0 [label="overlay", tooltip="github:o/r/overlay@main", shape=box, fillcolor="#3498db", color="#333333", fontcolor="black", URL="https://github.com/o/r/tree/main/overlay"];
+    n1 [label="patch.yaml", tooltip="github:o/r/overlay/patch.yaml@main", shape=cds, fillcolor="#e67e22", color="#333333", fontcolor="black", URL="https://github.com/o/r/blob/main/overlay/patch.yaml"];
+  }
+  subgraph cluster_1 {
+    label="gitlab:g/lib@v1";
+    style="rounded,dashed";
+    color="#7f8c8d";
+    n2 [label="base \"v1\"", tooltip="gitlab:g/lib/base@v1", shape=box, fillcolor="#3498db", color="#333333", fontcolor="black", URL="https://gitlab.com/g/lib/-/tree/v1/base"];
+    n3 [label="missing", tooltip="gitlab:g/lib/missing@v1", shape=octagon, fillcolor="#e74c3c", color="#c0392b", fontcolor="white"];
+  }
+  n0 -> n2 [label="resource", style=solid, color="#95a5a6"];
+  n0 -> n1 [label="patch", style=dashed, color="#e67e22"];
+  n0 -> n3 [style=solid, color="#95a5a6"];
+}
+`
+	if got := ToDOT(g, Options{}); got != want {
+		t.Errorf("ToDOT mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestToDOT_Options(t *testing.T) {
+	got := ToDOT(diagramTestGraph(), Options{Direction: "LR", HideFiles: true})
+	if !strings.Contains(got, "rankdir=LR;") {
+		t.Errorf("expected left-right direction: %s", got)
+	}
+	if strings.Contains(got, "deploy.yaml") {
+		t.Errorf("expected plain-file resource to be hidden: %s", got)
+	}
+}
+
+func TestRepoGroup(t *testing.T) {
+	cases := map[string]string{
+		"github:o/r/overlays/prod@main": "github:o/r@main",
+		"github:o/r/@main":              "github:o/r@main",
+		"azure:org/proj/repo/base@dev":  "azure:org/proj/repo@dev",
+		"local:overlays/prod@feature/x": "local@feature/x",
+		"overlays/prod":                 "",
+		"github:o@main":                 "",
+	}
+	for id, want := range cases {
+		if got := repoGroup(&types.Graph{}, id); got != want {
+			t.Errorf("repoGroup(%q) = %q, want %q", id, got, want)
+		}
+	}
+
+	// Recorded repositories tell nested groups from the path
+	g := &types.Graph{Repos: map[string]string{"gitlab:a/b/c/deploy@v1": "gitlab:a/b/c"}}
+	if got := repoGroup(g, "gitlab:a/b/c/deploy@v1"); got != "gitlab:a/b/c@v1" {
+		t.Errorf("repoGroup(nested group) = %q, want gitlab:a/b/c@v1", got)
+	}
+}
+
+func TestTreeURL_NestedGroup(t *testing.T) {
+	id := "gitlab:a/b/c/deploy/overlay@v1"
+	g := &types.Graph{
+		Elements: []types.Element{{Group: "nodes", Data: types.ElementData{ID: id, Label: "overlay", Type: "overlay", Path: "deploy/overlay"}}},
+		BaseURLs: map[string]string{id: "https://gitlab.example.com"},
+		Repos:    map[string]string{id: "gitlab:a/b/c"},
+	}
+	if got, want := treeURL(g, &g.Elements[0].Data), "https://gitlab.example.com/a/b/c/-/tree/v1/deploy/overlay"; got != want {
+		t.Errorf("treeURL = %q, want %q", got, want)
+	}
+	if got := ToMermaidWithOptions(g, Options{}); !strings.Contains(got, `"gitlab:a/b/c@v1"`) {
+		t.Errorf("expected one subgraph for the nested group project: %s", got)
+	}
+}
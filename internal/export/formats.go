@@ -0,0 +1,49 @@
+package export
+
+import (
+	"sort"
+
+	"github.com/cjeanner/kustomap/internal/types"
+)
+
+// Format is an export format of a graph.
+type Format struct {
+	ContentType string
+	Extension   string // file name extension, without the leading dot
+	Render      func(*types.Graph, Options) (string, error)
+}
+
+// Formats are the export formats by name, as accepted by ?format= on the graph API and
+// -format on the command line (besides the graph JSON itself).
+var Formats = map[string]Format{
+	"mermaid":  {ContentType: "text/plain; charset=utf-8", Extension: "mmd", Render: infallibleWithOptions(ToMermaidWithOptions)},
+	"dot":      {ContentType: "text/vnd.graphviz; charset=utf-8", Extension: "dot", Render: infallibleWithOptions(ToDOT)},
+	"graphml":  {ContentType: "application/graphml+xml; charset=utf-8", Extension: "graphml", Render: withoutOptions(ToGraphML)},
+	"jgf":      {ContentType: "application/vnd.jgf+json", Extension: "jgf.json", Render: withoutOptions(ToJGF)},
+	"plantuml": {ContentType: "text/plain; charset=utf-8", Extension: "puml", Render: infallibleWithOptions(ToPlantUML)},
+	"d2":       {ContentType: "text/plain; charset=utf-8", Extension: "d2", Render: infallibleWithOptions(ToD2)},
+}
+
+// FormatNames returns the names of Formats, sorted.
+func FormatNames() []string {
+	names := make([]string, 0, len(Formats))
+	for name := range Formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// withoutOptions adapts an exporter that has no Options to Format.Render.
+func withoutOptions(render func(*types.Graph) (string, error)) func(*types.Graph, Options) (string, error) {
+	return func(graph *types.Graph, _ Options) (string, error) {
+		return render(graph)
+	}
+}
+
+// infallibleWithOptions adapts an exporter with Options that cannot fail to Format.Render.
+func infallibleWithOptions(render func(*types.Graph, Options) string) func(*types.Graph, Options) (string, error) {
+	return func(graph *types.Graph, opts Options) (string, error) {
+		return render(graph, opts), nil
+	}
+}
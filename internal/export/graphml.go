@@ -0,0 +1,120 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cjeanner/kustomap/internal/types"
+)
+
+// graphMLKeys declares the GraphML attributes, in output order.
+var graphMLKeys = []graphMLKey{
+	{ID: "label", For: "node", Name: "label", Type: "string"},
+	{ID: "type", For: "node", Name: "type", Type: "string"},
+	{ID: "path", For: "node", Name: "path", Type: "string"},
+	{ID: "repo", For: "node", Name: "repo", Type: "string"},
+	{ID: "ref", For: "node", Name: "ref", Type: "string"},
+	{ID: "commit", For: "node", Name: "commit", Type: "string"},
+	{ID: "baseURL", For: "node", Name: "baseURL", Type: "string"},
+	{ID: "error", For: "node", Name: "error", Type: "string"},
+	{ID: "errorCategory", For: "node", Name: "errorCategory", Type: "string"},
+	{ID: "floatingRef", For: "node", Name: "floatingRef", Type: "boolean"},
+	{ID: "warnings", For: "node", Name: "warnings", Type: "string"},
+	{ID: "edgeType", For: "edge", Name: "edgeType", Type: "string"},
+}
+
+type graphMLDoc struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// ToGraphML converts a kustomize dependency graph to GraphML (yEd, Gephi, NetworkX
+// read_graphml). Nodes keep their graph IDs and carry every attribute as <data>: type,
+// path, repo, ref, commit, base URL, error message and category, floating ref, warnings
+// (joined with "; "). Edges carry their edge type.
+func ToGraphML(graph *types.Graph) (string, error) {
+	doc := graphMLDoc{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys:  graphMLKeys,
+		Graph: graphMLGraph{ID: "kustomap", EdgeDefault: "directed"},
+	}
+	if graph != nil {
+		known := make(map[string]bool)
+		for _, data := range uniqueNodes(graph) {
+			known[data.ID] = true
+			a := attrsOf(graph, data)
+			node := graphMLNode{ID: data.ID}
+			add := func(key, value string) {
+				if value != "" {
+					node.Data = append(node.Data, graphMLData{Key: key, Value: value})
+				}
+			}
+			add("label", a.Label)
+			add("type", a.Type)
+			add("path", a.Path)
+			add("repo", a.Repo)
+			add("ref", a.Ref)
+			add("commit", a.Commit)
+			add("baseURL", a.BaseURL)
+			add("error", a.Error)
+			add("errorCategory", a.ErrorCategory)
+			if a.FloatingRef {
+				add("floatingRef", strconv.FormatBool(a.FloatingRef))
+			}
+			add("warnings", strings.Join(a.Warnings, "; "))
+			doc.Graph.Nodes = append(doc.Graph.Nodes, node)
+		}
+		for i := range graph.Elements {
+			e := &graph.Elements[i]
+			if e.Group != "edges" || !known[e.Data.Source] || !known[e.Data.Target] {
+				continue
+			}
+			edge := graphMLEdge{ID: fmt.Sprintf("e%d", len(doc.Graph.Edges)), Source: e.Data.Source, Target: e.Data.Target}
+			if e.Data.EdgeType != "" {
+				edge.Data = []graphMLData{{Key: "edgeType", Value: e.Data.EdgeType}}
+			}
+			doc.Graph.Edges = append(doc.Graph.Edges, edge)
+		}
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("encode GraphML: %w", err)
+	}
+	return xml.Header + string(out) + "\n", nil
+}
@@ -0,0 +1,87 @@
+package export
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/cjeanner/kustomap/internal/types"
+)
+
+// exportTestGraph has a remote overlay, a base at another commit and an error node.
+func exportTestGraph() *types.Graph {
+	return &types.Graph{
+		ID:      "550e8400-e29b-41d4-a716-446655440000",
+		Created: "2025-01-01T00:00:00Z",
+		Elements: []types.Element{
+			{Group: "nodes", Data: types.ElementData{ID: "github:o/r/overlay@main", Label: "overlay", Type: "overlay", Path: "overlay", FloatingRef: true}},
+			{Group: "nodes", Data: types.ElementData{ID: "gitlab:g/lib/base@v1", Label: "base", Type: "resource", Path: "base", Warnings: []string{"kind mismatch"}}},
+			{Group: "nodes", Data: types.ElementData{ID: "github:o/r/missing@main", Label: "missing", Type: "error", Path: "missing",
+				Content: map[string]interface{}{"error": "Rate limited: github.com"}, ErrorCategory: types.ErrorRateLimited}},
+			{Group: "edges", Data: types.ElementData{ID: "e1", Source: "github:o/r/overlay@main", Target: "gitlab:g/lib/base@v1", EdgeType: "resource"}},
+			{Group: "edges", Data: types.ElementData{ID: "e2", Source: "github:o/r/overlay@main", Target: "github:o/r/missing@main", EdgeType: "component"}},
+			{Group: "edges", Data: types.ElementData{ID: "e3", Source: "github:o/r/overlay@main", Target: "not-a-node"}},
+		},
+		BaseURLs:   map[string]string{"gitlab:g/lib/base@v1": "https://gitlab.example.com"},
+		CommitSHAs: map[string]string{"gitlab:g/lib/base@v1": strings.Repeat("a", 40)},
+	}
+}
+
+func TestToGraphML(t *testing.T) {
+	out, err := ToGraphML(exportTestGraph())
+	if err != nil {
+		t.Fatalf("ToGraphML: %v", err)
+	}
+	if !strings.HasPrefix(out, xml.Header) {
+		t.Errorf("output does not start with the XML header: %q", out[:40])
+	}
+
+	var doc graphMLDoc
+	if err := xml.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("output is not valid GraphML: %v", err)
+	}
+	if doc.Graph.EdgeDefault != "directed" || len(doc.Graph.Nodes) != 3 || len(doc.Graph.Edges) != 2 {
+		t.Fatalf("graph = %d nodes, %d edges (%s); want 3 nodes, 2 directed edges",
+			len(doc.Graph.Nodes), len(doc.Graph.Edges), doc.Graph.EdgeDefault)
+	}
+	data := func(node graphMLNode) map[string]string {
+		m := make(map[string]string)
+		for _, d := range node.Data {
+			m[d.Key] = d.Value
+		}
+		return m
+	}
+
+	base := data(doc.Graph.Nodes[1])
+	want := map[string]string{
+		"label": "base", "type": "resource", "path": "base", "repo": "gitlab:g/lib", "ref": "v1",
+		"commit": strings.Repeat("a", 40), "baseURL": "https://gitlab.example.com", "warnings": "kind mismatch",
+	}
+	for k, v := range want {
+		if base[k] != v {
+			t.Errorf("base %s = %q, want %q", k, base[k], v)
+		}
+	}
+	if overlay := data(doc.Graph.Nodes[0]); overlay["floatingRef"] != "true" {
+		t.Errorf("overlay floatingRef = %q, want true", overlay["floatingRef"])
+	}
+	missing := data(doc.Graph.Nodes[2])
+	if missing["error"] != "Rate limited: github.com" || missing["errorCategory"] != types.ErrorRateLimited {
+		t.Errorf("error node data = %v", missing)
+	}
+	edge := doc.Graph.Edges[0]
+	if edge.Source != "github:o/r/overlay@main" || edge.Target != "gitlab:g/lib/base@v1" || edge.Data[0].Value != "resource" {
+		t.Errorf("edge = %+v", edge)
+	}
+}
+
+func TestToGraphML_Empty(t *testing.T) {
+	out, err := ToGraphML(nil)
+	if err != nil {
+		t.Fatalf("ToGraphML(nil): %v", err)
+	}
+	var doc graphMLDoc
+	if err := xml.Unmarshal([]byte(out), &doc); err != nil || len(doc.Graph.Nodes) != 0 {
+		t.Errorf("ToGraphML(nil) = %q, %v; want an empty graph", out, err)
+	}
+}
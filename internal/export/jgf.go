@@ -0,0 +1,73 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/cjeanner/kustomap/internal/types"
+)
+
+// JSON Graph Format (https://jsongraphformat.info), version 2: nodes are an object keyed by ID.
+type jgfDoc struct {
+	Graph jgfGraph `json:"graph"`
+}
+
+type jgfGraph struct {
+	ID       string             `json:"id,omitempty"`
+	Type     string             `json:"type"`
+	Directed bool               `json:"directed"`
+	Metadata map[string]string  `json:"metadata,omitempty"`
+	Nodes    map[string]jgfNode `json:"nodes"`
+	Edges    []jgfEdge          `json:"edges"`
+}
+
+type jgfNode struct {
+	Label    string    `json:"label"`
+	Metadata nodeAttrs `json:"metadata"`
+}
+
+type jgfEdge struct {
+	Source   string `json:"source"`
+	Target   string `json:"target"`
+	Relation string `json:"relation,omitempty"`
+	Directed bool   `json:"directed"`
+}
+
+// ToJGF converts a kustomize dependency graph to JSON Graph Format v2 (e.g. for NetworkX
+// or custom tooling). Node metadata carries every attribute: type, path, repo, ref,
+// commit, base URL, error message and category, floating ref and warnings. The edge
+// relation is the edge type.
+func ToJGF(graph *types.Graph) (string, error) {
+	doc := jgfDoc{Graph: jgfGraph{Type: "kustomap", Directed: true, Nodes: map[string]jgfNode{}, Edges: []jgfEdge{}}}
+	if graph != nil {
+		doc.Graph.ID = graph.ID
+		if graph.Created != "" {
+			doc.Graph.Metadata = map[string]string{"created": graph.Created}
+		}
+		for _, data := range uniqueNodes(graph) {
+			a := attrsOf(graph, data)
+			doc.Graph.Nodes[data.ID] = jgfNode{Label: a.Label, Metadata: a}
+		}
+		for i := range graph.Elements {
+			e := &graph.Elements[i]
+			if e.Group != "edges" {
+				continue
+			}
+			if _, ok := doc.Graph.Nodes[e.Data.Source]; !ok {
+				continue
+			}
+			if _, ok := doc.Graph.Nodes[e.Data.Target]; !ok {
+				continue
+			}
+			doc.Graph.Edges = append(doc.Graph.Edges, jgfEdge{
+				Source: e.Data.Source, Target: e.Data.Target, Relation: e.Data.EdgeType, Directed: true,
+			})
+		}
+	}
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("encode JGF: %w", err)
+	}
+	return string(out) + "\n", nil
+}
@@ -0,0 +1,52 @@
+package export
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestToJGF(t *testing.T) {
+	out, err := ToJGF(exportTestGraph())
+	if err != nil {
+		t.Fatalf("ToJGF: %v", err)
+	}
+	var doc jgfDoc
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	g := doc.Graph
+	if !g.Directed || g.ID != "550e8400-e29b-41d4-a716-446655440000" || g.Metadata["created"] != "2025-01-01T00:00:00Z" {
+		t.Errorf("graph header = %+v", g)
+	}
+	if len(g.Nodes) != 3 || len(g.Edges) != 2 {
+		t.Fatalf("graph = %d nodes, %d edges; want 3, 2 (edges to unknown nodes dropped)", len(g.Nodes), len(g.Edges))
+	}
+
+	base := g.Nodes["gitlab:g/lib/base@v1"]
+	want := nodeAttrs{
+		Type: "resource", Path: "base", Repo: "gitlab:g/lib", Ref: "v1", Commit: strings.Repeat("a", 40),
+		BaseURL: "https://gitlab.example.com", Warnings: []string{"kind mismatch"},
+	}
+	if base.Label != "base" || !reflect.DeepEqual(base.Metadata, want) {
+		t.Errorf("base = %+v, want label base, metadata %+v", base, want)
+	}
+	missing := g.Nodes["github:o/r/missing@main"].Metadata
+	if missing.Error != "Rate limited: github.com" || missing.ErrorCategory != "rate_limited" || missing.Type != "error" {
+		t.Errorf("error node metadata = %+v", missing)
+	}
+	if e := g.Edges[1]; e.Source != "github:o/r/overlay@main" || e.Target != "github:o/r/missing@main" || e.Relation != "component" || !e.Directed {
+		t.Errorf("edge = %+v", e)
+	}
+}
+
+func TestToJGF_Empty(t *testing.T) {
+	out, err := ToJGF(nil)
+	if err != nil {
+		t.Fatalf("ToJGF(nil): %v", err)
+	}
+	if !strings.Contains(out, `"nodes": {}`) || !strings.Contains(out, `"edges": []`) {
+		t.Errorf("ToJGF(nil) = %s, want empty nodes and edges", out)
+	}
+}
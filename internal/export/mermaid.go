@@ -7,70 +7,78 @@ import (
 	"github.com/cjeanner/kustomap/internal/types"
 )
 
-// ToMermaid converts a kustomize dependency graph to Mermaid flowchart syntax.
-// Node types and edge types are preserved as labels where possible.
-// Safe for use in documentation (e.g. README, MkDocs, Docusaurus).
+// Options tune diagram exports. Formats that do not support an option ignore it.
+type Options struct {
+	Direction string // "TD" (top-down, default) or "LR" (left-right)
+	HideFiles bool   // leave out plain-file nodes (manifest resources, values and other files)
+}
+
+// ToMermaid converts a kustomize dependency graph to Mermaid flowchart syntax with the
+// default options.
 func ToMermaid(graph *types.Graph) string {
+	return ToMermaidWithOptions(graph, Options{})
+}
+
+// ToMermaidWithOptions converts a kustomize dependency graph to Mermaid flowchart syntax.
+// Nodes are grouped into one subgraph per repository@ref and styled per node type
+// (classDef; error nodes in red), edge types are kept as labels, and GitHub/GitLab nodes
+// link to their tree URL (click). Safe for use in documentation (e.g. README, MkDocs,
+// Docusaurus).
+func ToMermaidWithOptions(graph *types.Graph, opts Options) string {
+	direction := "TD"
+	if opts.Direction == "LR" {
+		direction = "LR"
+	}
 	if graph == nil || len(graph.Elements) == 0 {
-		return "flowchart TD\n  empty[\"empty graph\"]\n"
+		return "flowchart " + direction + "\n  empty[\"empty graph\"]\n"
 	}
 
-	nodeIDToSafe := make(map[string]string)
-	var nodeOrder []string
-	safeIndex := 0
-	for i := range graph.Elements {
-		e := &graph.Elements[i]
-		if e.Group != "nodes" {
-			continue
+	d := newDiagram(graph, opts)
+	var b strings.Builder
+	b.WriteString("flowchart " + direction + "\n")
+
+	// Output nodes: safeId["label"]:::class, inside a subgraph per repository@ref
+	for i, group := range d.groupOrder {
+		indent := "  "
+		if group != "" {
+			fmt.Fprintf(&b, "  subgraph g%d[\"%s\"]\n", i, escapeMermaidLabel(group))
+			indent = "    "
 		}
-		id := e.Data.ID
-		if _, ok := nodeIDToSafe[id]; ok {
-			continue
+		for _, data := range d.groups[group] {
+			fmt.Fprintf(&b, "%s%s[\"%s\"]:::%s\n", indent, d.safeIDs[data.ID], escapeMermaidLabel(nodeLabel(data)), nodeClass(*data))
+		}
+		if group != "" {
+			b.WriteString("  end\n")
 		}
-		safeID := fmt.Sprintf("n%d", safeIndex)
-		safeIndex++
-		nodeIDToSafe[id] = safeID
-		nodeOrder = append(nodeOrder, id)
 	}
 
-	var b strings.Builder
-	b.WriteString("flowchart TD\n")
+	// Output edges: source --> target or source -->|edgeType| target (dashed and dotted
+	// edge types as -.->)
+	for _, e := range d.edges(graph) {
+		arrow := "-->"
+		if !e.solid() {
+			arrow = "-.->"
+		}
+		if e.edgeType != "" {
+			b.WriteString(fmt.Sprintf("  %s %s|\"%s\"| %s\n", e.source, arrow, escapeMermaidLabel(e.edgeType), e.target))
+		} else {
+			b.WriteString(fmt.Sprintf("  %s %s %s\n", e.source, arrow, e.target))
+		}
+	}
 
-	// Output nodes: safeId["label"] with optional type hint
-	for _, id := range nodeOrder {
-		var label string
-		for i := range graph.Elements {
-			e := &graph.Elements[i]
-			if e.Group == "nodes" && e.Data.ID == id {
-				label = e.Data.Label
-				if label == "" {
-					label = id
-				}
-				break
+	// Output links to the repository web UI
+	for _, group := range d.groupOrder {
+		for _, data := range d.groups[group] {
+			if link := treeURL(graph, data); link != "" {
+				fmt.Fprintf(&b, "  click %s href \"%s\" \"%s\" _blank\n", d.safeIDs[data.ID], link, escapeMermaidLabel(data.ID))
 			}
 		}
-		safeID := nodeIDToSafe[id]
-		escaped := escapeMermaidLabel(label)
-		b.WriteString(fmt.Sprintf("  %s[\"%s\"]\n", safeID, escaped))
 	}
 
-	// Output edges: source --> target or source -->|edgeType| target
-	for i := range graph.Elements {
-		e := &graph.Elements[i]
-		if e.Group != "edges" {
-			continue
-		}
-		src := nodeIDToSafe[e.Data.Source]
-		tgt := nodeIDToSafe[e.Data.Target]
-		if src == "" || tgt == "" {
-			continue
-		}
-		if e.Data.EdgeType != "" {
-			edgeLabel := escapeMermaidLabel(e.Data.EdgeType)
-			b.WriteString(fmt.Sprintf("  %s -->|\"%s\"| %s\n", src, edgeLabel, tgt))
-		} else {
-			b.WriteString(fmt.Sprintf("  %s --> %s\n", src, tgt))
-		}
+	// Output styles of the node classes in use
+	for _, class := range d.classOrder {
+		s := d.classes[class]
+		fmt.Fprintf(&b, "  classDef %s fill:%s,stroke:%s,color:%s\n", class, s.fill, s.border, s.font)
 	}
 
 	return strings.TrimSuffix(b.String(), "\n")
@@ -50,3 +50,74 @@ func TestToMermaid_EdgeTypeLabel(t *testing.T) {
 		t.Errorf("expected edge type 'component' in output: %s", got)
 	}
 }
+
+func TestToMermaidWithOptions_Full(t *testing.T) {
+	g := &types.Graph{
+		Elements: []types.Element{
+			{Group: "nodes", Data: types.ElementData{ID: "github:o/r/overlays/prod@main", Label: "prod", Type: "overlay", Path: "overlays/prod"}},
+			{Group: "nodes", Data: types.ElementData{ID: "github:o/r/overlays/prod/deploy.yaml@main", Label: "deploy.yaml", Type: "resource", Path: "overlays/prod/deploy.yaml"}},
+			{Group: "nodes", Data: types.ElementData{ID: "gitlab:g/p/base@v1", Label: "base", Type: "base", Path: "base"}},
+			{Group: "nodes", Data: types.ElementData{ID: "error:missing", Label: "missing", Type: "error"}},
+			{Group: "edges", Data: types.ElementData{Source: "github:o/r/overlays/prod@main", Target: "github:o/r/overlays/prod/deploy.yaml@main", EdgeType: "resource"}},
+			{Group: "edges", Data: types.ElementData{Source: "github:o/r/overlays/prod@main", Target: "gitlab:g/p/base@v1", EdgeType: "patch"}},
+			{Group: "edges", Data: types.ElementData{Source: "github:o/r/overlays/prod@main", Target: "error:missing"}},
+		},
+		BaseURLs: map[string]string{"gitlab:g/p/base@v1": "https://gitlab.example.com/"},
+	}
+	want := `flowchart LR
+  subgraph g0["github:o/r@main"]
+    n0["prod"]:::overlay
+    n1["deploy.yaml"]:::resource
+  end
+  subgraph g1["gitlab:g/p@v1"]
+    n2["base"]:::base
+  end
+  n3["missing"]:::error
+  n0 -->|"resource"| n1
+  n0 -.->|"patch"| n2
+  n0 --> n3
+  click n0 href "https://github.com/o/r/tree/main/overlays/prod" "github:o/r/overlays/prod@main" _blank
+  click n1 href "https://github.com/o/r/blob/main/overlays/prod/deploy.yaml" "github:o/r/overlays/prod/deploy.yaml@main" _blank
+  click n2 href "https://gitlab.example.com/g/p/-/tree/v1/base" "gitlab:g/p/base@v1" _blank
+  classDef overlay fill:#3498db,stroke:#333333,color:black
+  classDef resource fill:#3498db,stroke:#333333,color:black
+  classDef base fill:#2ecc71,stroke:#333333,color:black
+  classDef error fill:#e74c3c,stroke:#c0392b,color:white`
+	if got := ToMermaidWithOptions(g, Options{Direction: "LR"}); got != want {
+		t.Errorf("ToMermaidWithOptions() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestToMermaidWithOptions_HideFiles(t *testing.T) {
+	g := &types.Graph{
+		Elements: []types.Element{
+			{Group: "nodes", Data: types.ElementData{ID: "local:overlay@local", Label: "overlay", Type: "overlay", Path: "overlay"}},
+			{Group: "nodes", Data: types.ElementData{ID: "local:overlay/cm.yaml@local", Label: "cm.yaml", Type: "resource", Path: "overlay/cm.yaml"}},
+			{Group: "nodes", Data: types.ElementData{ID: "local:base@local", Label: "base", Type: "resource", Path: "base"}},
+			{Group: "edges", Data: types.ElementData{Source: "local:overlay@local", Target: "local:overlay/cm.yaml@local", EdgeType: "resource"}},
+			{Group: "edges", Data: types.ElementData{Source: "local:overlay@local", Target: "local:base@local", EdgeType: "resource"}},
+		},
+	}
+	got := ToMermaidWithOptions(g, Options{HideFiles: true})
+	if strings.Contains(got, "cm.yaml") {
+		t.Errorf("expected plain-file resource to be hidden: %s", got)
+	}
+	if !strings.Contains(got, `n1["base"]:::resource`) || !strings.Contains(got, `n0 -->|"resource"| n1`) {
+		t.Errorf("expected kustomization resource and its edge to be kept: %s", got)
+	}
+	if strings.Contains(got, "click") {
+		t.Errorf("expected no links for local nodes: %s", got)
+	}
+}
+
+func TestToMermaidWithOptions_RateLimited(t *testing.T) {
+	g := &types.Graph{
+		Elements: []types.Element{
+			{Group: "nodes", Data: types.ElementData{ID: "error:quota", Label: "quota", Type: "error", ErrorCategory: types.ErrorRateLimited}},
+		},
+	}
+	got := ToMermaid(g)
+	if !strings.Contains(got, `n0["quota"]:::rateLimited`) || !strings.Contains(got, "classDef rateLimited fill:#e67e22") {
+		t.Errorf("expected rate-limited class: %s", got)
+	}
+}
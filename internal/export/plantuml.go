@@ -0,0 +1,77 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cjeanner/kustomap/internal/types"
+)
+
+// ToPlantUML converts a kustomize dependency graph to a PlantUML diagram, with the same
+// layout as ToMermaidWithOptions: one package per repository@ref, a stereotype per node
+// type styled with skinparam (error nodes in red), edge types as labels (dashed and
+// dotted edge types as ..>) and GitHub/GitLab nodes linked to their tree URL.
+func ToPlantUML(graph *types.Graph, opts Options) string {
+	var b strings.Builder
+	b.WriteString("@startuml\n")
+	if opts.Direction == "LR" {
+		b.WriteString("left to right direction\n")
+	}
+	if graph == nil || len(graph.Elements) == 0 {
+		b.WriteString("rectangle \"empty graph\" as empty\n@enduml\n")
+		return b.String()
+	}
+
+	d := newDiagram(graph, opts)
+	b.WriteString("skinparam shadowing false\n")
+	b.WriteString("skinparam rectangle {\n")
+	for _, class := range d.classOrder {
+		s := d.classes[class]
+		fmt.Fprintf(&b, "  BackgroundColor<<%s>> %s\n", class, s.fill)
+		fmt.Fprintf(&b, "  BorderColor<<%s>> %s\n", class, s.border)
+		fmt.Fprintf(&b, "  FontColor<<%s>> %s\n", class, s.font)
+	}
+	b.WriteString("}\n")
+
+	for i, group := range d.groupOrder {
+		indent := ""
+		if group != "" {
+			fmt.Fprintf(&b, "package \"%s\" as g%d {\n", escapePlantUML(group), i)
+			indent = "  "
+		}
+		for _, data := range d.groups[group] {
+			fmt.Fprintf(&b, "%srectangle \"%s\" as %s <<%s>>", indent, escapePlantUML(nodeLabel(data)), d.safeIDs[data.ID], nodeClass(*data))
+			if link := treeURL(graph, data); link != "" {
+				fmt.Fprintf(&b, " [[%s]]", link)
+			}
+			b.WriteString("\n")
+		}
+		if group != "" {
+			b.WriteString("}\n")
+		}
+	}
+
+	for _, e := range d.edges(graph) {
+		arrow := "-->"
+		if !e.solid() {
+			arrow = "..>"
+		}
+		fmt.Fprintf(&b, "%s %s %s", e.source, arrow, e.target)
+		if e.edgeType != "" {
+			fmt.Fprintf(&b, " : %s", escapePlantUML(e.edgeType))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("@enduml\n")
+	return b.String()
+}
+
+// escapePlantUML makes s safe inside "..." in PlantUML, which has no escape for double
+// quotes: they become single quotes, and newlines become \n.
+func escapePlantUML(s string) string {
+	return strings.NewReplacer(
+		`"`, `'`,
+		"\n", `\n`,
+	).Replace(s)
+}
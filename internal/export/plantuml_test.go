@@ -0,0 +1,78 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cjeanner/kustomap/internal/types"
+)
+
+// diagramTestGraph has two repositories, a plain file, an edge of each line style and an
+// error node outside any repository.
+func diagramTestGraph() *types.Graph {
+	return &types.Graph{
+		Elements: []types.Element{
+			{Group: "nodes", Data: types.ElementData{ID: "github:o/r/overlays/prod@main", Label: "prod", Type: "overlay", Path: "overlays/prod"}},
+			{Group: "nodes", Data: types.ElementData{ID: "github:o/r/overlays/prod/deploy.yaml@main", Label: "deploy.yaml", Type: "resource", Path: "overlays/prod/deploy.yaml"}},
+			{Group: "nodes", Data: types.ElementData{ID: "gitlab:g/p/base@v1", Label: `base "v1"`, Type: "base", Path: "base"}},
+			{Group: "nodes", Data: types.ElementData{ID: "error:missing", Label: "missing", Type: "error"}},
+			{Group: "edges", Data: types.ElementData{Source: "github:o/r/overlays/prod@main", Target: "github:o/r/overlays/prod/deploy.yaml@main", EdgeType: "resource"}},
+			{Group: "edges", Data: types.ElementData{Source: "github:o/r/overlays/prod@main", Target: "gitlab:g/p/base@v1", EdgeType: "patch"}},
+			{Group: "edges", Data: types.ElementData{Source: "github:o/r/overlays/prod@main", Target: "error:missing"}},
+		},
+		BaseURLs: map[string]string{"gitlab:g/p/base@v1": "https://gitlab.example.com"},
+	}
+}
+
+func TestToPlantUML_NilOrEmpty(t *testing.T) {
+	for _, g := range []*types.Graph{nil, {Elements: []types.Element{}}} {
+		if got := ToPlantUML(g, Options{}); !strings.HasPrefix(got, "@startuml") || !strings.Contains(got, "empty graph") {
+			t.Errorf("ToPlantUML(%v) = %q, want a diagram with 'empty graph'", g, got)
+		}
+	}
+}
+
+func TestToPlantUML(t *testing.T) {
+	want := `@startuml
+skinparam shadowing false
+skinparam rectangle {
+  BackgroundColor<<overlay>> #3498db
+  BorderColor<<overlay>> #333333
+  FontColor<<overlay>> black
+  BackgroundColor<<resource>> #3498db
+  BorderColor<<resource>> #333333
+  FontColor<<resource>> black
+  BackgroundColor<<base>> #2ecc71
+  BorderColor<<base>> #333333
+  FontColor<<base>> black
+  BackgroundColor<<error>> #e74c3c
+  BorderColor<<error>> #c0392b
+  FontColor<<error>> white
+}
+package "github:o/r@main" as g0 {
+  rectangle "prod" as n0 <<overlay>> [[https://github.com/o/r/tree/main/overlays/prod]]
+  rectangle "deploy.yaml" as n1 <<resource>> [[https://github.com/o/r/blob/main/overlays/prod/deploy.yaml]]
+}
+package "gitlab:g/p@v1" as g1 {
+  rectangle "base 'v1'" as n2 <<base>> [[https://gitlab.example.com/g/p/-/tree/v1/base]]
+}
+rectangle "missing" as n3 <<error>>
+n0 --> n1 : resource
+n0 ..> n2 : patch
+n0 --> n3
+@enduml
+`
+	if got := ToPlantUML(diagramTestGraph(), Options{}); got != want {
+		t.Errorf("ToPlantUML mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestToPlantUML_Options(t *testing.T) {
+	got := ToPlantUML(diagramTestGraph(), Options{Direction: "LR", HideFiles: true})
+	if !strings.Contains(got, "left to right direction") {
+		t.Errorf("expected left-right direction: %s", got)
+	}
+	if strings.Contains(got, "deploy.yaml") {
+		t.Errorf("expected plain-file resource to be hidden: %s", got)
+	}
+}
@@ -0,0 +1,104 @@
+package export
+
+import (
+	"strings"
+
+	"github.com/cjeanner/kustomap/internal/types"
+)
+
+// nodeStyle is how a node type is drawn. Colors follow the web UI (web/js/app.js) so
+// exported diagrams read like the graph view.
+type nodeStyle struct {
+	fill     string
+	border   string
+	font     string
+	dotShape string // Graphviz shape
+}
+
+var defaultNodeStyle = nodeStyle{fill: "#ecf0f1", border: "#333333", font: "black", dotShape: "box"}
+
+var nodeStyles = map[string]nodeStyle{
+	"overlay":   {fill: "#3498db", border: "#333333", font: "black", dotShape: "box"},
+	"base":      {fill: "#2ecc71", border: "#333333", font: "black", dotShape: "box"},
+	"resource":  {fill: "#3498db", border: "#333333", font: "black", dotShape: "box"},
+	"component": {fill: "#9b59b6", border: "#333333", font: "black", dotShape: "component"},
+	"patch":     {fill: "#e67e22", border: "#333333", font: "black", dotShape: "cds"},
+	"generator": {fill: "#1abc9c", border: "#333333", font: "black", dotShape: "hexagon"},
+	"chart":     {fill: "#0f1689", border: "#333333", font: "white", dotShape: "cylinder"},
+	"plugin":    {fill: "#34495e", border: "#333333", font: "white", dotShape: "box3d"},
+	"file":      {fill: "#bdc3c7", border: "#333333", font: "black", dotShape: "note"},
+	"error":     {fill: "#e74c3c", border: "#c0392b", font: "white", dotShape: "octagon"},
+}
+
+// styleForNode returns the style of a node; rate-limited errors are orange as in the UI.
+func styleForNode(data types.ElementData) nodeStyle {
+	if data.ErrorCategory == types.ErrorRateLimited {
+		return nodeStyle{fill: "#e67e22", border: "#c0392b", font: "white", dotShape: "octagon"}
+	}
+	if s, ok := nodeStyles[data.Type]; ok {
+		return s
+	}
+	return defaultNodeStyle
+}
+
+// edgeStyle is how an edge type is drawn: line is "solid", "dashed" or "dotted".
+type edgeStyle struct {
+	line  string
+	color string
+}
+
+var defaultEdgeStyle = edgeStyle{line: "solid", color: "#95a5a6"}
+
+var edgeStyles = map[string]edgeStyle{
+	"component":   {line: "solid", color: "#9b59b6"},
+	"patch":       {line: "dashed", color: "#e67e22"},
+	"merge":       {line: "dotted", color: "#1abc9c"},
+	"replace":     {line: "dotted", color: "#1abc9c"},
+	"replacement": {line: "dashed", color: "#8e44ad"},
+}
+
+func styleForEdge(edgeType string) edgeStyle {
+	if s, ok := edgeStyles[edgeType]; ok {
+		return s
+	}
+	return defaultEdgeStyle
+}
+
+// nodeRepo returns the repository (e.g. "github:org/repo" for
+// "github:org/repo/overlays/prod@main", "local" for local nodes) and ref of a node of graph.
+// The repository is the one recorded in graph.Repos, else it is read from the ID (graphs
+// analyzed before Repos was recorded), which cannot tell nested GitLab groups or plain git
+// owners from the path. repo is "" when the ID does not name a repository.
+func nodeRepo(graph *types.Graph, nodeID string) (repo, ref string) {
+	colon := strings.Index(nodeID, ":")
+	at := strings.LastIndex(nodeID, "@")
+	if colon <= 0 || at < colon {
+		return "", ""
+	}
+	typ, path, ref := nodeID[:colon], nodeID[colon+1:at], nodeID[at+1:]
+	if repo := graph.Repos[nodeID]; repo != "" {
+		return repo, ref
+	}
+	if typ == "local" {
+		return "local", ref
+	}
+	segments := 2 // owner/repo
+	if typ == "azure" {
+		segments = 3 // organization/project/repo
+	}
+	parts := strings.SplitN(path, "/", segments+1)
+	if len(parts) < segments {
+		return "", ""
+	}
+	return typ + ":" + strings.Join(parts[:segments], "/"), ref
+}
+
+// repoGroup returns the repository@ref a node of graph belongs to (e.g.
+// "github:org/repo@main"), or "" when the ID does not name a repository.
+func repoGroup(graph *types.Graph, nodeID string) string {
+	repo, ref := nodeRepo(graph, nodeID)
+	if repo == "" {
+		return ""
+	}
+	return repo + "@" + ref
+}
@@ -0,0 +1,128 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cjeanner/kustomap/internal/repository"
+)
+
+// AzureDevOpsFetcher reads files through the Azure DevOps Git Items API.
+type AzureDevOpsFetcher struct {
+	client *http.Client
+	info   *repository.RepositoryInfo
+	token  string
+
+	once        sync.Once
+	versionType string // branch, tag or commit; looked up on first use
+}
+
+func NewAzureDevOpsFetcher(info *repository.RepositoryInfo, token string) (*AzureDevOpsFetcher, error) {
+	return &AzureDevOpsFetcher{
+		client: &http.Client{Timeout: 30 * time.Second},
+		info:   info,
+		token:  token,
+	}, nil
+}
+
+// itemsURL returns the Items API URL for query at the fetcher ref.
+func (f *AzureDevOpsFetcher) itemsURL(ctx context.Context, query url.Values) string {
+	f.once.Do(func() {
+		f.versionType = repository.AzureRefType(ctx, f.info, f.token)
+	})
+	for k, v := range repository.AzureVersion(f.info.Ref, f.versionType) {
+		query[k] = v
+	}
+	return repository.AzureAPIURL(f.info, "items", query)
+}
+
+// FetchFile retrieves a single file content
+func (f *AzureDevOpsFetcher) FetchFile(ctx context.Context, path string) ([]byte, error) {
+	log.Printf("Fetching file from Azure DevOps: %s/%s/%s @ %s",
+		f.info.Owner, f.info.Repo, path, f.info.Ref)
+
+	body, err := repository.AzureGet(ctx, f.client, f.itemsURL(ctx, url.Values{
+		"path":           {"/" + strings.Trim(path, "/")},
+		"includeContent": {"true"},
+		"$format":        {"json"},
+	}), f.token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch file %s: %w", path, err)
+	}
+
+	var item struct {
+		IsFolder bool   `json:"isFolder"`
+		Content  string `json:"content"`
+	}
+	if err := json.Unmarshal(body, &item); err != nil {
+		return nil, fmt.Errorf("invalid item response for %s: %w", path, err)
+	}
+	if item.IsFolder {
+		return nil, fmt.Errorf("file not found: %s", path)
+	}
+	return []byte(item.Content), nil
+}
+
+// ListFiles lists all files recursively in the repository
+func (f *AzureDevOpsFetcher) ListFiles(ctx context.Context) ([]string, error) {
+	log.Printf("Listing files from Azure DevOps: %s/%s @ %s",
+		f.info.Owner, f.info.Repo, f.info.Ref)
+
+	body, err := repository.AzureGet(ctx, f.client, f.itemsURL(ctx, url.Values{
+		"scopePath":      {"/"},
+		"recursionLevel": {"Full"},
+	}), f.token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repository items: %w", err)
+	}
+
+	var items struct {
+		Value []struct {
+			Path     string `json:"path"`
+			IsFolder bool   `json:"isFolder"`
+		} `json:"value"`
+	}
+	if err := json.Unmarshal(body, &items); err != nil {
+		return nil, fmt.Errorf("invalid items response: %w", err)
+	}
+	var files []string
+	for _, item := range items.Value {
+		if !item.IsFolder {
+			files = append(files, strings.TrimPrefix(item.Path, "/"))
+		}
+	}
+
+	log.Printf("Found %d files in repository", len(files))
+	return files, nil
+}
+
+// FindKustomizationInPath finds kustomization.yaml in a specific path
+func (f *AzureDevOpsFetcher) FindKustomizationInPath(ctx context.Context, path string) (string, error) {
+	path = strings.Trim(path, "/")
+
+	log.Printf("Trying to fetch path as-is: %s", path)
+	if content, err := f.FetchFile(ctx, path); err == nil {
+		return string(content), nil
+	}
+
+	// Try common kustomization file names
+	for _, name := range []string{"kustomization.yaml", "kustomization.yml", "Kustomization"} {
+		p := name
+		if path != "" {
+			p = path + "/" + name
+		}
+		if content, err := f.FetchFile(ctx, p); err == nil {
+			log.Printf("✅ Found kustomization file: %s", p)
+			return string(content), nil
+		}
+	}
+
+	return "", fmt.Errorf("no kustomization file found in path: %s", path)
+}
@@ -0,0 +1,84 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cjeanner/kustomap/internal/repository"
+)
+
+func TestAzureDevOpsFetcher(t *testing.T) {
+	const repoAPI = "/contoso/platform/_apis/git/repositories/gitops"
+	files := map[string]string{
+		"/deploy/overlay/kustomization.yaml": "resources:\n- ../base\n",
+		"/deploy/base/deployment.yaml":       "kind: Deployment\n",
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, pat, _ := r.BasicAuth(); pat != "secret" {
+			t.Errorf("missing PAT basic auth")
+		}
+		q := r.URL.Query()
+		switch r.URL.Path {
+		case repoAPI + "/refs":
+			w.Write([]byte(`{"value":[{"name":"refs/heads/develop"}]}`))
+		case repoAPI + "/items":
+			if q.Get("versionDescriptor.version") != "develop" || q.Get("versionDescriptor.versionType") != "branch" {
+				t.Errorf("version query = %s", r.URL.RawQuery)
+			}
+			if q.Get("recursionLevel") == "Full" {
+				w.Write([]byte(`{"value":[{"path":"/","isFolder":true},{"path":"/deploy/overlay/kustomization.yaml"},{"path":"/deploy/base/deployment.yaml"}]}`))
+				return
+			}
+			path := q.Get("path")
+			if content, ok := files[path]; ok {
+				json.NewEncoder(w).Encode(map[string]interface{}{"path": path, "content": content})
+				return
+			}
+			if path == "/deploy/overlay" {
+				w.Write([]byte(`{"path":"/deploy/overlay","isFolder":true}`))
+				return
+			}
+			http.NotFound(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	info := &repository.RepositoryInfo{Type: repository.AzureDevOps, Owner: "contoso/platform", Repo: "gitops", Ref: "develop", BaseURL: srv.URL}
+	f, err := NewFetcher(info, "secret")
+	if err != nil {
+		t.Fatalf("NewFetcher(AzureDevOps): %v", err)
+	}
+
+	content, err := f.FetchFile(context.Background(), "deploy/base/deployment.yaml")
+	if err != nil {
+		t.Fatalf("FetchFile: %v", err)
+	}
+	if string(content) != "kind: Deployment\n" {
+		t.Errorf("FetchFile = %q", content)
+	}
+	if _, err := f.FetchFile(context.Background(), "deploy/overlay"); err == nil {
+		t.Error("FetchFile of a folder should error")
+	}
+
+	list, err := f.ListFiles(context.Background())
+	if err != nil {
+		t.Fatalf("ListFiles: %v", err)
+	}
+	if strings.Join(list, ",") != "deploy/overlay/kustomization.yaml,deploy/base/deployment.yaml" {
+		t.Errorf("ListFiles = %v", list)
+	}
+
+	kust, err := f.FindKustomizationInPath(context.Background(), "deploy/overlay")
+	if err != nil {
+		t.Fatalf("FindKustomizationInPath: %v", err)
+	}
+	if !strings.Contains(kust, "../base") {
+		t.Errorf("FindKustomizationInPath = %q", kust)
+	}
+}
@@ -0,0 +1,136 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cjeanner/kustomap/internal/repository"
+)
+
+// BitbucketFetcher reads files through the Bitbucket REST API: the 2.0 "src" API on
+// Bitbucket Cloud, the 1.0 "raw" and "files" APIs on Bitbucket Server / Data Center.
+type BitbucketFetcher struct {
+	client  *http.Client
+	info    *repository.RepositoryInfo
+	token   string
+	repoAPI string
+	cloud   bool
+}
+
+func NewBitbucketFetcher(info *repository.RepositoryInfo, token string) (*BitbucketFetcher, error) {
+	return &BitbucketFetcher{
+		client:  &http.Client{Timeout: 30 * time.Second},
+		info:    info,
+		token:   token,
+		repoAPI: repository.BitbucketRepoAPI(info),
+		cloud:   repository.IsBitbucketCloud(info.BaseURL),
+	}, nil
+}
+
+// escapePath escapes each segment of a repository path for use in a URL path.
+func escapePath(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// FetchFile retrieves a single file content
+func (f *BitbucketFetcher) FetchFile(ctx context.Context, path string) ([]byte, error) {
+	log.Printf("Fetching file from Bitbucket: %s/%s/%s @ %s",
+		f.info.Owner, f.info.Repo, path, f.info.Ref)
+
+	var fileURL string
+	if f.cloud {
+		fileURL = fmt.Sprintf("%s/src/%s/%s", f.repoAPI, url.PathEscape(f.info.Ref), escapePath(path))
+	} else {
+		fileURL = fmt.Sprintf("%s/raw/%s?at=%s", f.repoAPI, escapePath(path), url.QueryEscape(f.info.Ref))
+	}
+	content, err := repository.BitbucketGet(ctx, f.client, fileURL, f.token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch file %s: %w", path, err)
+	}
+	return content, nil
+}
+
+// ListFiles lists all files recursively in the repository
+func (f *BitbucketFetcher) ListFiles(ctx context.Context) ([]string, error) {
+	log.Printf("Listing files from Bitbucket: %s/%s @ %s",
+		f.info.Owner, f.info.Repo, f.info.Ref)
+
+	var files []string
+	var listURL string
+	if f.cloud {
+		listURL = fmt.Sprintf("%s/src/%s/?max_depth=100&pagelen=100", f.repoAPI, url.PathEscape(f.info.Ref))
+	} else {
+		listURL = fmt.Sprintf("%s/files?at=%s", f.repoAPI, url.QueryEscape(f.info.Ref))
+	}
+	err := repository.BitbucketPages(ctx, f.client, listURL, f.token, f.cloud, func(body []byte) error {
+		if !f.cloud {
+			var page struct {
+				Values []string `json:"values"`
+			}
+			if err := json.Unmarshal(body, &page); err != nil {
+				return err
+			}
+			files = append(files, page.Values...)
+			return nil
+		}
+		var page struct {
+			Values []struct {
+				Type string `json:"type"`
+				Path string `json:"path"`
+			} `json:"values"`
+		}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return err
+		}
+		for _, v := range page.Values {
+			if v.Type == "commit_file" {
+				files = append(files, v.Path)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repository files: %w", err)
+	}
+
+	log.Printf("Found %d files in Bitbucket repository", len(files))
+	return files, nil
+}
+
+// FindKustomizationInPath finds kustomization.yaml in a specific path.
+// Kustomization file names are tried first: on Bitbucket Cloud, fetching a directory
+// returns its listing rather than an error.
+func (f *BitbucketFetcher) FindKustomizationInPath(ctx context.Context, path string) (string, error) {
+	path = strings.Trim(path, "/")
+
+	for _, name := range []string{"kustomization.yaml", "kustomization.yml", "Kustomization"} {
+		p := name
+		if path != "" {
+			p = path + "/" + name
+		}
+		if content, err := f.FetchFile(ctx, p); err == nil {
+			log.Printf("✅ Found kustomization file: %s", p)
+			return string(content), nil
+		}
+	}
+
+	// Path could be the kustomization file itself
+	name := path[strings.LastIndex(path, "/")+1:]
+	if isKustomizationFileName(name) || strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml") {
+		if content, err := f.FetchFile(ctx, path); err == nil {
+			return string(content), nil
+		}
+	}
+
+	return "", fmt.Errorf("no kustomization file found in path: %s", path)
+}
@@ -0,0 +1,127 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cjeanner/kustomap/internal/repository"
+)
+
+func TestBitbucketFetcher_Server(t *testing.T) {
+	const repoAPI = "/rest/api/1.0/projects/PRJ/repos/infra"
+	files := map[string]string{
+		"deploy/overlay/kustomization.yaml": "resources:\n- ../base\n",
+		"deploy/base/deployment.yaml":       "kind: Deployment\n",
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q, want bearer token", got)
+		}
+		if got := r.URL.Query().Get("at"); got != "develop" {
+			t.Errorf("at = %q, want develop", got)
+		}
+		switch {
+		case r.URL.Path == repoAPI+"/files":
+			w.Write([]byte(`{"values":["deploy/overlay/kustomization.yaml","deploy/base/deployment.yaml"],"isLastPage":true}`))
+		case strings.HasPrefix(r.URL.Path, repoAPI+"/raw/"):
+			content, ok := files[strings.TrimPrefix(r.URL.Path, repoAPI+"/raw/")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte(content))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	info := &repository.RepositoryInfo{Type: repository.Bitbucket, Owner: "PRJ", Repo: "infra", Ref: "develop", BaseURL: srv.URL}
+	f, err := NewFetcher(info, "secret")
+	if err != nil {
+		t.Fatalf("NewFetcher(Bitbucket): %v", err)
+	}
+
+	content, err := f.FetchFile(context.Background(), "deploy/base/deployment.yaml")
+	if err != nil {
+		t.Fatalf("FetchFile: %v", err)
+	}
+	if string(content) != "kind: Deployment\n" {
+		t.Errorf("FetchFile = %q", content)
+	}
+	if _, err := f.FetchFile(context.Background(), "missing.yaml"); err == nil {
+		t.Error("FetchFile(missing.yaml) should error")
+	}
+
+	list, err := f.ListFiles(context.Background())
+	if err != nil {
+		t.Fatalf("ListFiles: %v", err)
+	}
+	if len(list) != 2 {
+		t.Errorf("ListFiles = %v, want 2 files", list)
+	}
+
+	kust, err := f.FindKustomizationInPath(context.Background(), "deploy/overlay")
+	if err != nil {
+		t.Fatalf("FindKustomizationInPath: %v", err)
+	}
+	if !strings.Contains(kust, "../base") {
+		t.Errorf("FindKustomizationInPath = %q", kust)
+	}
+	if _, err := f.FindKustomizationInPath(context.Background(), "deploy/base"); err == nil {
+		t.Error("FindKustomizationInPath(deploy/base) should error")
+	}
+}
+
+func TestBitbucketFetcher_Cloud(t *testing.T) {
+	const src = "/repositories/team/infra/src/abc123/"
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case src:
+			// Recursive listing, paged with "next" links
+			if r.URL.Query().Get("page") == "" {
+				w.Write([]byte(`{"values":[{"type":"commit_directory","path":"deploy"},{"type":"commit_file","path":"deploy/kustomization.yaml"}],"next":"` + srv.URL + src + `?page=2"}`))
+				return
+			}
+			w.Write([]byte(`{"values":[{"type":"commit_file","path":"deploy/cm.yaml"}]}`))
+		case src + "deploy/kustomization.yaml":
+			w.Write([]byte("resources:\n- cm.yaml\n"))
+		case src + "deploy":
+			// Directories return their listing rather than an error
+			w.Write([]byte(`{"values":[]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	info := &repository.RepositoryInfo{Type: repository.Bitbucket, Owner: "team", Repo: "infra", Ref: "abc123", BaseURL: "https://bitbucket.org"}
+	f, err := NewBitbucketFetcher(info, "")
+	if err != nil {
+		t.Fatalf("NewBitbucketFetcher: %v", err)
+	}
+	if !f.cloud {
+		t.Fatal("bitbucket.org should use the Cloud API")
+	}
+	f.repoAPI = srv.URL + "/repositories/team/infra"
+
+	list, err := f.ListFiles(context.Background())
+	if err != nil {
+		t.Fatalf("ListFiles: %v", err)
+	}
+	if strings.Join(list, ",") != "deploy/kustomization.yaml,deploy/cm.yaml" {
+		t.Errorf("ListFiles = %v", list)
+	}
+
+	kust, err := f.FindKustomizationInPath(context.Background(), "deploy")
+	if err != nil {
+		t.Fatalf("FindKustomizationInPath: %v", err)
+	}
+	if !strings.Contains(kust, "cm.yaml") {
+		t.Errorf("FindKustomizationInPath = %q", kust)
+	}
+}
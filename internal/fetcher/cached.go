@@ -0,0 +1,112 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync/atomic"
+
+	"github.com/cjeanner/kustomap/internal/cache"
+	"github.com/cjeanner/kustomap/internal/repository"
+)
+
+// CacheStats counts the cache hits and misses of the CachedFetchers sharing it (e.g. the
+// fetchers of one analysis: Store.Stats counts those of every analysis).
+type CacheStats struct {
+	Hits   atomic.Int64
+	Misses atomic.Int64
+}
+
+// CachedFetcher serves files of a repository pinned to a commit from an on-disk cache,
+// falling back to the wrapped fetcher on misses. Only successful results are cached.
+type CachedFetcher struct {
+	fetcher Fetcher
+	store   *cache.Store
+	key     cache.Key // Path is set per lookup
+	stats   *CacheStats
+}
+
+// NewCachedFetcher wraps f so that content of info at commit sha is cached in store.
+// f must read the repository at sha (or at a ref that pointed to sha when pinned). Entries
+// are shared by all callers: only wrap f once the caller's access to the repository has
+// been checked (e.g. by resolving sha with its token). Lookups are counted in stats, if not nil.
+func NewCachedFetcher(f Fetcher, store *cache.Store, info *repository.RepositoryInfo, sha string, stats *CacheStats) *CachedFetcher {
+	return &CachedFetcher{
+		fetcher: f,
+		store:   store,
+		key:     cache.Key{Host: info.Host(), Owner: info.Owner, Repo: info.Repo, SHA: sha},
+		stats:   stats,
+	}
+}
+
+// lookup returns the cached content of path, logging and counting hits.
+func (f *CachedFetcher) lookup(path string) (cache.Key, []byte, bool) {
+	key := f.key
+	key.Path = path
+	data, ok := f.store.Get(key)
+	if ok {
+		log.Printf("Cache hit: %s", key)
+	}
+	if f.stats != nil {
+		if ok {
+			f.stats.Hits.Add(1)
+		} else {
+			f.stats.Misses.Add(1)
+		}
+	}
+	return key, data, ok
+}
+
+// save caches data for key; failures only cost a refetch later.
+func (f *CachedFetcher) save(key cache.Key, data []byte) {
+	if err := f.store.Put(key, data); err != nil {
+		log.Printf("Warning: failed to cache %s: %v", key, err)
+	}
+}
+
+// FetchFile retrieves a single file content
+func (f *CachedFetcher) FetchFile(ctx context.Context, path string) ([]byte, error) {
+	key, data, ok := f.lookup("file:" + path)
+	if ok {
+		return data, nil
+	}
+	data, err := f.fetcher.FetchFile(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	f.save(key, data)
+	return data, nil
+}
+
+// ListFiles lists all files recursively in the repository
+func (f *CachedFetcher) ListFiles(ctx context.Context) ([]string, error) {
+	key, data, ok := f.lookup("list:")
+	if ok {
+		var files []string
+		if json.Unmarshal(data, &files) == nil {
+			return files, nil
+		}
+	}
+	files, err := f.fetcher.ListFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if data, err := json.Marshal(files); err == nil {
+		f.save(key, data)
+	}
+	return files, nil
+}
+
+// FindKustomizationInPath finds kustomization.yaml in a specific path
+func (f *CachedFetcher) FindKustomizationInPath(ctx context.Context, path string) (string, error) {
+	key, data, ok := f.lookup("kustomization:" + path)
+	if ok {
+		return string(data), nil
+	}
+	content, err := f.fetcher.FindKustomizationInPath(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	f.save(key, []byte(content))
+	return content, nil
+}
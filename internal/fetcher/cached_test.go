@@ -0,0 +1,101 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cjeanner/kustomap/internal/cache"
+	"github.com/cjeanner/kustomap/internal/repository"
+)
+
+// countingFetcher serves files from a map and counts calls.
+type countingFetcher struct {
+	files map[string]string
+	calls int
+}
+
+func (f *countingFetcher) FetchFile(ctx context.Context, path string) ([]byte, error) {
+	f.calls++
+	content, ok := f.files[path]
+	if !ok {
+		return nil, fmt.Errorf("file not found: %s", path)
+	}
+	return []byte(content), nil
+}
+
+func (f *countingFetcher) ListFiles(ctx context.Context) ([]string, error) {
+	f.calls++
+	var files []string
+	for path := range f.files {
+		files = append(files, path)
+	}
+	return files, nil
+}
+
+func (f *countingFetcher) FindKustomizationInPath(ctx context.Context, path string) (string, error) {
+	content, err := f.FetchFile(ctx, path+"/kustomization.yaml")
+	return string(content), err
+}
+
+func TestCachedFetcher(t *testing.T) {
+	store, err := cache.New(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("cache.New: %v", err)
+	}
+	info := &repository.RepositoryInfo{
+		Type: repository.GitHub, Owner: "o", Repo: "r", Ref: "main", BaseURL: "https://github.com",
+	}
+	sha := strings.Repeat("a", 40)
+	inner := &countingFetcher{files: map[string]string{"base/kustomization.yaml": "resources: []"}}
+
+	// Two fetchers of the same commit share entries (e.g. across analyses)
+	var stats [2]CacheStats
+	for i := 0; i < 2; i++ {
+		f := NewCachedFetcher(inner, store, info, sha, &stats[i])
+		content, err := f.FindKustomizationInPath(context.Background(), "base")
+		if err != nil || content != "resources: []" {
+			t.Fatalf("FindKustomizationInPath = %q, %v", content, err)
+		}
+		data, err := f.FetchFile(context.Background(), "base/kustomization.yaml")
+		if err != nil || string(data) != "resources: []" {
+			t.Fatalf("FetchFile = %q, %v", data, err)
+		}
+		files, err := f.ListFiles(context.Background())
+		if err != nil || !reflect.DeepEqual(files, []string{"base/kustomization.yaml"}) {
+			t.Fatalf("ListFiles = %v, %v", files, err)
+		}
+	}
+	if inner.calls != 3 {
+		t.Errorf("inner fetcher called %d times, want 3 (second round served from cache)", inner.calls)
+	}
+	// Each fetcher counts its own lookups only
+	if h, m := stats[0].Hits.Load(), stats[0].Misses.Load(); h != 0 || m != 3 {
+		t.Errorf("first fetcher: %d hits, %d misses; want 0, 3", h, m)
+	}
+	if h, m := stats[1].Hits.Load(), stats[1].Misses.Load(); h != 3 || m != 0 {
+		t.Errorf("second fetcher: %d hits, %d misses; want 3, 0", h, m)
+	}
+
+	// Errors are not cached
+	f := NewCachedFetcher(inner, store, info, sha, nil)
+	for i := 0; i < 2; i++ {
+		if _, err := f.FetchFile(context.Background(), "missing.yaml"); err == nil {
+			t.Fatal("FetchFile(missing.yaml) should error")
+		}
+	}
+	if inner.calls != 5 {
+		t.Errorf("inner fetcher called %d times, want 5", inner.calls)
+	}
+
+	// Another commit is fetched again
+	other := NewCachedFetcher(inner, store, info, strings.Repeat("b", 40), nil)
+	if _, err := other.FetchFile(context.Background(), "base/kustomization.yaml"); err != nil {
+		t.Fatalf("FetchFile at another commit: %v", err)
+	}
+	if inner.calls != 6 {
+		t.Errorf("inner fetcher called %d times, want 6", inner.calls)
+	}
+}
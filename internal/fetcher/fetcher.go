@@ -1,6 +1,7 @@
 package fetcher
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/cjeanner/kustomap/internal/repository"
@@ -9,13 +10,13 @@ import (
 // Fetcher interface for retrieving files from remote repositories
 type Fetcher interface {
 	// FetchFile retrieves a single file content
-	FetchFile(path string) ([]byte, error)
+	FetchFile(ctx context.Context, path string) ([]byte, error)
 
 	// ListFiles lists all files in the repository (recursive)
-	ListFiles() ([]string, error)
+	ListFiles(ctx context.Context) ([]string, error)
 
 	// FindKustomizationInPath finds kustomization.yaml in a specific path
-	FindKustomizationInPath(path string) (string, error)
+	FindKustomizationInPath(ctx context.Context, path string) (string, error)
 }
 
 // NewFetcher creates the appropriate fetcher based on repository type
@@ -25,8 +26,16 @@ func NewFetcher(info *repository.RepositoryInfo, token string) (Fetcher, error)
 		return NewGitHubFetcher(info, token)
 	case repository.GitLab:
 		return NewGitLabFetcher(info, token)
+	case repository.Bitbucket:
+		return NewBitbucketFetcher(info, token)
+	case repository.Gitea:
+		return NewGiteaFetcher(info, token)
+	case repository.AzureDevOps:
+		return NewAzureDevOpsFetcher(info, token)
 	case repository.Local:
 		return NewLocalFetcher(info, token)
+	case repository.Git:
+		return NewGitFetcher(info, token)
 	default:
 		return nil, fmt.Errorf("unsupported repository type: %s", info.Type)
 	}
@@ -0,0 +1,200 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/cjeanner/kustomap/internal/repository"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// GitFetcher reads files from any git remote (HTTPS or SSH) through a shallow,
+// in-memory clone made with go-git on first use.
+type GitFetcher struct {
+	info     *repository.RepositoryInfo
+	cloneURL string
+	auth     transport.AuthMethod
+
+	once    sync.Once
+	tree    *object.Tree
+	loadErr error
+}
+
+func NewGitFetcher(info *repository.RepositoryInfo, token string) (*GitFetcher, error) {
+	cloneURL := repository.GitCloneURL(info)
+	return &GitFetcher{
+		info:     info,
+		cloneURL: cloneURL,
+		auth:     repository.GitAuth(cloneURL, token),
+	}, nil
+}
+
+// load clones the repository at the fetcher ref and returns the commit tree.
+// The clone is done once, with the context of the first call; later calls return the
+// cached tree (or error).
+func (f *GitFetcher) load(ctx context.Context) (*object.Tree, error) {
+	f.once.Do(func() {
+		log.Printf("Cloning %s @ %s", f.cloneURL, f.info.Ref)
+		commit, err := f.cloneCommit(ctx)
+		if err != nil {
+			f.loadErr = fmt.Errorf("failed to clone %s @ %s: %w", f.cloneURL, f.info.Ref, err)
+			return
+		}
+		f.tree, f.loadErr = commit.Tree()
+	})
+	return f.tree, f.loadErr
+}
+
+// cloneCommit fetches the commit of the fetcher ref: a depth-1 single-branch clone for
+// branches and tags, or a depth-1 fetch of the commit itself for SHAs (servers that do
+// not allow fetching unadvertised commits fall back to a full clone).
+func (f *GitFetcher) cloneCommit(ctx context.Context) (*object.Commit, error) {
+	ref := f.info.Ref
+	if repository.IsCommitHash(ref) {
+		commit, err := f.fetchCommit(ctx, ref)
+		if err == nil {
+			return commit, nil
+		}
+		log.Printf("Shallow fetch of %s failed, cloning full history: %v", ref, err)
+		repo, err := git.CloneContext(ctx, memory.NewStorage(), nil, &git.CloneOptions{URL: f.cloneURL, Auth: f.auth, Tags: git.NoTags})
+		if err != nil {
+			return nil, err
+		}
+		return repo.CommitObject(plumbing.NewHash(ref))
+	}
+
+	var lastErr error
+	for _, name := range []plumbing.ReferenceName{plumbing.NewBranchReferenceName(ref), plumbing.NewTagReferenceName(ref)} {
+		repo, err := git.CloneContext(ctx, memory.NewStorage(), nil, &git.CloneOptions{
+			URL:           f.cloneURL,
+			Auth:          f.auth,
+			ReferenceName: name,
+			SingleBranch:  true,
+			Depth:         1,
+			Tags:          git.NoTags,
+		})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		head, err := repo.Head()
+		if err != nil {
+			return nil, err
+		}
+		return commitOf(repo, head.Hash())
+	}
+	return nil, lastErr
+}
+
+// fetchCommit fetches a single commit by SHA into an empty in-memory repository.
+func (f *GitFetcher) fetchCommit(ctx context.Context, sha string) (*object.Commit, error) {
+	repo, err := git.Init(memory.NewStorage(), nil)
+	if err != nil {
+		return nil, err
+	}
+	remote, err := repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{f.cloneURL}})
+	if err != nil {
+		return nil, err
+	}
+	err = remote.FetchContext(ctx, &git.FetchOptions{
+		RefSpecs: []config.RefSpec{config.RefSpec(sha + ":refs/heads/kustomap")},
+		Auth:     f.auth,
+		Depth:    1,
+		Tags:     git.NoTags,
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return nil, err
+	}
+	return repo.CommitObject(plumbing.NewHash(sha))
+}
+
+// commitOf returns the commit at hash, peeling annotated tags.
+func commitOf(repo *git.Repository, hash plumbing.Hash) (*object.Commit, error) {
+	if tag, err := repo.TagObject(hash); err == nil {
+		return tag.Commit()
+	}
+	return repo.CommitObject(hash)
+}
+
+// FetchFile retrieves a single file content from the cloned tree
+func (f *GitFetcher) FetchFile(ctx context.Context, path string) ([]byte, error) {
+	tree, err := f.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	path = strings.Trim(path, "/")
+	log.Printf("Fetching file from git: %s/%s/%s @ %s", f.info.Owner, f.info.Repo, path, f.info.Ref)
+
+	file, err := tree.File(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch file %s: %w", path, err)
+	}
+	reader, err := file.Reader()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", path, err)
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// ListFiles lists all files recursively in the cloned tree
+func (f *GitFetcher) ListFiles(ctx context.Context) ([]string, error) {
+	tree, err := f.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	err = tree.Files().ForEach(func(file *object.File) error {
+		files = append(files, file.Name)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+	log.Printf("Found %d files in git repository", len(files))
+	return files, nil
+}
+
+// FindKustomizationInPath finds kustomization.yaml in a specific path
+func (f *GitFetcher) FindKustomizationInPath(ctx context.Context, path string) (string, error) {
+	tree, err := f.load(ctx)
+	if err != nil {
+		return "", err
+	}
+	path = strings.Trim(path, "/")
+
+	// Try path as a file first (path could be kustomization.yaml)
+	if path != "" {
+		if _, err := tree.File(path); err == nil {
+			content, err := f.FetchFile(ctx, path)
+			if err != nil {
+				return "", err
+			}
+			return string(content), nil
+		}
+	}
+
+	// Try common kustomization file names
+	for _, name := range []string{"kustomization.yaml", "kustomization.yml", "Kustomization"} {
+		p := name
+		if path != "" {
+			p = path + "/" + name
+		}
+		if content, err := f.FetchFile(ctx, p); err == nil {
+			log.Printf("✅ Found kustomization file: %s", p)
+			return string(content), nil
+		}
+	}
+
+	return "", fmt.Errorf("no kustomization file found in path: %s", path)
+}
@@ -0,0 +1,129 @@
+package fetcher
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cjeanner/kustomap/internal/repository"
+)
+
+// initGitRepo creates a git repository in a temp dir with the given files committed on
+// branch main and tagged v1, and returns its path and the commit SHA.
+func initGitRepo(t *testing.T, files map[string]string) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	run := func(args ...string) string {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v (%s)", args, err, string(out))
+		}
+		return strings.TrimSpace(string(out))
+	}
+	run("init", "-b", "main")
+	run("config", "user.name", "Test User")
+	run("config", "user.email", "test@example.com")
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("MkdirAll: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+	run("add", "-A")
+	run("commit", "-m", "initial")
+	run("tag", "v1")
+	return dir, run("rev-parse", "HEAD")
+}
+
+func TestGitFetcher(t *testing.T) {
+	dir, sha := initGitRepo(t, map[string]string{
+		"deploy/base/kustomization.yaml": "resources:\n- deployment.yaml\n",
+		"deploy/base/deployment.yaml":    "kind: Deployment\n",
+		"README.md":                      "readme\n",
+	})
+
+	for _, ref := range []string{"main", "v1", sha} {
+		t.Run(ref, func(t *testing.T) {
+			info := &repository.RepositoryInfo{Type: repository.Git, Owner: "o", Repo: "r", Ref: ref, CloneURL: dir}
+			f, err := NewFetcher(info, "")
+			if err != nil {
+				t.Fatalf("NewFetcher(Git): %v", err)
+			}
+
+			content, err := f.FetchFile(context.Background(), "deploy/base/deployment.yaml")
+			if err != nil {
+				t.Fatalf("FetchFile: %v", err)
+			}
+			if string(content) != "kind: Deployment\n" {
+				t.Errorf("FetchFile = %q", content)
+			}
+			if _, err := f.FetchFile(context.Background(), "missing.yaml"); err == nil {
+				t.Error("FetchFile(missing.yaml) should error")
+			}
+
+			files, err := f.ListFiles(context.Background())
+			if err != nil {
+				t.Fatalf("ListFiles: %v", err)
+			}
+			if len(files) != 3 {
+				t.Errorf("ListFiles = %v, want 3 files", files)
+			}
+
+			kust, err := f.FindKustomizationInPath(context.Background(), "deploy/base")
+			if err != nil {
+				t.Fatalf("FindKustomizationInPath: %v", err)
+			}
+			if !strings.Contains(kust, "deployment.yaml") {
+				t.Errorf("FindKustomizationInPath = %q", kust)
+			}
+			if _, err := f.FindKustomizationInPath(context.Background(), "deploy"); err == nil {
+				t.Error("FindKustomizationInPath(deploy) should error")
+			}
+		})
+	}
+}
+
+func TestGitFetcher_UnknownRef(t *testing.T) {
+	dir, _ := initGitRepo(t, map[string]string{"kustomization.yaml": "resources: []\n"})
+	info := &repository.RepositoryInfo{Type: repository.Git, Owner: "o", Repo: "r", Ref: "nope", CloneURL: dir}
+	f, err := NewGitFetcher(info, "")
+	if err != nil {
+		t.Fatalf("NewGitFetcher: %v", err)
+	}
+	if _, err := f.FetchFile(context.Background(), "kustomization.yaml"); err == nil {
+		t.Error("FetchFile at an unknown ref should error")
+	}
+}
+
+func TestGitFetcher_AnnotatedTag(t *testing.T) {
+	dir, sha := initGitRepo(t, map[string]string{"kustomization.yaml": "resources: []\n"})
+	out, err := exec.Command("git", "-C", dir, "tag", "-a", "v2", "-m", "release v2").CombinedOutput()
+	if err != nil {
+		t.Fatalf("git tag -a: %v (%s)", err, string(out))
+	}
+
+	info := &repository.RepositoryInfo{Type: repository.Git, Owner: "o", Repo: "r", Ref: "v2", CloneURL: dir}
+	resolved, err := repository.ResolveCommitSHA(context.Background(), info, "")
+	if err != nil {
+		t.Fatalf("ResolveCommitSHA(v2): %v", err)
+	}
+	if resolved != sha {
+		t.Fatalf("ResolveCommitSHA(v2) = %s, want the tagged commit %s", resolved, sha)
+	}
+
+	// Pinned references fetch the resolved SHA, which must be a commit
+	info.Ref = resolved
+	f, err := NewGitFetcher(info, "")
+	if err != nil {
+		t.Fatalf("NewGitFetcher: %v", err)
+	}
+	if _, err := f.FetchFile(context.Background(), "kustomization.yaml"); err != nil {
+		t.Errorf("FetchFile at the tagged commit: %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cjeanner/kustomap/internal/repository"
+)
+
+// GiteaFetcher reads files through the Gitea/Forgejo REST API (contents and git/trees).
+type GiteaFetcher struct {
+	client  *http.Client
+	info    *repository.RepositoryInfo
+	token   string
+	repoAPI string
+}
+
+func NewGiteaFetcher(info *repository.RepositoryInfo, token string) (*GiteaFetcher, error) {
+	return &GiteaFetcher{
+		client:  &http.Client{Timeout: 30 * time.Second},
+		info:    info,
+		token:   token,
+		repoAPI: repository.GiteaRepoAPI(info),
+	}, nil
+}
+
+// FetchFile retrieves a single file content
+func (f *GiteaFetcher) FetchFile(ctx context.Context, path string) ([]byte, error) {
+	log.Printf("Fetching file from Gitea: %s/%s/%s @ %s",
+		f.info.Owner, f.info.Repo, path, f.info.Ref)
+
+	body, err := repository.GiteaGet(ctx, f.client,
+		fmt.Sprintf("%s/contents/%s?ref=%s", f.repoAPI, escapePath(path), url.QueryEscape(f.info.Ref)), f.token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch file %s: %w", path, err)
+	}
+
+	// Directories are returned as a JSON array of entries
+	var file struct {
+		Type     string `json:"type"`
+		Encoding string `json:"encoding"`
+		Content  string `json:"content"`
+	}
+	if err := json.Unmarshal(body, &file); err != nil || file.Type != "file" {
+		return nil, fmt.Errorf("file not found: %s", path)
+	}
+	if file.Encoding != "base64" {
+		return []byte(file.Content), nil
+	}
+	content, err := base64.StdEncoding.DecodeString(file.Content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode file content: %w", err)
+	}
+	return content, nil
+}
+
+// ListFiles lists all files recursively in the repository
+func (f *GiteaFetcher) ListFiles(ctx context.Context) ([]string, error) {
+	log.Printf("Listing files from Gitea: %s/%s @ %s",
+		f.info.Owner, f.info.Repo, f.info.Ref)
+
+	var files []string
+	for page := 1; ; page++ {
+		body, err := repository.GiteaGet(ctx, f.client,
+			fmt.Sprintf("%s/git/trees/%s?recursive=true&per_page=1000&page=%d", f.repoAPI, url.PathEscape(f.info.Ref), page), f.token)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get repository tree: %w", err)
+		}
+		var tree struct {
+			Tree []struct {
+				Path string `json:"path"`
+				Type string `json:"type"`
+			} `json:"tree"`
+			Truncated bool `json:"truncated"`
+		}
+		if err := json.Unmarshal(body, &tree); err != nil {
+			return nil, fmt.Errorf("invalid repository tree: %w", err)
+		}
+		for _, entry := range tree.Tree {
+			if entry.Type == "blob" {
+				files = append(files, entry.Path)
+			}
+		}
+		// Large trees are paged; truncated means more entries follow
+		if !tree.Truncated || len(tree.Tree) == 0 {
+			break
+		}
+	}
+
+	log.Printf("Found %d files in repository", len(files))
+	return files, nil
+}
+
+// FindKustomizationInPath finds kustomization.yaml in a specific path
+func (f *GiteaFetcher) FindKustomizationInPath(ctx context.Context, path string) (string, error) {
+	path = strings.Trim(path, "/")
+
+	log.Printf("Trying to fetch path as-is: %s", path)
+	if content, err := f.FetchFile(ctx, path); err == nil {
+		return string(content), nil
+	}
+
+	// Try common kustomization file names
+	for _, name := range []string{"kustomization.yaml", "kustomization.yml", "Kustomization"} {
+		p := name
+		if path != "" {
+			p = path + "/" + name
+		}
+		if content, err := f.FetchFile(ctx, p); err == nil {
+			log.Printf("✅ Found kustomization file: %s", p)
+			return string(content), nil
+		}
+	}
+
+	return "", fmt.Errorf("no kustomization file found in path: %s", path)
+}
@@ -0,0 +1,83 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cjeanner/kustomap/internal/repository"
+)
+
+func TestGiteaFetcher(t *testing.T) {
+	const repoAPI = "/api/v1/repos/org/infra"
+	files := map[string]string{
+		"deploy/overlay/kustomization.yaml": "resources:\n- ../base\n",
+		"deploy/base/deployment.yaml":       "kind: Deployment\n",
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "token secret" {
+			t.Errorf("Authorization = %q, want token auth", got)
+		}
+		switch {
+		case r.URL.Path == repoAPI+"/git/trees/develop":
+			if r.URL.Query().Get("page") == "1" {
+				w.Write([]byte(`{"tree":[{"path":"deploy","type":"tree"},{"path":"deploy/overlay/kustomization.yaml","type":"blob"}],"truncated":true}`))
+				return
+			}
+			w.Write([]byte(`{"tree":[{"path":"deploy/base/deployment.yaml","type":"blob"}],"truncated":false}`))
+		case strings.HasPrefix(r.URL.Path, repoAPI+"/contents/"):
+			if got := r.URL.Query().Get("ref"); got != "develop" {
+				t.Errorf("ref = %q, want develop", got)
+			}
+			path := strings.TrimPrefix(r.URL.Path, repoAPI+"/contents/")
+			if content, ok := files[path]; ok {
+				w.Write([]byte(`{"type":"file","encoding":"base64","content":"` + base64.StdEncoding.EncodeToString([]byte(content)) + `"}`))
+				return
+			}
+			if strings.HasPrefix("deploy/overlay/kustomization.yaml", path+"/") {
+				w.Write([]byte(`[{"type":"file","path":"` + path + `/kustomization.yaml"}]`))
+				return
+			}
+			http.NotFound(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	info := &repository.RepositoryInfo{Type: repository.Gitea, Owner: "org", Repo: "infra", Ref: "develop", BaseURL: srv.URL}
+	f, err := NewFetcher(info, "secret")
+	if err != nil {
+		t.Fatalf("NewFetcher(Gitea): %v", err)
+	}
+
+	content, err := f.FetchFile(context.Background(), "deploy/base/deployment.yaml")
+	if err != nil {
+		t.Fatalf("FetchFile: %v", err)
+	}
+	if string(content) != "kind: Deployment\n" {
+		t.Errorf("FetchFile = %q", content)
+	}
+	if _, err := f.FetchFile(context.Background(), "deploy/overlay"); err == nil {
+		t.Error("FetchFile of a directory should error")
+	}
+
+	list, err := f.ListFiles(context.Background())
+	if err != nil {
+		t.Fatalf("ListFiles: %v", err)
+	}
+	if strings.Join(list, ",") != "deploy/overlay/kustomization.yaml,deploy/base/deployment.yaml" {
+		t.Errorf("ListFiles = %v", list)
+	}
+
+	kust, err := f.FindKustomizationInPath(context.Background(), "deploy/overlay")
+	if err != nil {
+		t.Fatalf("FindKustomizationInPath: %v", err)
+	}
+	if !strings.Contains(kust, "../base") {
+		t.Errorf("FindKustomizationInPath = %q", kust)
+	}
+}
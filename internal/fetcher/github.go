@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"strings"
 
 	"github.com/cjeanner/kustomap/internal/repository"
@@ -13,12 +14,9 @@ import (
 type GitHubFetcher struct {
 	client *github.Client
 	info   *repository.RepositoryInfo
-	ctx    context.Context
 }
 
 func NewGitHubFetcher(info *repository.RepositoryInfo, token string) (*GitHubFetcher, error) {
-	ctx := context.Background()
-
 	var client *github.Client
 	if token != "" {
 		client = github.NewClient(nil).WithAuthToken(token)
@@ -29,22 +27,35 @@ func NewGitHubFetcher(info *repository.RepositoryInfo, token string) (*GitHubFet
 	return &GitHubFetcher{
 		client: client,
 		info:   info,
-		ctx:    ctx,
 	}, nil
 }
 
+// githubHTTPResponse returns the HTTP response of a go-github call (nil when there is none).
+func githubHTTPResponse(resp *github.Response) *http.Response {
+	if resp == nil {
+		return nil
+	}
+	return resp.Response
+}
+
 // FetchFile retrieves a single file content
-func (f *GitHubFetcher) FetchFile(path string) ([]byte, error) {
+func (f *GitHubFetcher) FetchFile(ctx context.Context, path string) ([]byte, error) {
 	log.Printf("Fetching file from GitHub: %s/%s/%s @ %s",
 		f.info.Owner, f.info.Repo, path, f.info.Ref)
 
-	fileContent, _, _, err := f.client.Repositories.GetContents(
-		f.ctx,
-		f.info.Owner,
-		f.info.Repo,
-		path,
-		&github.RepositoryContentGetOptions{Ref: f.info.Ref},
-	)
+	var fileContent *github.RepositoryContent
+	err := repository.WithRateLimitRetry(ctx, f.info.Host(), func() (*http.Response, error) {
+		var resp *github.Response
+		var err error
+		fileContent, _, resp, err = f.client.Repositories.GetContents(
+			ctx,
+			f.info.Owner,
+			f.info.Repo,
+			path,
+			&github.RepositoryContentGetOptions{Ref: f.info.Ref},
+		)
+		return githubHTTPResponse(resp), err
+	})
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch file %s: %w", path, err)
@@ -63,17 +74,23 @@ func (f *GitHubFetcher) FetchFile(path string) ([]byte, error) {
 }
 
 // ListFiles lists all files recursively in the repository
-func (f *GitHubFetcher) ListFiles() ([]string, error) {
+func (f *GitHubFetcher) ListFiles(ctx context.Context) ([]string, error) {
 	log.Printf("Listing files from GitHub: %s/%s @ %s",
 		f.info.Owner, f.info.Repo, f.info.Ref)
 
-	tree, _, err := f.client.Git.GetTree(
-		f.ctx,
-		f.info.Owner,
-		f.info.Repo,
-		f.info.Ref,
-		true, // recursive
-	)
+	var tree *github.Tree
+	err := repository.WithRateLimitRetry(ctx, f.info.Host(), func() (*http.Response, error) {
+		var resp *github.Response
+		var err error
+		tree, resp, err = f.client.Git.GetTree(
+			ctx,
+			f.info.Owner,
+			f.info.Repo,
+			f.info.Ref,
+			true, // recursive
+		)
+		return githubHTTPResponse(resp), err
+	})
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get repository tree: %w", err)
@@ -91,15 +108,18 @@ func (f *GitHubFetcher) ListFiles() ([]string, error) {
 }
 
 // FindKustomizationInPath finds kustomization.yaml in a specific path
-func (f *GitHubFetcher) FindKustomizationInPath(path string) (string, error) {
+func (f *GitHubFetcher) FindKustomizationInPath(ctx context.Context, path string) (string, error) {
 	// Normalize path
 	path = strings.Trim(path, "/")
 
 	log.Printf("Trying to fetch path as-is: %s", path)
-	content, err := f.FetchFile(path)
+	content, err := f.FetchFile(ctx, path)
 	if err == nil {
 		return string(content), nil
 	}
+	if repository.IsRateLimited(err) {
+		return "", err
+	}
 
 	// Try common kustomization file names
 	kustomizationFiles := []string{
@@ -118,11 +138,14 @@ func (f *GitHubFetcher) FindKustomizationInPath(path string) (string, error) {
 
 		log.Printf("Trying to fetch: %s", fullPath)
 
-		content, err := f.FetchFile(fullPath)
+		content, err := f.FetchFile(ctx, fullPath)
 		if err == nil {
 			log.Printf("✅ Found kustomization file: %s", fullPath)
 			return string(content), nil
 		}
+		if repository.IsRateLimited(err) {
+			return "", err
+		}
 	}
 
 	return "", fmt.Errorf("no kustomization file found in path: %s", strings.Clone(path))
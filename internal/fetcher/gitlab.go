@@ -1,9 +1,11 @@
 package fetcher
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 	"log"
+	"net/http"
 	"strings"
 
 	"github.com/cjeanner/kustomap/internal/repository"
@@ -41,18 +43,45 @@ func NewGitLabFetcher(info *repository.RepositoryInfo, token string) (*GitLabFet
 	}, nil
 }
 
+// gitlabHTTPResponse returns the HTTP response of a GitLab client call (nil when there is none).
+func gitlabHTTPResponse(resp *gitlab.Response) *http.Response {
+	if resp == nil {
+		return nil
+	}
+	return resp.Response
+}
+
+// listTree lists a repository tree page, retrying when rate limited.
+func (f *GitLabFetcher) listTree(ctx context.Context, opts *gitlab.ListTreeOptions) ([]*gitlab.TreeNode, *gitlab.Response, error) {
+	var tree []*gitlab.TreeNode
+	var resp *gitlab.Response
+	err := repository.WithRateLimitRetry(ctx, f.info.Host(), func() (*http.Response, error) {
+		var err error
+		tree, resp, err = f.client.Repositories.ListTree(f.projectID, opts, gitlab.WithContext(ctx))
+		return gitlabHTTPResponse(resp), err
+	})
+	return tree, resp, err
+}
+
 // FetchFile retrieves a single file content
-func (f *GitLabFetcher) FetchFile(path string) ([]byte, error) {
+func (f *GitLabFetcher) FetchFile(ctx context.Context, path string) ([]byte, error) {
 	log.Printf("Fetching file from GitLab: %s/%s @ %s",
 		f.projectID, path, f.info.Ref)
 
-	file, _, err := f.client.RepositoryFiles.GetFile(
-		f.projectID,
-		path,
-		&gitlab.GetFileOptions{
-			Ref: gitlab.Ptr(f.info.Ref),
-		},
-	)
+	var file *gitlab.File
+	err := repository.WithRateLimitRetry(ctx, f.info.Host(), func() (*http.Response, error) {
+		var resp *gitlab.Response
+		var err error
+		file, resp, err = f.client.RepositoryFiles.GetFile(
+			f.projectID,
+			path,
+			&gitlab.GetFileOptions{
+				Ref: gitlab.Ptr(f.info.Ref),
+			},
+			gitlab.WithContext(ctx),
+		)
+		return gitlabHTTPResponse(resp), err
+	})
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch file %s: %w", path, err)
@@ -68,7 +97,7 @@ func (f *GitLabFetcher) FetchFile(path string) ([]byte, error) {
 }
 
 // ListFiles lists all files recursively in the repository
-func (f *GitLabFetcher) ListFiles() ([]string, error) {
+func (f *GitLabFetcher) ListFiles(ctx context.Context) ([]string, error) {
 	log.Printf("Listing files from GitLab: %s @ %s",
 		f.projectID, f.info.Ref)
 
@@ -84,7 +113,7 @@ func (f *GitLabFetcher) ListFiles() ([]string, error) {
 	var allFiles []string
 
 	for {
-		tree, resp, err := f.client.Repositories.ListTree(f.projectID, opts)
+		tree, resp, err := f.listTree(ctx, opts)
 		if err != nil {
 			return nil, fmt.Errorf("failed to list repository tree: %w", err)
 		}
@@ -120,14 +149,17 @@ func isKustomizationFileName(name string) bool {
 // FindKustomizationInPath finds kustomization.yaml in a specific path.
 // It tries the path as a file first, then lists the directory (when path is a directory)
 // and picks a kustomization file by name (case-insensitive), matching GitHub fetcher behavior.
-func (f *GitLabFetcher) FindKustomizationInPath(path string) (string, error) {
+func (f *GitLabFetcher) FindKustomizationInPath(ctx context.Context, path string) (string, error) {
 	path = strings.Trim(path, "/")
 
 	log.Printf("Trying to fetch path as-is: %s", path)
-	content, err := f.FetchFile(path)
+	content, err := f.FetchFile(ctx, path)
 	if err == nil {
 		return string(content), nil
 	}
+	if repository.IsRateLimited(err) {
+		return "", err
+	}
 
 	// Path may be a directory: list it and look for a kustomization file by name (case-insensitive)
 	opts := &gitlab.ListTreeOptions{
@@ -136,7 +168,10 @@ func (f *GitLabFetcher) FindKustomizationInPath(path string) (string, error) {
 		Recursive:   gitlab.Ptr(false),
 		ListOptions: gitlab.ListOptions{PerPage: 100, Page: 1},
 	}
-	tree, _, err := f.client.Repositories.ListTree(f.projectID, opts)
+	tree, _, err := f.listTree(ctx, opts)
+	if repository.IsRateLimited(err) {
+		return "", err
+	}
 	if err == nil {
 		for _, node := range tree {
 			if node.Type != "blob" {
@@ -158,11 +193,14 @@ func (f *GitLabFetcher) FindKustomizationInPath(path string) (string, error) {
 					filePath = name
 				}
 			}
-			content, err := f.FetchFile(filePath)
+			content, err := f.FetchFile(ctx, filePath)
 			if err == nil {
 				log.Printf("✅ Found kustomization file: %s", filePath)
 				return string(content), nil
 			}
+			if repository.IsRateLimited(err) {
+				return "", err
+			}
 		}
 	}
 
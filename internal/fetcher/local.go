@@ -1,6 +1,7 @@
 package fetcher
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -46,7 +47,10 @@ func (f *LocalFetcher) joinPath(path string) (string, error) {
 }
 
 // FetchFile retrieves a single file content from the local filesystem.
-func (f *LocalFetcher) FetchFile(path string) ([]byte, error) {
+func (f *LocalFetcher) FetchFile(ctx context.Context, path string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	full, err := f.joinPath(path)
 	if err != nil {
 		return nil, err
@@ -56,13 +60,16 @@ func (f *LocalFetcher) FetchFile(path string) ([]byte, error) {
 }
 
 // ListFiles lists all files recursively under the repository root.
-func (f *LocalFetcher) ListFiles() ([]string, error) {
+func (f *LocalFetcher) ListFiles(ctx context.Context) ([]string, error) {
 	root := filepath.Clean(f.info.RootPath)
 	var files []string
 	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		if info.IsDir() {
 			if info.Name() == ".git" {
 				return filepath.SkipDir
@@ -84,7 +91,10 @@ func (f *LocalFetcher) ListFiles() ([]string, error) {
 }
 
 // FindKustomizationInPath finds kustomization.yaml in a specific path.
-func (f *LocalFetcher) FindKustomizationInPath(path string) (string, error) {
+func (f *LocalFetcher) FindKustomizationInPath(ctx context.Context, path string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
 	path = strings.Trim(path, "/")
 
 	// Try path as a file first (path could be kustomization.yaml)
@@ -0,0 +1,53 @@
+package parser
+
+import (
+	"log"
+	"strings"
+
+	"github.com/cjeanner/kustomap/internal/repository"
+)
+
+// buildFileKinds maps kustomization fields that reference build configuration files
+// to the file kind recorded on their nodes (also used as the edge type).
+var buildFileKinds = map[string]string{
+	"crds":           "crd",
+	"openapi":        "openapi",
+	"configurations": "configuration",
+}
+
+// processBuildFiles adds a "file" node for each file of a crds, openapi or configurations
+// field (CRD schemas, OpenAPI schema, transformer configurations), linked from the
+// kustomization with an edge of the file kind. Files are fetched to check they exist:
+// a missing or unreadable file becomes an error node.
+func (p *Parser) processBuildFiles(parentID, field string, files []string, currentPath string, currentRepo *repository.RepositoryInfo) {
+	kind := buildFileKinds[field]
+	for _, file := range files {
+		if strings.Contains(file, "://") {
+			// Remote files are listed in the kustomization content only
+			continue
+		}
+		filePath := resolvePath(currentPath, file)
+		fileID := p.buildNodeID(currentRepo, filePath)
+		log.Printf("Processing %s file: %s", kind, filePath)
+
+		f, err := p.fetcherForRepo(currentRepo)
+		if err == nil {
+			_, err = f.FetchFile(p.ctx, filePath)
+		}
+		if err != nil {
+			p.addFetchErrorNode(fileID, filePath, field+" file not found or inaccessible: ", err, currentRepo.BaseURL)
+		} else {
+			p.addNode(fileID, "file", filePath, map[string]interface{}{"field": field, "fileKind": kind}, currentRepo.BaseURL)
+		}
+		p.addEdge(parentID, fileID, kind)
+	}
+}
+
+// openAPIFiles returns the schema file of the kustomization "openapi" field, which
+// holds either a path or a builtin Kubernetes version.
+func openAPIFiles(openAPI map[string]string) []string {
+	if file := openAPI["path"]; file != "" {
+		return []string{file}
+	}
+	return nil
+}
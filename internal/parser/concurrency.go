@@ -0,0 +1,228 @@
+package parser
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/cjeanner/kustomap/internal/fetcher"
+	"github.com/cjeanner/kustomap/internal/repository"
+)
+
+// DefaultWorkers is the default number of sibling references fetched in parallel.
+const DefaultWorkers = 8
+
+// fetchPool bounds parallel fetches: at most workers references are resolved at once,
+// and at most perHost[host] (default: workers) fetcher calls hit a host at once.
+type fetchPool struct {
+	workers int
+	perHost map[string]int
+
+	mu    sync.Mutex
+	slots map[string]chan struct{} // per-host semaphores, created on first use
+}
+
+// slot returns the semaphore limiting calls to host.
+func (fp *fetchPool) slot(host string) chan struct{} {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+	if s, ok := fp.slots[host]; ok {
+		return s
+	}
+	limit := fp.workers
+	if n, ok := fp.perHost[host]; ok && n > 0 {
+		limit = n
+	}
+	s := make(chan struct{}, limit)
+	fp.slots[host] = s
+	return s
+}
+
+// SetConcurrency sets how many sibling references (resources, components) are fetched in
+// parallel, and optional per-host limits (host name -> max parallel calls, e.g.
+// {"github.com": 4}). workers <= 1 fetches references one at a time.
+func (p *Parser) SetConcurrency(workers int, perHost map[string]int) {
+	if workers < 1 {
+		workers = 1
+	}
+	p.pool = &fetchPool{workers: workers, perHost: perHost, slots: make(map[string]chan struct{})}
+}
+
+// limitedFetcher holds a per-host slot for the duration of each call.
+type limitedFetcher struct {
+	fetcher fetcher.Fetcher
+	slot    chan struct{}
+}
+
+// acquire takes a slot of the host, giving up when ctx is done first.
+func (f *limitedFetcher) acquire(ctx context.Context) error {
+	select {
+	case f.slot <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (f *limitedFetcher) release() { <-f.slot }
+
+func (f *limitedFetcher) FetchFile(ctx context.Context, path string) ([]byte, error) {
+	if err := f.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer f.release()
+	return f.fetcher.FetchFile(ctx, path)
+}
+
+func (f *limitedFetcher) ListFiles(ctx context.Context) ([]string, error) {
+	if err := f.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer f.release()
+	return f.fetcher.ListFiles(ctx)
+}
+
+func (f *limitedFetcher) FindKustomizationInPath(ctx context.Context, path string) (string, error) {
+	if err := f.acquire(ctx); err != nil {
+		return "", err
+	}
+	defer f.release()
+	return f.fetcher.FindKustomizationInPath(ctx, path)
+}
+
+// limited wraps f so that calls to the host of repo respect the per-host limit.
+func (p *Parser) limited(f fetcher.Fetcher, repo *repository.RepositoryInfo) fetcher.Fetcher {
+	host := repo.Host()
+	if host == "" || p.pool.workers <= 1 {
+		return f
+	}
+	return &limitedFetcher{fetcher: f, slot: p.pool.slot(host)}
+}
+
+// lockKey serializes lookups of the same key (e.g. a repo's default branch) across workers,
+// so each is resolved once. It returns the unlock function.
+func (p *Parser) lockKey(key string) func() {
+	p.mu.Lock()
+	l, ok := p.keyLocks[key]
+	if !ok {
+		l = &sync.Mutex{}
+		p.keyLocks[key] = l
+	}
+	p.mu.Unlock()
+	l.Lock()
+	return l.Unlock
+}
+
+// visited reports whether the kustomization nodeID has already been processed.
+func (p *Parser) visited(nodeID string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.visitedURLs[nodeID]
+}
+
+// prefetchKey identifies a reference of a kustomization.
+func prefetchKey(parentID, ref string) string {
+	return parentID + "\x00" + ref
+}
+
+// prefetch fetches the references among refs (resources and components of parentID) in
+// parallel, ahead of processReference and processManifestFile: kustomizations are resolved
+// and fetched, YAML resources fetched. The graph itself is only modified by the traversal
+// goroutine, in the order of the sequential walk, so parallel fetches do not change element
+// order or node IDs. The other files of a kustomization (patches, generator and helm values
+// files, plugins, build files and replacement sources) are fewer and fetched sequentially.
+func (p *Parser) prefetch(parentID string, refs []string, currentPath string, currentRepo *repository.RepositoryInfo) {
+	if p.pool.workers <= 1 {
+		return
+	}
+	// YAML resources of a plugin kustomization are plugin configs (see processResource)
+	parent := p.nodeByID(parentID)
+	inPlugin := parent != nil && parent.Type == "plugin"
+	var pending []string
+	seen := make(map[string]bool)
+	for _, ref := range refs {
+		yamlFile := isYAMLFile(ref)
+		if yamlFile && (inPlugin || isRemoteManifest(ref)) || seen[ref] {
+			continue
+		}
+		seen[ref] = true
+		pending = append(pending, ref)
+	}
+	if len(pending) < 2 {
+		return // nothing to overlap
+	}
+	log.Printf("Fetching %d references of %s in parallel", len(pending), parentID)
+
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < p.pool.workers && i < len(pending); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ref := range jobs {
+				var target *refTarget
+				if isYAMLFile(ref) {
+					target = p.fetchManifest(ref, currentPath, currentRepo)
+				} else {
+					target = p.resolveReference(ref, currentPath, currentRepo)
+				}
+				p.mu.Lock()
+				p.prefetched[prefetchKey(parentID, ref)] = target
+				p.mu.Unlock()
+			}
+		}()
+	}
+	for _, ref := range pending {
+		jobs <- ref
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// takePrefetched returns (and forgets) the prefetched target of ref in parentID, if any.
+func (p *Parser) takePrefetched(parentID, ref string) *refTarget {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := prefetchKey(parentID, ref)
+	target := p.prefetched[key]
+	delete(p.prefetched, key)
+	return target
+}
+
+// refTarget is a kustomization reference resolved to its node and content (or a fetched
+// YAML resource, see fetchManifest).
+type refTarget struct {
+	id       string
+	path     string
+	repo     *repository.RepositoryInfo // nil when the reference could not be resolved
+	baseURL  string
+	content  string // empty when the node was already processed (processKustomization returns early)
+	errMsg   string // set when the reference could not be resolved
+	fetchErr error  // set when the kustomization could not be fetched
+}
+
+// errorTarget returns a target for an error node.
+func errorTarget(id, nodePath, errMsg, baseURL string) *refTarget {
+	return &refTarget{id: id, path: nodePath, baseURL: baseURL, errMsg: errMsg}
+}
+
+// fetchTarget fetches the kustomization of the target node, unless it was already processed.
+func (p *Parser) fetchTarget(target *refTarget, childFetcher fetcher.Fetcher) *refTarget {
+	if p.visited(target.id) {
+		// Processed kustomizations were fetched successfully: no need to fetch again
+		return target
+	}
+	content, err := childFetcher.FindKustomizationInPath(p.ctx, target.path)
+	if err != nil {
+		// Use explicit copies for log and stored error to avoid corruption from
+		// shared buffers when multiple requests log concurrently.
+		pathCopy := copyLogArgs(target.path)
+		errStr := copyLogArgs(err.Error())
+		log.Printf("⚠️  Warning: failed to fetch kustomization at %s: %s", pathCopy, errStr)
+		target.path = pathCopy
+		target.fetchErr = err
+		return target
+	}
+	target.content = content
+	return target
+}
@@ -0,0 +1,208 @@
+package parser
+
+import (
+	"log"
+	"path"
+	"strings"
+
+	"github.com/cjeanner/kustomap/internal/repository"
+	"github.com/cjeanner/kustomap/internal/types"
+)
+
+// Generator is an entry of the kustomization "configMapGenerator" or "secretGenerator" field.
+type Generator struct {
+	Name      string                 `yaml:"name" json:"name"`
+	Namespace string                 `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+	Behavior  string                 `yaml:"behavior,omitempty" json:"behavior,omitempty"` // create (default), merge, replace
+	Type      string                 `yaml:"type,omitempty" json:"type,omitempty"`         // secretGenerator only
+	Files     []string               `yaml:"files,omitempty" json:"files,omitempty"`
+	Envs      []string               `yaml:"envs,omitempty" json:"envs,omitempty"`
+	Env       string                 `yaml:"env,omitempty" json:"env,omitempty"` // deprecated single env file
+	Literals  []string               `yaml:"literals,omitempty" json:"literals,omitempty"`
+	Options   map[string]interface{} `yaml:"options,omitempty" json:"options,omitempty"`
+}
+
+// generatorKinds maps generator fields to the kind of object they generate.
+var generatorKinds = map[string]string{
+	"configMapGenerator": "ConfigMap",
+	"secretGenerator":    "Secret",
+}
+
+// processGenerators adds a "generator" node for each configMapGenerator/secretGenerator
+// entry, with "source" edges to the files and env files it reads. Generators with
+// behavior merge or replace are linked to the generator of the same name they modify
+// in the kustomization's resources/components (edge type "merge" or "replace").
+// Generators of the same name in different namespaces are distinct nodes: the namespace,
+// if any, is part of the node path (field/namespace/name).
+// Must run after resources and components are processed so that those generators exist.
+func (p *Parser) processGenerators(parentID, field string, generators []Generator, currentPath string, currentRepo *repository.RepositoryInfo) {
+	kind := generatorKinds[field]
+	for i, gen := range generators {
+		genPath := path.Join(currentPath, field, gen.Namespace, gen.Name)
+		if gen.Name == "" {
+			genPath = inlineNodePath(currentPath, field, i)
+		}
+		log.Printf("Processing %s: %s", field, genPath)
+
+		genID := p.buildNodeID(currentRepo, genPath)
+		p.addNode(genID, "generator", genPath, generatorContent(kind, gen), currentRepo.BaseURL)
+		p.addEdge(parentID, genID, "generator")
+
+		var sources []string
+		for _, file := range gen.Files {
+			sources = append(sources, generatorFilePath(file))
+		}
+		sources = append(sources, gen.Envs...)
+		if gen.Env != "" {
+			sources = append(sources, gen.Env)
+		}
+		for _, source := range sources {
+			sourcePath := resolvePath(currentPath, source)
+			sourceID := p.buildNodeID(currentRepo, sourcePath)
+			p.addNode(sourceID, "file", sourcePath, nil, currentRepo.BaseURL)
+			p.addEdge(genID, sourceID, "source")
+		}
+
+		if gen.Behavior == "merge" || gen.Behavior == "replace" {
+			for _, baseID := range p.findInheritedGenerators(parentID, kind, gen) {
+				p.addEdge(genID, baseID, gen.Behavior)
+			}
+		}
+	}
+}
+
+// generatorContent returns the node content for a generator. Secret literal values
+// are never exposed: only their keys are listed.
+func generatorContent(kind string, gen Generator) map[string]interface{} {
+	behavior := gen.Behavior
+	if behavior == "" {
+		behavior = "create"
+	}
+	content := map[string]interface{}{
+		"kind":     kind,
+		"name":     gen.Name,
+		"behavior": behavior,
+	}
+	if gen.Namespace != "" {
+		content["namespace"] = gen.Namespace
+	}
+	if gen.Type != "" {
+		content["type"] = gen.Type
+	}
+	if len(gen.Files) > 0 {
+		content["files"] = gen.Files
+	}
+	envs := gen.Envs
+	if gen.Env != "" {
+		envs = append(append([]string{}, envs...), gen.Env)
+	}
+	if len(envs) > 0 {
+		content["envs"] = envs
+	}
+	if len(gen.Literals) > 0 {
+		if kind == "Secret" {
+			content["literals"] = literalKeys(gen.Literals)
+		} else {
+			content["literals"] = gen.Literals
+		}
+	}
+	if len(gen.Options) > 0 {
+		content["options"] = gen.Options
+	}
+	return content
+}
+
+// redactSecretGenerators returns a copy of the secretGenerator entries with literal
+// values stripped (keys only), safe to store in kustomization node content.
+func redactSecretGenerators(generators []Generator) []Generator {
+	if generators == nil {
+		return nil
+	}
+	redacted := make([]Generator, len(generators))
+	for i, gen := range generators {
+		redacted[i] = gen
+		redacted[i].Literals = literalKeys(gen.Literals)
+	}
+	return redacted
+}
+
+// literalKeys returns the keys of KEY=VALUE literals.
+func literalKeys(literals []string) []string {
+	if literals == nil {
+		return nil
+	}
+	keys := make([]string, 0, len(literals))
+	for _, literal := range literals {
+		key, _, _ := strings.Cut(literal, "=")
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// generatorFilePath returns the file path of a generator "files" entry, which is
+// either "path" or "key=path".
+func generatorFilePath(entry string) string {
+	if _, file, ok := strings.Cut(entry, "="); ok {
+		return file
+	}
+	return entry
+}
+
+// findInheritedGenerators returns the IDs of generator nodes of the given kind and name
+// reachable from parentID through resource/component edges (i.e. defined in the bases
+// and components the generator merges into or replaces).
+func (p *Parser) findInheritedGenerators(parentID, kind string, gen Generator) []string {
+	children := make(map[string][]types.ElementData)
+	for _, elem := range p.graph.Elements {
+		if elem.Group == "edges" {
+			children[elem.Data.Source] = append(children[elem.Data.Source], elem.Data)
+		}
+	}
+
+	var matches []string
+	visited := map[string]bool{parentID: true}
+	queue := []string{parentID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, edge := range children[id] {
+			switch edge.EdgeType {
+			case "resource", "component":
+				if !visited[edge.Target] {
+					visited[edge.Target] = true
+					queue = append(queue, edge.Target)
+				}
+			case "generator":
+				if id == parentID {
+					continue
+				}
+				node := p.nodeByID(edge.Target)
+				if node == nil || node.Content["kind"] != kind || node.Content["name"] != gen.Name {
+					continue
+				}
+				if ns, _ := node.Content["namespace"].(string); ns != "" && gen.Namespace != "" && ns != gen.Namespace {
+					continue
+				}
+				matches = append(matches, edge.Target)
+			}
+		}
+	}
+	if len(matches) == 0 {
+		log.Printf("No generator %s/%s found for behavior %s (from %s, %d nodes searched)",
+			kind, gen.Name, gen.Behavior, parentID, len(visited))
+	} else {
+		log.Printf("Generator %s/%s (%s) modifies %d generator(s)", kind, gen.Name, gen.Behavior, len(matches))
+	}
+	return matches
+}
+
+// nodeByID returns the data of the node with the given ID, or nil if absent.
+func (p *Parser) nodeByID(id string) *types.ElementData {
+	for i := range p.graph.Elements {
+		elem := &p.graph.Elements[i]
+		if elem.Group == "nodes" && elem.Data.ID == id {
+			return &elem.Data
+		}
+	}
+	return nil
+}
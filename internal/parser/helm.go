@@ -0,0 +1,109 @@
+package parser
+
+import (
+	"log"
+	"path"
+	"strings"
+
+	"github.com/cjeanner/kustomap/internal/repository"
+)
+
+// defaultChartHome is the directory kustomize looks in for charts without a repo.
+const defaultChartHome = "charts"
+
+// HelmChart is an entry of the kustomization "helmCharts" field.
+type HelmChart struct {
+	Name                  string                 `yaml:"name" json:"name"`
+	Version               string                 `yaml:"version,omitempty" json:"version,omitempty"`
+	Repo                  string                 `yaml:"repo,omitempty" json:"repo,omitempty"`
+	ReleaseName           string                 `yaml:"releaseName,omitempty" json:"releaseName,omitempty"`
+	Namespace             string                 `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+	ValuesFile            string                 `yaml:"valuesFile,omitempty" json:"valuesFile,omitempty"`
+	AdditionalValuesFiles []string               `yaml:"additionalValuesFiles,omitempty" json:"additionalValuesFiles,omitempty"`
+	ValuesInline          map[string]interface{} `yaml:"valuesInline,omitempty" json:"valuesInline,omitempty"`
+	ValuesMerge           string                 `yaml:"valuesMerge,omitempty" json:"valuesMerge,omitempty"`
+	IncludeCRDs           bool                   `yaml:"includeCRDs,omitempty" json:"includeCRDs,omitempty"`
+}
+
+// HelmGlobals is the kustomization "helmGlobals" field.
+type HelmGlobals struct {
+	ChartHome  string `yaml:"chartHome,omitempty" json:"chartHome,omitempty"`
+	ConfigHome string `yaml:"configHome,omitempty" json:"configHome,omitempty"`
+}
+
+// processHelmCharts adds a "chart" node for each helmCharts entry, linked to the
+// kustomization with a "chart" edge, and "values" edges from the chart to its local values files.
+func (p *Parser) processHelmCharts(parentID string, charts []HelmChart, globals *HelmGlobals, currentPath string, currentRepo *repository.RepositoryInfo) {
+	for i, chart := range charts {
+		release := chart.ReleaseName
+		if release == "" {
+			release = chart.Name
+		}
+		chartPath := path.Join(currentPath, "helmCharts", release)
+		if release == "" {
+			chartPath = inlineNodePath(currentPath, "helmCharts", i)
+		}
+		log.Printf("Processing helm chart: %s (repo: %s, version: %s)", chart.Name, chart.Repo, chart.Version)
+
+		chartID := p.buildNodeID(currentRepo, chartPath)
+		p.addNode(chartID, "chart", chartPath, helmChartContent(chart, globals, currentPath), currentRepo.BaseURL)
+		p.addEdge(parentID, chartID, "chart")
+
+		valuesFiles := chart.AdditionalValuesFiles
+		if chart.ValuesFile != "" {
+			valuesFiles = append([]string{chart.ValuesFile}, valuesFiles...)
+		}
+		for _, valuesFile := range valuesFiles {
+			if strings.Contains(valuesFile, "://") {
+				// Remote values files are listed in the chart content only
+				continue
+			}
+			valuesPath := resolvePath(currentPath, valuesFile)
+			valuesID := p.buildNodeID(currentRepo, valuesPath)
+			p.addNode(valuesID, "file", valuesPath, nil, currentRepo.BaseURL)
+			p.addEdge(chartID, valuesID, "values")
+		}
+	}
+}
+
+// helmChartContent returns the node content for a chart. Charts without a repo are
+// read from the chart home (helmGlobals.chartHome, default "charts"); its path is recorded.
+func helmChartContent(chart HelmChart, globals *HelmGlobals, currentPath string) map[string]interface{} {
+	content := map[string]interface{}{
+		"name": chart.Name,
+	}
+	if chart.Repo != "" {
+		content["repo"] = chart.Repo
+	} else {
+		chartHome := defaultChartHome
+		if globals != nil && globals.ChartHome != "" {
+			chartHome = globals.ChartHome
+		}
+		content["localChart"] = resolvePath(currentPath, path.Join(chartHome, chart.Name))
+	}
+	if chart.Version != "" {
+		content["version"] = chart.Version
+	}
+	if chart.ReleaseName != "" {
+		content["releaseName"] = chart.ReleaseName
+	}
+	if chart.Namespace != "" {
+		content["namespace"] = chart.Namespace
+	}
+	if chart.ValuesFile != "" {
+		content["valuesFile"] = chart.ValuesFile
+	}
+	if len(chart.AdditionalValuesFiles) > 0 {
+		content["additionalValuesFiles"] = chart.AdditionalValuesFiles
+	}
+	if len(chart.ValuesInline) > 0 {
+		content["valuesInline"] = chart.ValuesInline
+	}
+	if chart.ValuesMerge != "" {
+		content["valuesMerge"] = chart.ValuesMerge
+	}
+	if chart.IncludeCRDs {
+		content["includeCRDs"] = true
+	}
+	return content
+}
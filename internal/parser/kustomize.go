@@ -1,12 +1,15 @@
 package parser
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 
+	"github.com/cjeanner/kustomap/internal/cache"
 	"github.com/cjeanner/kustomap/internal/fetcher"
 	"github.com/cjeanner/kustomap/internal/repository"
 	"github.com/cjeanner/kustomap/internal/types"
@@ -20,12 +23,47 @@ func copyLogArgs(s string) string { return strings.Clone(s) }
 
 // Kustomization represents a kustomization.yaml file structure
 type Kustomization struct {
-	Resources  []string      `yaml:"resources"`
-	Components []string      `yaml:"components"`
-	Patches    []interface{} `yaml:"patches"`
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"` // "Kustomization" (default) or "Component"
+
+	Resources  []string `yaml:"resources"`
+	Components []string `yaml:"components"`
+	Patches    []Patch  `yaml:"patches"`
+
+	ConfigMapGenerator []Generator `yaml:"configMapGenerator"`
+	SecretGenerator    []Generator `yaml:"secretGenerator"`
+
+	HelmCharts  []HelmChart  `yaml:"helmCharts"`
+	HelmGlobals *HelmGlobals `yaml:"helmGlobals"`
+
+	// Plugin configs (files, inline configs or kustomization directories)
+	Transformers []string `yaml:"transformers"`
+	Generators   []string `yaml:"generators"`
+	Validators   []string `yaml:"validators"`
+
+	// Transformers applied to all resources of the kustomization
+	Namespace         string            `yaml:"namespace"`
+	NamePrefix        string            `yaml:"namePrefix"`
+	NameSuffix        string            `yaml:"nameSuffix"`
+	Images            []Image           `yaml:"images"`
+	Replicas          []Replica         `yaml:"replicas"`
+	CommonLabels      map[string]string `yaml:"commonLabels"`
+	Labels            []Label           `yaml:"labels"`
+	CommonAnnotations map[string]string `yaml:"commonAnnotations"`
+
+	// Build configuration files
+	Crds           []string          `yaml:"crds"`
+	OpenAPI        map[string]string `yaml:"openapi"` // "path" or "version"
+	Configurations []string          `yaml:"configurations"`
+
+	// Data flow between resources
+	Replacements []Replacement `yaml:"replacements"`
 
 	// Deprecated but still supported for backward compatibility
-	Bases []string `yaml:"bases"`
+	Vars                  []Var    `yaml:"vars"`
+	Bases                 []string `yaml:"bases"`
+	PatchesStrategicMerge []string `yaml:"patchesStrategicMerge"`
+	PatchesJson6902       []Patch  `yaml:"patchesJson6902"`
 }
 
 // FetcherFactory creates a fetcher for a given repo and token.
@@ -39,8 +77,32 @@ type Parser struct {
 	repoInfo       *repository.RepositoryInfo
 	tokens         map[repository.RepositoryType]string // GitHub and GitLab tokens
 	graph          *types.Graph
-	visitedURLs    map[string]bool // Prevent infinite loops
-	FetcherFactory FetcherFactory // optional; used in tests to inject mock fetchers
+	visitedURLs    map[string]bool            // Prevent infinite loops
+	kustomizations map[string]*Kustomization  // parsed kustomization per node ID (for the effective view)
+	replacements   map[string][]Replacement   // resolved replacements per kustomization node ID
+	defaultBranch  map[string]string          // default branch per remote repo (see resolveDefaultBranch)
+	floatingIDs    map[string]bool            // node IDs built on a floating default branch
+	commitSHA      map[string]string          // commit SHA per remote repo@ref (see pinCommit)
+	nodeCommits    map[string]string          // commit SHA per node ID
+	cloneURLs      map[string]string          // clone URL per plain git node ID
+	repos          map[string]string          // repository (type:owner/repo) per remote node ID
+	fetchers       map[string]fetcher.Fetcher // fetcher per repo@commit (see getFetcherForRepo)
+	cache          *cache.Store               // optional on-disk content cache for pinned repos
+	cacheStats     fetcher.CacheStats         // cache hits and misses of this parse
+	FetcherFactory FetcherFactory             // optional; used in tests to inject mock fetchers
+
+	// Sibling references are fetched by workers (see prefetch); mu guards the maps above
+	// that workers share with the traversal (visitedURLs, defaultBranch, floatingIDs,
+	// commitSHA, nodeCommits, cloneURLs, repos, fetchers) and the ones below.
+	mu         sync.Mutex
+	keyLocks   map[string]*sync.Mutex // per-key locks of lookups in flight (see lockKey)
+	prefetched map[string]*refTarget  // references fetched ahead, by parent ID and ref
+	factoryMu  sync.Mutex             // serializes fetcher creation (FetcherFactory need not be thread-safe)
+	pool       *fetchPool
+
+	// ctx is the context of the Parse call in progress: fetches made during the walk
+	// use it, and the walk stops once it is done.
+	ctx context.Context
 }
 
 // sameRepoAsEntry reports whether current is the same repo as entry.
@@ -56,21 +118,82 @@ func sameRepoAsEntry(entry, current *repository.RepositoryInfo) bool {
 }
 
 // getFetcherForRepo returns a fetcher for the given repo, using FetcherFactory if set (e.g. in tests).
+// Remote repos are fetched at the commit their ref pointed to when first touched (see pinCommit).
+// Fetchers are created once per repo@commit and reused, so that e.g. a git remote is cloned once.
 func (p *Parser) getFetcherForRepo(repo *repository.RepositoryInfo, token string) (fetcher.Fetcher, error) {
+	sha := p.pinCommit(repo, token)
+	if sha != "" {
+		pinned := *repo
+		pinned.Ref = sha
+		repo = &pinned
+	}
+	key := fetcherKey(repo)
+	defer p.lockKey("fetcher:" + key)()
+	p.mu.Lock()
+	f, ok := p.fetchers[key]
+	p.mu.Unlock()
+	if ok {
+		return f, nil
+	}
+	f, err := p.newFetcher(repo, token)
+	if err != nil {
+		return nil, err
+	}
+	f = p.cached(p.limited(f, repo), repo, sha)
+	p.mu.Lock()
+	p.fetchers[key] = f
+	p.mu.Unlock()
+	return f, nil
+}
+
+// newFetcher creates a fetcher for repo with FetcherFactory if set, otherwise with fetcher.NewFetcher.
+func (p *Parser) newFetcher(repo *repository.RepositoryInfo, token string) (fetcher.Fetcher, error) {
+	p.factoryMu.Lock()
+	defer p.factoryMu.Unlock()
 	if p.FetcherFactory != nil {
 		return p.FetcherFactory(repo, token)
 	}
 	return fetcher.NewFetcher(repo, token)
 }
 
+// cached wraps f with the content cache when one is set and repo is pinned to commit sha.
+func (p *Parser) cached(f fetcher.Fetcher, repo *repository.RepositoryInfo, sha string) fetcher.Fetcher {
+	if p.cache == nil || sha == "" {
+		return f
+	}
+	return fetcher.NewCachedFetcher(f, p.cache, repo, sha, &p.cacheStats)
+}
+
+// fetcherForRepo returns a fetcher for files in repo: the entry fetcher when repo is the
+// entry repo, otherwise a fetcher created for repo.
+func (p *Parser) fetcherForRepo(repo *repository.RepositoryInfo) (fetcher.Fetcher, error) {
+	if sameRepoAsEntry(p.repoInfo, repo) {
+		return p.fetcher, nil
+	}
+	return p.getFetcherForRepo(repo, p.tokens[repo.Type])
+}
+
 // NewParser creates a new Kustomize parser
 func NewParser(f fetcher.Fetcher, repoInfo *repository.RepositoryInfo) *Parser {
 	return &Parser{
-		fetcher:     f,
-		repoInfo:    repoInfo,
+		fetcher:        f,
+		repoInfo:       repoInfo,
 		tokens:         make(map[repository.RepositoryType]string),
-		graph:          &types.Graph{Elements: []types.Element{}, BaseURLs: make(map[string]string), LocalRootPaths: make(map[string]string)},
+		graph:          &types.Graph{Elements: []types.Element{}, BaseURLs: make(map[string]string), CommitSHAs: make(map[string]string), CloneURLs: make(map[string]string), Repos: make(map[string]string), LocalRootPaths: make(map[string]string)},
 		visitedURLs:    make(map[string]bool),
+		kustomizations: make(map[string]*Kustomization),
+		replacements:   make(map[string][]Replacement),
+		defaultBranch:  make(map[string]string),
+		floatingIDs:    make(map[string]bool),
+		commitSHA:      make(map[string]string),
+		nodeCommits:    make(map[string]string),
+		cloneURLs:      make(map[string]string),
+		repos:          make(map[string]string),
+		fetchers:       make(map[string]fetcher.Fetcher),
+		keyLocks:       make(map[string]*sync.Mutex),
+		prefetched:     make(map[string]*refTarget),
+		pool:           &fetchPool{workers: DefaultWorkers, slots: make(map[string]chan struct{})},
+		ctx:            context.Background(),
 	}
 }
 
@@ -79,22 +202,71 @@ func (p *Parser) SetToken(repoType repository.RepositoryType, token string) {
 	p.tokens[repoType] = token
 }
 
-// Parse starts parsing from the initial path
-func (p *Parser) Parse(startPath string) (*types.Graph, error) {
+// SetCache sets the on-disk cache used for content of repos pinned to a commit
+func (p *Parser) SetCache(store *cache.Store) {
+	p.cache = store
+}
+
+// Parse starts parsing from the initial path. Fetches stop when ctx is done, in which
+// case Parse returns the context error.
+func (p *Parser) Parse(ctx context.Context, startPath string) (*types.Graph, error) {
 	log.Printf("Starting parse from path: %s", startPath)
+	p.ctx = ctx
+
+	// Pin the entry repo to the commit its ref points to now, and read it at that commit
+	// (as remote repos are, see getFetcherForRepo) rather than at the moving ref
+	if p.repoInfo.Type != repository.Local {
+		token := p.tokens[p.repoInfo.Type]
+		repo := p.repoInfo
+		sha := p.pinCommit(repo, token)
+		if sha != "" && sha != repo.Ref {
+			pinned := *repo
+			pinned.Ref = sha
+			repo = &pinned
+			f, err := p.newFetcher(repo, token)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create fetcher at commit %s: %w", sha, err)
+			}
+			p.fetcher = f
+		}
+		p.fetcher = p.cached(p.limited(p.fetcher, repo), repo, sha)
+	}
+	if p.cache != nil {
+		defer func() {
+			log.Printf("Content cache: %d hits, %d misses", p.cacheStats.Hits.Load(), p.cacheStats.Misses.Load())
+		}()
+	}
 
 	// Fetch the initial kustomization.yaml
-	content, err := p.fetcher.FindKustomizationInPath(startPath)
+	nodeID := p.buildNodeID(p.repoInfo, startPath)
+	content, err := p.fetcher.FindKustomizationInPath(ctx, startPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch initial kustomization: %w", err)
+		if !repository.IsRateLimited(err) {
+			return nil, fmt.Errorf("failed to fetch initial kustomization: %w", err)
+		}
+		// Rate limits are transient: report them on the entry node, like on any other node
+		p.addFetchErrorNode(nodeID, startPath, "", err, p.repoInfo.BaseURL)
+		return p.graph, nil
 	}
 
 	// Parse and process recursively (entry point is an overlay)
-	nodeID := p.buildNodeID(p.repoInfo, startPath)
 	err = p.processKustomization(nodeID, content, startPath, p.repoInfo, "overlay")
 	if err != nil {
 		return nil, err
 	}
+	// A graph cut short by cancellation would silently miss nodes
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("parse interrupted: %w", err)
+	}
+
+	// Link replacements and vars from the kustomization defining their source to those they rewrite
+	p.linkReplacements()
+
+	// Record the commit SHA (and git clone URL) of each node and flag floating default branches
+	p.annotateRefs()
+
+	// Combine transformers along the overlay chain (final image tags, namespace, ...)
+	p.computeEffectiveTransformers()
 
 	log.Printf("✅ Graph built with %d elements", len(p.graph.Elements))
 	return p.graph, nil
@@ -104,12 +276,22 @@ func (p *Parser) Parse(startPath string) (*types.Graph, error) {
 // nodeType is the kind of this node: "overlay" for the entry point, "resource" when
 // reached via resources/bases, or "component" when reached via components.
 func (p *Parser) processKustomization(nodeID, content, currentPath string, currentRepo *repository.RepositoryInfo, nodeType string) error {
+	if err := p.ctx.Err(); err != nil {
+		return err
+	}
+
 	// Check if already visited to prevent loops
-	if p.visitedURLs[nodeID] {
+	if p.visited(nodeID) {
 		log.Printf("Already visited: %s", nodeID)
+		// The same directory may be referenced differently (e.g. both as resource and component)
+		if kust, ok := p.kustomizations[nodeID]; ok {
+			p.checkKind(nodeID, kust.Kind, nodeType)
+		}
 		return nil
 	}
+	p.mu.Lock()
 	p.visitedURLs[nodeID] = true
+	p.mu.Unlock()
 
 	log.Printf("Processing kustomization at: %s (type: %s)", nodeID, nodeType)
 
@@ -118,13 +300,18 @@ func (p *Parser) processKustomization(nodeID, content, currentPath string, curre
 	if err := yaml.Unmarshal([]byte(content), &kust); err != nil {
 		return fmt.Errorf("failed to parse kustomization YAML: %w", err)
 	}
+	p.kustomizations[nodeID] = &kust
 
 	// Create node for this kustomization (type reflects how it was referenced)
-	p.addNode(nodeID, nodeType, currentPath, &kust, currentRepo.BaseURL)
+	p.addNode(nodeID, nodeType, currentPath, kustomizationContent(&kust), currentRepo.BaseURL)
+	p.checkKind(nodeID, kust.Kind, nodeType)
 
 	// Merge bases into resources (backward compatibility)
 	allResources := append(kust.Resources, kust.Bases...)
 
+	// Fetch the kustomizations and YAML files referenced by resources and components in parallel
+	p.prefetch(nodeID, append(append([]string{}, allResources...), kust.Components...), currentPath, currentRepo)
+
 	// Process all resources (files + kustomizations)
 	for _, resource := range allResources {
 		if err := p.processResource(nodeID, resource, currentPath, currentRepo); err != nil {
@@ -132,6 +319,9 @@ func (p *Parser) processKustomization(nodeID, content, currentPath string, curre
 		}
 	}
 
+	// Count objects defined by this kustomization's own YAML resources
+	p.countObjects(nodeID)
+
 	// Process components (reusable components)
 	for _, component := range kust.Components {
 		if err := p.processReference(nodeID, component, "component", currentPath, currentRepo); err != nil {
@@ -139,6 +329,32 @@ func (p *Parser) processKustomization(nodeID, content, currentPath string, curre
 		}
 	}
 
+	// Process patches (file or inline); each becomes a patch node.
+	// Legacy patch fields get the same treatment, flagged as deprecated.
+	p.processPatches(nodeID, "patches", kust.Patches, currentPath, currentRepo)
+	p.processPatches(nodeID, "patchesStrategicMerge", strategicMergePatches(kust.PatchesStrategicMerge), currentPath, currentRepo)
+	p.processPatches(nodeID, "patchesJson6902", kust.PatchesJson6902, currentPath, currentRepo)
+
+	// Process generators last: merge/replace behaviors link to generators in resources/components
+	p.processGenerators(nodeID, "configMapGenerator", kust.ConfigMapGenerator, currentPath, currentRepo)
+	p.processGenerators(nodeID, "secretGenerator", kust.SecretGenerator, currentPath, currentRepo)
+
+	// Process helm charts and their values files
+	p.processHelmCharts(nodeID, kust.HelmCharts, kust.HelmGlobals, currentPath, currentRepo)
+
+	// Process plugins (transformers, generators, validators)
+	p.processPlugins(nodeID, "transformers", kust.Transformers, currentPath, currentRepo)
+	p.processPlugins(nodeID, "generators", kust.Generators, currentPath, currentRepo)
+	p.processPlugins(nodeID, "validators", kust.Validators, currentPath, currentRepo)
+
+	// Process build configuration files (CRD schemas, OpenAPI schema, transformer configurations)
+	p.processBuildFiles(nodeID, "crds", kust.Crds, currentPath, currentRepo)
+	p.processBuildFiles(nodeID, "openapi", openAPIFiles(kust.OpenAPI), currentPath, currentRepo)
+	p.processBuildFiles(nodeID, "configurations", kust.Configurations, currentPath, currentRepo)
+
+	// Load replacements (inline or from files); edges are added once the graph is complete
+	p.processReplacements(nodeID, kust.Replacements, currentPath, currentRepo)
+
 	return nil
 }
 
@@ -148,21 +364,51 @@ func (p *Parser) processReference(parentID, ref, refType, currentPath string, cu
 
 	// Check if it's a YAML file
 	if isYAMLFile(ref) {
-		resourcePath := path.Join(currentPath, ref)
-		childID := p.buildNodeID(currentRepo, resourcePath)
-		p.addNode(childID, "resource", resourcePath, nil, currentRepo.BaseURL)
-		p.addEdge(parentID, childID, refType)
+		p.processManifestFile(parentID, refType, ref, currentPath, currentRepo)
+		return nil
+	}
+
+	target := p.takePrefetched(parentID, ref)
+	if target == nil {
+		target = p.resolveReference(ref, currentPath, currentRepo)
+	}
+	childRepo := target.repo
+
+	// Store per-node local root for nodes in external local repos (used by build endpoint)
+	if childRepo != nil && childRepo.Type == repository.Local && childRepo.RootPath != "" && !sameRepoAsEntry(p.repoInfo, childRepo) {
+		if p.graph.LocalRootPaths == nil {
+			p.graph.LocalRootPaths = make(map[string]string)
+		}
+		p.graph.LocalRootPaths[target.id] = childRepo.RootPath
+	}
+
+	if target.errMsg != "" || target.fetchErr != nil {
+		if target.fetchErr != nil {
+			p.addFetchErrorNode(target.id, target.path, "File not found or inaccessible: ", target.fetchErr, target.baseURL)
+		} else {
+			p.addErrorNode(target.id, target.path, target.errMsg, target.baseURL)
+		}
+		p.addEdge(parentID, target.id, refType) // Edge AFTER node creation
 		return nil
 	}
 
+	// Add edge BEFORE processing (so the node will exist after processKustomization)
+	p.addEdge(parentID, target.id, refType)
+
+	// Recursively process the child (creates the node with type = refType: "resource" or "component",
+	// or "plugin" for transformers/generators/validators)
+	return p.processKustomization(target.id, target.content, target.path, childRepo, nodeTypeForRef(refType))
+}
+
+// resolveReference resolves a kustomization reference (relative or remote) to the node it
+// points to and fetches its kustomization. Failures are returned as error targets; the graph
+// is not modified, so references can be resolved by fetch workers (see prefetch).
+func (p *Parser) resolveReference(ref, currentPath string, currentRepo *repository.RepositoryInfo) *refTarget {
 	// Parse the reference
 	token := p.tokens[currentRepo.Type]
-	kustomizeRef, err := ParseReference(ref, token)
+	kustomizeRef, err := ParseReference(p.ctx, ref, token)
 	if err != nil {
-		childID := fmt.Sprintf("error:%s", ref)
-		p.addErrorNode(childID, ref, fmt.Sprintf("Failed to parse reference: %v", err), currentRepo.BaseURL)
-		p.addEdge(parentID, childID, refType) // Edge AFTER node creation
-		return nil
+		return errorTarget(fmt.Sprintf("error:%s", ref), ref, fmt.Sprintf("Failed to parse reference: %v", err), currentRepo.BaseURL)
 	}
 
 	var childFetcher fetcher.Fetcher
@@ -187,26 +433,17 @@ func (p *Parser) processReference(parentID, ref, refType, currentPath string, cu
 				// Path references a directory outside the current repo; validate and resolve as external local repo
 				validatedPath, err := validation.ValidateLocalPath(absPath)
 				if err != nil {
-					childID := p.buildNodeID(currentRepo, childPath)
-					p.addErrorNode(childID, childPath, fmt.Sprintf("Invalid local path: %v", err), currentRepo.BaseURL)
-					p.addEdge(parentID, childID, refType)
-					return nil
+					return errorTarget(p.buildNodeID(currentRepo, childPath), childPath, fmt.Sprintf("Invalid local path: %v", err), currentRepo.BaseURL)
 				}
 				extRepo, err := repository.DetectLocalRepository(validatedPath)
 				if err != nil {
-					childID := p.buildNodeID(currentRepo, childPath)
-					p.addErrorNode(childID, childPath, fmt.Sprintf("Failed to detect repository: %v", err), currentRepo.BaseURL)
-					p.addEdge(parentID, childID, refType)
-					return nil
+					return errorTarget(p.buildNodeID(currentRepo, childPath), childPath, fmt.Sprintf("Failed to detect repository: %v", err), currentRepo.BaseURL)
 				}
 				childRepo = extRepo
 				childPath = extRepo.Path
 				lf, err := fetcher.NewLocalFetcher(extRepo, "")
 				if err != nil {
-					childID := p.buildNodeID(extRepo, childPath)
-					p.addErrorNode(childID, childPath, fmt.Sprintf("Failed to create fetcher: %v", err), extRepo.BaseURL)
-					p.addEdge(parentID, childID, refType)
-					return nil
+					return errorTarget(p.buildNodeID(extRepo, childPath), childPath, fmt.Sprintf("Failed to create fetcher: %v", err), extRepo.BaseURL)
 				}
 				childFetcher = lf
 			} else {
@@ -218,10 +455,7 @@ func (p *Parser) processReference(parentID, ref, refType, currentPath string, cu
 					var err error
 					childFetcher, err = p.getFetcherForRepo(currentRepo, tok)
 					if err != nil {
-						childID := p.buildNodeID(currentRepo, childPath)
-						p.addErrorNode(childID, childPath, fmt.Sprintf("Failed to create fetcher: %v", err), currentRepo.BaseURL)
-						p.addEdge(parentID, childID, refType)
-						return nil
+						return errorTarget(p.buildNodeID(currentRepo, childPath), childPath, fmt.Sprintf("Failed to create fetcher: %v", err), currentRepo.BaseURL)
 					}
 				}
 			}
@@ -234,10 +468,7 @@ func (p *Parser) processReference(parentID, ref, refType, currentPath string, cu
 				var err error
 				childFetcher, err = p.getFetcherForRepo(currentRepo, tok)
 				if err != nil {
-					childID := p.buildNodeID(currentRepo, childPath)
-					p.addErrorNode(childID, childPath, fmt.Sprintf("Failed to create fetcher: %v", err), currentRepo.BaseURL)
-					p.addEdge(parentID, childID, refType)
-					return nil
+					return errorTarget(p.buildNodeID(currentRepo, childPath), childPath, fmt.Sprintf("Failed to create fetcher: %v", err), currentRepo.BaseURL)
 				}
 			}
 		}
@@ -247,45 +478,51 @@ func (p *Parser) processReference(parentID, ref, refType, currentPath string, cu
 		childPath = kustomizeRef.Path
 
 		token := p.tokens[childRepo.Type]
+		// Remote refs without ?ref= follow the repository's default branch
+		p.resolveDefaultBranch(childRepo, token)
 		var err error
 		childFetcher, err = p.getFetcherForRepo(childRepo, token)
 		if err != nil {
-			childID := p.buildNodeID(childRepo, childPath)
-			p.addErrorNode(childID, childPath, fmt.Sprintf("Failed to create fetcher: %v", err), childRepo.BaseURL)
-			p.addEdge(parentID, childID, refType) // Edge AFTER node creation
-			return nil
+			return errorTarget(p.buildNodeID(childRepo, childPath), childPath, fmt.Sprintf("Failed to create fetcher: %v", err), childRepo.BaseURL)
 		}
 	}
 
-	// Build unique node ID
-	childID := p.buildNodeID(childRepo, childPath)
-
-	// Store per-node local root for nodes in external local repos (used by build endpoint)
-	if childRepo.Type == repository.Local && childRepo.RootPath != "" && !sameRepoAsEntry(p.repoInfo, childRepo) {
-		if p.graph.LocalRootPaths == nil {
-			p.graph.LocalRootPaths = make(map[string]string)
-		}
-		p.graph.LocalRootPaths[childID] = childRepo.RootPath
+	target := &refTarget{
+		id:      p.buildNodeID(childRepo, childPath),
+		path:    childPath,
+		repo:    childRepo,
+		baseURL: childRepo.BaseURL,
 	}
+	return p.fetchTarget(target, childFetcher)
+}
 
-	// Try to fetch the child kustomization
-	content, err := childFetcher.FindKustomizationInPath(childPath)
-	if err != nil {
-		// Use explicit copies for log and stored error to avoid corruption from
-		// shared buffers when multiple requests log concurrently.
-		pathCopy := copyLogArgs(childPath)
-		errStr := copyLogArgs(err.Error())
-		log.Printf("⚠️  Warning: failed to fetch kustomization at %s: %s", pathCopy, errStr)
-		p.addErrorNode(childID, pathCopy, "File not found or inaccessible: "+errStr, childRepo.BaseURL)
-		p.addEdge(parentID, childID, refType)
-		return nil
+// checkKind warns on a kustomization node whose kind does not match how it is referenced:
+// kustomize rejects a Kustomization listed under components and a Component listed under resources.
+func (p *Parser) checkKind(nodeID, kind, refType string) {
+	if kind == "" {
+		kind = "Kustomization"
 	}
+	switch {
+	case refType == "component" && kind != "Component":
+		p.addWarning(nodeID, fmt.Sprintf("Listed under components but has kind: %s (kustomize requires kind: Component)", kind))
+	case refType == "resource" && kind == "Component":
+		p.addWarning(nodeID, "kind: Component listed under resources (kustomize requires it under components)")
+	}
+}
 
-	// Add edge BEFORE processing (so the node will exist after processKustomization)
-	p.addEdge(parentID, childID, refType)
-
-	// Recursively process the child (creates the node with type = refType: "resource" or "component")
-	return p.processKustomization(childID, content, childPath, childRepo, refType)
+// addWarning records a warning on a node (shown in the graph and in node details).
+func (p *Parser) addWarning(nodeID, warning string) {
+	node := p.nodeByID(nodeID)
+	if node == nil {
+		return
+	}
+	for _, w := range node.Warnings {
+		if w == warning {
+			return
+		}
+	}
+	node.Warnings = append(node.Warnings, warning)
+	log.Printf("⚠️  %s: %s", nodeID, warning)
 }
 
 // addErrorNode adds an error node to the graph
@@ -319,17 +556,35 @@ func (p *Parser) addErrorNode(id, path, errorMessage, baseURL string) {
 	log.Printf("Added error node: %s (error: %s)", copyLogArgs(id), copyLogArgs(errorMessage))
 }
 
+// addFetchErrorNode adds an error node for a file that could not be fetched, described as
+// notFound + err, except for rate-limited requests: those get their own message and
+// category since the file may well exist.
+func (p *Parser) addFetchErrorNode(id, path, notFound string, err error, baseURL string) {
+	errStr := copyLogArgs(err.Error())
+	if !repository.IsRateLimited(err) {
+		p.addErrorNode(id, path, notFound+errStr, baseURL)
+		return
+	}
+	p.addErrorNode(id, path, "Rate limited: "+errStr, baseURL)
+	if node := p.nodeByID(id); node != nil {
+		node.ErrorCategory = types.ErrorRateLimited
+	}
+}
+
 // processResource handles individual YAML resources or kustomization directories
 func (p *Parser) processResource(parentID, resource, currentPath string, currentRepo *repository.RepositoryInfo) error {
 	log.Printf("Processing resource: %s", resource)
 
 	// Check if it's a directory (needs kustomization) or a file
 	if isYAMLFile(resource) {
-		// Direct YAML file - create a resource node
-		resourcePath := path.Join(currentPath, resource)
-		resourceID := p.buildNodeID(currentRepo, resourcePath)
-		p.addNode(resourceID, "resource", resourcePath, nil, currentRepo.BaseURL)
-		p.addEdge(parentID, resourceID, "resource")
+		// Resources of a plugin kustomization are plugin configs
+		if parent := p.nodeByID(parentID); parent != nil && parent.Type == "plugin" {
+			p.processPluginFile(parentID, "", "resource", path.Join(currentPath, resource), currentRepo)
+			return nil
+		}
+
+		// Direct YAML file - create a resource node listing its objects
+		p.processManifestFile(parentID, "resource", resource, currentPath, currentRepo)
 		return nil
 	}
 
@@ -346,21 +601,67 @@ func (p *Parser) buildNodeID(repoInfo *repository.RepositoryInfo, nodePath strin
 	if repoInfo.Type == repository.Local {
 		return fmt.Sprintf("local:%s@%s", nodePath, repoInfo.Ref)
 	}
-	return fmt.Sprintf("%s:%s/%s/%s@%s",
+	id := fmt.Sprintf("%s:%s/%s/%s@%s",
 		repoInfo.Type, repoInfo.Owner, repoInfo.Repo, nodePath, repoInfo.Ref)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if repoInfo.FloatingRef {
+		p.floatingIDs[id] = true
+	}
+	if sha := p.commitSHA[repoRefKey(repoInfo)]; sha != "" {
+		p.nodeCommits[id] = sha
+	}
+	p.repos[id] = fmt.Sprintf("%s:%s/%s", repoInfo.Type, repoInfo.Owner, repoInfo.Repo)
+	if repoInfo.Type == repository.Git {
+		p.cloneURLs[id] = repository.GitCloneURL(repoInfo)
+	}
+	return id
 }
 
-// addNode adds a node to the graph
-func (p *Parser) addNode(id, nodeType, nodePath string, kust *Kustomization, baseURL string) {
-	var content map[string]interface{}
-	if kust != nil {
-		content = map[string]interface{}{
-			"resources":  kust.Resources,
-			"bases":      kust.Bases,
-			"components": kust.Components,
-			"patches":    kust.Patches,
-		}
+// kustomizationContent returns the node content stored for a parsed kustomization.
+func kustomizationContent(kust *Kustomization) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": kust.APIVersion,
+		"kind":       kust.Kind,
+		"resources":  kust.Resources,
+		"bases":      kust.Bases,
+		"components": kust.Components,
+		"patches":    kust.Patches,
+		// Generators (secret literal values are redacted)
+		"configMapGenerator": kust.ConfigMapGenerator,
+		"secretGenerator":    redactSecretGenerators(kust.SecretGenerator),
+		// Helm charts
+		"helmCharts":  kust.HelmCharts,
+		"helmGlobals": kust.HelmGlobals,
+		// Plugins
+		"transformers": kust.Transformers,
+		"generators":   kust.Generators,
+		"validators":   kust.Validators,
+		// Transformers
+		"namespace":         kust.Namespace,
+		"namePrefix":        kust.NamePrefix,
+		"nameSuffix":        kust.NameSuffix,
+		"images":            kust.Images,
+		"replicas":          kust.Replicas,
+		"commonLabels":      kust.CommonLabels,
+		"labels":            kust.Labels,
+		"commonAnnotations": kust.CommonAnnotations,
+		// Build configuration files
+		"crds":           kust.Crds,
+		"openapi":        kust.OpenAPI,
+		"configurations": kust.Configurations,
+		// Replacements (path entries are expanded once loaded) and legacy vars
+		"replacements": kust.Replacements,
+		"vars":         kust.Vars,
+		// Deprecated patch fields
+		"patchesStrategicMerge": kust.PatchesStrategicMerge,
+		"patchesJson6902":       kust.PatchesJson6902,
 	}
+}
+
+// addNode adds a node to the graph. content may be nil (e.g. generator source files or
+// remote manifests).
+func (p *Parser) addNode(id, nodeType, nodePath string, content map[string]interface{}, baseURL string) {
 	label := getShortLabel(nodePath)
 	newData := types.ElementData{
 		ID:      id,
@@ -398,8 +699,12 @@ func (p *Parser) addNode(id, nodeType, nodePath string, kust *Kustomization, bas
 
 // addEdge adds an edge to the graph
 func (p *Parser) addEdge(sourceID, targetID, edgeType string) {
-	edgeID := fmt.Sprintf("%s->%s", sourceID, targetID)
+	p.addEdgeWithID(fmt.Sprintf("%s->%s", sourceID, targetID), sourceID, targetID, edgeType)
+}
 
+// addEdgeWithID adds an edge with an explicit ID, for edge types that may link
+// the same nodes as another edge.
+func (p *Parser) addEdgeWithID(edgeID, sourceID, targetID, edgeType string) {
 	// Check if edge already exists
 	for _, elem := range p.graph.Elements {
 		if elem.Group == "edges" && elem.Data.ID == edgeID {
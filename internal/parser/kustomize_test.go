@@ -1,14 +1,40 @@
 package parser
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/cjeanner/kustomap/internal/cache"
 	"github.com/cjeanner/kustomap/internal/fetcher"
 	"github.com/cjeanner/kustomap/internal/repository"
 	"github.com/cjeanner/kustomap/internal/types"
 )
 
+// offlineResolver fails every commit and default branch lookup so that no parser test
+// reaches a real API: refs stay unpinned (and "main") unless a test installs its own.
+type offlineResolver struct{}
+
+func (offlineResolver) ResolveCommit(*repository.RepositoryInfo, string) (string, error) {
+	return "", errors.New("commit lookups are disabled in tests")
+}
+
+func (offlineResolver) GetDefaultBranch(*repository.RepositoryInfo, string) (string, error) {
+	return "", errors.New("default branch lookups are disabled in tests")
+}
+
+func TestMain(m *testing.M) {
+	repository.SetTestCommitResolver(offlineResolver{})
+	repository.SetTestDefaultBranchGetter(offlineResolver{})
+	os.Exit(m.Run())
+}
+
 func TestIsYAMLFile(t *testing.T) {
 	cases := []struct {
 		path string
@@ -123,24 +149,56 @@ func TestSameRepoAsEntry(t *testing.T) {
 
 // mockFetcher implements fetcher.Fetcher for tests. PathToContent maps path -> kustomization content;
 // PathToError maps path -> error for FindKustomizationInPath. If path is in PathToError, that error is returned.
+// Files maps file path -> content for FetchFile.
+// testRepo returns the entry repository of most tests: github.com/o/r at main.
+func testRepo() *repository.RepositoryInfo {
+	return &repository.RepositoryInfo{Type: repository.GitHub, Owner: "o", Repo: "r", Ref: "main", BaseURL: "https://github.com"}
+}
+
+// indexGraph returns the nodes of g by ID and the edge types by edge ID ("source->target",
+// with a ":type" suffix for edge types that may link the same nodes, e.g. replacements).
+func indexGraph(t *testing.T, g *types.Graph) (map[string]types.ElementData, map[string]string) {
+	t.Helper()
+	if g == nil {
+		t.Fatal("nil graph")
+	}
+	nodes := map[string]types.ElementData{}
+	edges := map[string]string{}
+	for _, e := range g.Elements {
+		if e.Group == "nodes" {
+			nodes[e.Data.ID] = e.Data
+		} else {
+			edges[e.Data.ID] = e.Data.EdgeType
+		}
+	}
+	return nodes, edges
+}
+
 type mockFetcher struct {
 	PathToContent map[string]string
 	PathToError   map[string]error
 	ListFilesErr  error
+	Files         map[string]string
 }
 
-func (m *mockFetcher) FetchFile(path string) ([]byte, error) {
-	return nil, errors.New("not implemented")
+func (m *mockFetcher) FetchFile(ctx context.Context, path string) ([]byte, error) {
+	if err, ok := m.PathToError[path]; ok {
+		return nil, err
+	}
+	if content, ok := m.Files[path]; ok {
+		return []byte(content), nil
+	}
+	return nil, errors.New("file not found: " + path)
 }
 
-func (m *mockFetcher) ListFiles() ([]string, error) {
+func (m *mockFetcher) ListFiles(ctx context.Context) ([]string, error) {
 	if m.ListFilesErr != nil {
 		return nil, m.ListFilesErr
 	}
 	return nil, nil
 }
 
-func (m *mockFetcher) FindKustomizationInPath(path string) (string, error) {
+func (m *mockFetcher) FindKustomizationInPath(ctx context.Context, path string) (string, error) {
 	if m.PathToError != nil {
 		if err, ok := m.PathToError[path]; ok {
 			return "", err
@@ -217,7 +275,7 @@ resources: [dataplane-deployment.yaml]
 	p.SetToken(repository.GitHub, "")
 
 	// Parse from entry path; entry fetcher returns kustomization that has remote component to GitHub
-	graph, err := p.Parse("environments/demo/nodeset")
+	graph, err := p.Parse(context.Background(), "environments/demo/nodeset")
 	if err != nil {
 		t.Fatalf("Parse: %v", err)
 	}
@@ -246,3 +304,1177 @@ resources: [dataplane-deployment.yaml]
 		t.Errorf("deployment node should not be an error (relative ref must use current-repo fetcher, not entry fetcher): content=%v", deploymentNode.Data.Content)
 	}
 }
+
+func TestParse_PatchesBecomePatchNodes(t *testing.T) {
+	repo := testRepo()
+	f := &mockFetcher{
+		PathToContent: map[string]string{
+			"overlay": `resources:
+  - ../base
+patches:
+  - path: replicas.yaml
+    target:
+      kind: Deployment
+      name: app
+  - patch: |-
+      - op: replace
+        path: /spec/replicas
+        value: 3
+    target:
+      group: apps
+      version: v1
+      kind: Deployment
+      labelSelector: app=web
+  - target:
+      kind: Service
+`,
+			"base": "resources: [deployment.yaml]\n",
+		},
+	}
+	graph, err := NewParser(f, repo).Parse(context.Background(), "overlay")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	nodes, edges := indexGraph(t, graph)
+
+	overlayID := "github:o/r/overlay@main"
+	fileID := "github:o/r/overlay/replicas.yaml@main"
+	inlineID := "github:o/r/overlay/patches[1]@main"
+	invalidID := "github:o/r/overlay/patches[2]@main"
+
+	if n := nodes[fileID]; n.Type != "patch" || n.Content["source"] != "file" {
+		t.Errorf("file patch node = %+v, want type patch with source file", n)
+	}
+	if target, ok := nodes[fileID].Content["target"].(*PatchTarget); !ok || target.Kind != "Deployment" || target.Name != "app" {
+		t.Errorf("file patch target = %v, want Deployment/app", nodes[fileID].Content["target"])
+	}
+	if n := nodes[inlineID]; n.Type != "patch" || n.Content["source"] != "inline" || n.Content["patch"] == "" {
+		t.Errorf("inline patch node = %+v, want type patch with inline body", n)
+	}
+	if target, ok := nodes[inlineID].Content["target"].(*PatchTarget); !ok || target.LabelSelector != "app=web" || target.Group != "apps" {
+		t.Errorf("inline patch target = %v, want apps group with labelSelector", nodes[inlineID].Content["target"])
+	}
+	if n := nodes[invalidID]; n.Type != "error" {
+		t.Errorf("patch without path or patch should be an error node, got %+v", n)
+	}
+	for _, id := range []string{fileID, inlineID, invalidID} {
+		if got := edges[overlayID+"->"+id]; got != "patch" {
+			t.Errorf("edge %s -> %s type = %q, want patch", overlayID, id, got)
+		}
+	}
+	if got := edges[overlayID+"->github:o/r/base@main"]; got != "resource" {
+		t.Errorf("resource edge type = %q, want resource", got)
+	}
+}
+
+func TestParse_LegacyPatchFields(t *testing.T) {
+	repo := testRepo()
+	f := &mockFetcher{
+		PathToContent: map[string]string{
+			"overlay": `bases:
+  - ../base
+patchesStrategicMerge:
+  - deployment-patch.yaml
+  - |-
+    apiVersion: apps/v1
+    kind: Deployment
+    metadata:
+      name: app
+patchesJson6902:
+  - target:
+      group: apps
+      version: v1
+      kind: Deployment
+      name: app
+    path: json-patch.yaml
+`,
+			"base": "resources: [deployment.yaml]\n",
+		},
+	}
+	graph, err := NewParser(f, repo).Parse(context.Background(), "overlay")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	nodes, edges := indexGraph(t, graph)
+
+	overlayID := "github:o/r/overlay@main"
+	cases := []struct {
+		id     string
+		field  string
+		source string
+	}{
+		{"github:o/r/overlay/deployment-patch.yaml@main", "patchesStrategicMerge", "file"},
+		{"github:o/r/overlay/patchesStrategicMerge[1]@main", "patchesStrategicMerge", "inline"},
+		{"github:o/r/overlay/json-patch.yaml@main", "patchesJson6902", "file"},
+	}
+	for _, c := range cases {
+		t.Run(c.id, func(t *testing.T) {
+			n, ok := nodes[c.id]
+			if !ok {
+				t.Fatalf("missing node %s", c.id)
+			}
+			if n.Type != "patch" || n.Content["field"] != c.field || n.Content["source"] != c.source {
+				t.Errorf("node = %+v, want patch from %s (%s)", n, c.field, c.source)
+			}
+			if n.Content["deprecated"] == nil {
+				t.Errorf("node %s should be flagged as deprecated", c.id)
+			}
+			if got := edges[overlayID+"->"+c.id]; got != "patch" {
+				t.Errorf("edge type = %q, want patch", got)
+			}
+		})
+	}
+	if target, ok := nodes["github:o/r/overlay/json-patch.yaml@main"].Content["target"].(*PatchTarget); !ok || target.Kind != "Deployment" {
+		t.Errorf("patchesJson6902 target = %v, want Deployment", nodes["github:o/r/overlay/json-patch.yaml@main"].Content["target"])
+	}
+}
+
+func TestIsInlineDocument(t *testing.T) {
+	cases := []struct {
+		entry string
+		want  bool
+	}{
+		{"patch.yaml", false},
+		{"patches/deployment.yml", false},
+		{"apiVersion: v1\nkind: ConfigMap", true},
+		{`{"apiVersion": "v1", "kind": "ConfigMap"}`, true},
+	}
+	for _, c := range cases {
+		t.Run(c.entry, func(t *testing.T) {
+			if got := isInlineDocument(c.entry); got != c.want {
+				t.Errorf("isInlineDocument(%q) = %v, want %v", c.entry, got, c.want)
+			}
+		})
+	}
+}
+
+func TestParse_GeneratorsInNamespaces(t *testing.T) {
+	repo := testRepo()
+	f := &mockFetcher{
+		PathToContent: map[string]string{
+			"overlay": `configMapGenerator:
+  - name: app-config
+    namespace: team-a
+    literals:
+      - OWNER=a
+  - name: app-config
+    namespace: team-b
+    literals:
+      - OWNER=b
+  - name: app-config
+    literals:
+      - OWNER=default
+`,
+		},
+	}
+	graph, err := NewParser(f, repo).Parse(context.Background(), "overlay")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	nodes, _ := indexGraph(t, graph)
+	for id, owner := range map[string]string{
+		"github:o/r/overlay/configMapGenerator/team-a/app-config@main": "OWNER=a",
+		"github:o/r/overlay/configMapGenerator/team-b/app-config@main": "OWNER=b",
+		"github:o/r/overlay/configMapGenerator/app-config@main":        "OWNER=default",
+	} {
+		literals, _ := nodes[id].Content["literals"].([]string)
+		if nodes[id].Type != "generator" || len(literals) != 1 || literals[0] != owner {
+			t.Errorf("generator %s = %+v, want literals [%s]", id, nodes[id], owner)
+		}
+	}
+}
+
+func TestParse_GeneratorsAndBehavior(t *testing.T) {
+	repo := testRepo()
+	f := &mockFetcher{
+		PathToContent: map[string]string{
+			"overlay": `resources:
+  - ../base
+configMapGenerator:
+  - name: app-config
+    behavior: merge
+    literals:
+      - LOG_LEVEL=debug
+secretGenerator:
+  - name: app-secret
+    type: Opaque
+    files:
+      - tls.crt=certs/tls.crt
+    literals:
+      - PASSWORD=hunter2
+`,
+			"base": `configMapGenerator:
+  - name: app-config
+    envs:
+      - app.env
+    files:
+      - config.properties
+`,
+		},
+	}
+	graph, err := NewParser(f, repo).Parse(context.Background(), "overlay")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	nodes, edges := indexGraph(t, graph)
+
+	overlayCM := "github:o/r/overlay/configMapGenerator/app-config@main"
+	baseCM := "github:o/r/base/configMapGenerator/app-config@main"
+	secret := "github:o/r/overlay/secretGenerator/app-secret@main"
+
+	if n := nodes[overlayCM]; n.Type != "generator" || n.Content["behavior"] != "merge" || n.Content["kind"] != "ConfigMap" {
+		t.Errorf("overlay configMap generator = %+v", n)
+	}
+	if n := nodes[baseCM]; n.Type != "generator" || n.Content["behavior"] != "create" {
+		t.Errorf("base configMap generator = %+v", n)
+	}
+	if got := edges["github:o/r/overlay@main->"+overlayCM]; got != "generator" {
+		t.Errorf("kustomization -> generator edge = %q, want generator", got)
+	}
+	if got := edges[overlayCM+"->"+baseCM]; got != "merge" {
+		t.Errorf("merge edge = %q, want merge", got)
+	}
+	for _, file := range []string{"base/app.env", "base/config.properties"} {
+		id := "github:o/r/" + file + "@main"
+		if nodes[id].Type != "file" || edges[baseCM+"->"+id] != "source" {
+			t.Errorf("expected file node %s with source edge from generator", id)
+		}
+	}
+	if edges[secret+"->github:o/r/overlay/certs/tls.crt@main"] != "source" {
+		t.Errorf("expected source edge from secret generator to certs/tls.crt")
+	}
+
+	keys, ok := nodes[secret].Content["literals"].([]string)
+	if !ok || len(keys) != 1 || keys[0] != "PASSWORD" {
+		t.Errorf("secret literals = %v, want [PASSWORD]", nodes[secret].Content["literals"])
+	}
+	overlayContent := nodes["github:o/r/overlay@main"].Content
+	if gens, ok := overlayContent["secretGenerator"].([]Generator); !ok || len(gens) != 1 || gens[0].Literals[0] != "PASSWORD" {
+		t.Errorf("kustomization secretGenerator content = %v, want redacted literals", overlayContent["secretGenerator"])
+	}
+}
+
+func TestParse_HelmCharts(t *testing.T) {
+	repo := testRepo()
+	f := &mockFetcher{
+		PathToContent: map[string]string{
+			"overlay": `helmGlobals:
+  chartHome: ../charts
+helmCharts:
+  - name: ingress-nginx
+    repo: https://kubernetes.github.io/ingress-nginx
+    version: 4.10.0
+    releaseName: ingress
+    namespace: ingress-nginx
+    valuesFile: values.yaml
+    valuesInline:
+      controller:
+        replicaCount: 2
+  - name: local-chart
+`,
+		},
+	}
+	graph, err := NewParser(f, repo).Parse(context.Background(), "overlay")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	nodes, edges := indexGraph(t, graph)
+
+	chartID := "github:o/r/overlay/helmCharts/ingress@main"
+	n := nodes[chartID]
+	if n.Type != "chart" {
+		t.Fatalf("chart node = %+v, want type chart", n)
+	}
+	for key, want := range map[string]string{
+		"name": "ingress-nginx", "version": "4.10.0", "releaseName": "ingress",
+		"namespace": "ingress-nginx", "repo": "https://kubernetes.github.io/ingress-nginx", "valuesFile": "values.yaml",
+	} {
+		if n.Content[key] != want {
+			t.Errorf("chart content[%s] = %v, want %s", key, n.Content[key], want)
+		}
+	}
+	if n.Content["valuesInline"] == nil {
+		t.Error("chart content should carry valuesInline")
+	}
+	if got := edges["github:o/r/overlay@main->"+chartID]; got != "chart" {
+		t.Errorf("kustomization -> chart edge = %q, want chart", got)
+	}
+	valuesID := "github:o/r/overlay/values.yaml@main"
+	if nodes[valuesID].Type != "file" || edges[chartID+"->"+valuesID] != "values" {
+		t.Errorf("expected values file node %s linked from chart", valuesID)
+	}
+
+	local := nodes["github:o/r/overlay/helmCharts/local-chart@main"]
+	if local.Content["localChart"] != "charts/local-chart" {
+		t.Errorf("local chart path = %v, want charts/local-chart", local.Content["localChart"])
+	}
+}
+
+func TestParse_EffectiveTransformers(t *testing.T) {
+	repo := testRepo()
+	f := &mockFetcher{
+		PathToContent: map[string]string{
+			"overlays/prod": `resources:
+  - ../../base
+namePrefix: prod-
+images:
+  - name: nginx
+    newTag: "1.27"
+replicas:
+  - name: web
+    count: 5
+commonLabels:
+  env: prod
+`,
+			"base": `namespace: web
+namePrefix: web-
+images:
+  - name: nginx
+    newTag: "1.25"
+  - name: redis
+    newName: registry.example.com/redis
+    newTag: "7"
+labels:
+  - pairs:
+      app: web
+`,
+		},
+	}
+	graph, err := NewParser(f, repo).Parse(context.Background(), "overlays/prod")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	nodes, _ := indexGraph(t, graph)
+	overlay, base := nodes["github:o/r/overlays/prod@main"], nodes["github:o/r/base@main"]
+	if overlay.Content["namePrefix"] != "prod-" || base.Content["namespace"] != "web" {
+		t.Errorf("transformer fields missing from content: overlay=%v base=%v", overlay.Content, base.Content)
+	}
+
+	eff, ok := overlay.Content["effective"].(*EffectiveTransformers)
+	if !ok {
+		t.Fatalf("overlay effective = %T, want *EffectiveTransformers", overlay.Content["effective"])
+	}
+	if eff.Namespace != "web" || eff.NamespaceSetBy != base.ID {
+		t.Errorf("effective namespace = %q (set by %q), want web from base", eff.Namespace, eff.NamespaceSetBy)
+	}
+	if eff.NamePrefix != "prod-web-" {
+		t.Errorf("effective namePrefix = %q, want prod-web-", eff.NamePrefix)
+	}
+	if img := eff.Images["nginx"]; img.NewTag != "1.27" || img.SetBy != overlay.ID {
+		t.Errorf("effective nginx image = %+v, want tag 1.27 set by overlay", img)
+	}
+	if img := eff.Images["redis"]; img.NewName != "registry.example.com/redis" || img.SetBy != base.ID {
+		t.Errorf("effective redis image = %+v, want base override", img)
+	}
+	if rep := eff.Replicas["web"]; rep.Count != 5 {
+		t.Errorf("effective replicas for web = %d, want 5", rep.Count)
+	}
+	if eff.Labels["env"] != "prod" || eff.Labels["app"] != "web" {
+		t.Errorf("effective labels = %v, want env=prod and app=web", eff.Labels)
+	}
+
+	baseEff := base.Content["effective"].(*EffectiveTransformers)
+	if baseEff.Images["nginx"].NewTag != "1.25" {
+		t.Errorf("base effective nginx tag = %q, want 1.25", baseEff.Images["nginx"].NewTag)
+	}
+}
+
+func TestParse_PluginReferences(t *testing.T) {
+	repo := testRepo()
+	f := &mockFetcher{
+		PathToContent: map[string]string{
+			"overlay": `transformers:
+  - set-labels.yaml
+  - |-
+    apiVersion: builtin
+    kind: PrefixSuffixTransformer
+    metadata:
+      name: prefix
+    prefix: dev-
+  - ../plugins
+generators:
+  - secret-gen.yaml
+validators:
+  - missing.yaml
+`,
+			"plugins": `resources: [legacy.yaml]
+`,
+		},
+		Files: map[string]string{
+			"overlay/set-labels.yaml": `apiVersion: example.com/v1
+kind: SetLabels
+metadata:
+  name: labels
+  annotations:
+    config.kubernetes.io/function: |
+      container:
+        image: ghcr.io/example/set-labels:v1
+        network: true
+`,
+			"overlay/secret-gen.yaml": `apiVersion: example.com/v1
+kind: SecretGen
+metadata:
+  name: gen
+  annotations:
+    config.kubernetes.io/function: |
+      exec:
+        path: ./plugins/secret-gen
+`,
+			"plugins/legacy.yaml": `apiVersion: someteam.example.com/v1
+kind: ChartInflator
+metadata:
+  name: inflate
+`,
+		},
+	}
+	graph, err := NewParser(f, repo).Parse(context.Background(), "overlay")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	nodes, edges := indexGraph(t, graph)
+	overlayID := "github:o/r/overlay@main"
+	plugin := func(id string) PluginInfo {
+		t.Helper()
+		n := nodes[id]
+		if n.Type != "plugin" {
+			t.Fatalf("node %s = %+v, want type plugin", id, n)
+		}
+		plugins, ok := n.Content["plugins"].([]PluginInfo)
+		if !ok || len(plugins) != 1 {
+			t.Fatalf("node %s plugins = %v, want one plugin", id, n.Content["plugins"])
+		}
+		return plugins[0]
+	}
+
+	fnID := "github:o/r/overlay/set-labels.yaml@main"
+	if p := plugin(fnID); p.Runtime != "container" || p.Image != "ghcr.io/example/set-labels:v1" || !p.Network {
+		t.Errorf("KRM function plugin = %+v, want container image with network", p)
+	}
+	if edges[overlayID+"->"+fnID] != "transformer" {
+		t.Errorf("overlay -> %s edge = %q, want transformer", fnID, edges[overlayID+"->"+fnID])
+	}
+	inlineID := "github:o/r/overlay/transformers[1]@main"
+	if p := plugin(inlineID); p.Runtime != "builtin" || p.Kind != "PrefixSuffixTransformer" {
+		t.Errorf("inline plugin = %+v, want builtin PrefixSuffixTransformer", p)
+	}
+	execID := "github:o/r/overlay/secret-gen.yaml@main"
+	if p := plugin(execID); p.Runtime != "exec" || p.Path != "./plugins/secret-gen" {
+		t.Errorf("exec plugin = %+v, want exec path ./plugins/secret-gen", p)
+	}
+	if edges[overlayID+"->"+execID] != "generator" {
+		t.Errorf("overlay -> %s edge = %q, want generator", execID, edges[overlayID+"->"+execID])
+	}
+
+	dirID := "github:o/r/plugins@main"
+	if nodes[dirID].Type != "plugin" || edges[overlayID+"->"+dirID] != "transformer" {
+		t.Errorf("plugin directory = %+v (edge %q), want plugin node with transformer edge", nodes[dirID], edges[overlayID+"->"+dirID])
+	}
+	legacyID := "github:o/r/plugins/legacy.yaml@main"
+	if p := plugin(legacyID); p.Runtime != "legacy" || p.Path != "kustomize/plugin/someteam.example.com/v1/chartinflator/ChartInflator" {
+		t.Errorf("legacy plugin = %+v, want legacy plugin path", p)
+	}
+
+	missingID := "github:o/r/overlay/missing.yaml@main"
+	if nodes[missingID].Type != "error" || edges[overlayID+"->"+missingID] != "validator" {
+		t.Errorf("missing validator = %+v (edge %q), want error node with validator edge", nodes[missingID], edges[overlayID+"->"+missingID])
+	}
+}
+
+func TestParse_ReplacementsAndVars(t *testing.T) {
+	repo := testRepo()
+	f := &mockFetcher{
+		PathToContent: map[string]string{
+			"overlay": `resources:
+  - ../config
+  - ../app
+replacements:
+  - path: replacements.yaml
+  - path: missing.yaml
+vars:
+  - name: SETTINGS
+    objref:
+      apiVersion: v1
+      kind: ConfigMap
+      name: settings
+`,
+			"config": `configMapGenerator:
+  - name: settings
+    literals: [host=db]
+`,
+			"app": `configMapGenerator:
+  - name: app-env
+    literals: [DB_HOST=placeholder]
+`,
+		},
+		Files: map[string]string{
+			"overlay/replacements.yaml": `- source:
+    kind: ConfigMap
+    name: settings
+    fieldPath: data.host
+  targets:
+    - select:
+        kind: ConfigMap
+        name: app-env
+      fieldPaths:
+        - data.DB_HOST
+`,
+		},
+	}
+	graph, err := NewParser(f, repo).Parse(context.Background(), "overlay")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	nodes, edges := indexGraph(t, graph)
+	overlayID := "github:o/r/overlay@main"
+	configID := "github:o/r/config@main"
+	appID := "github:o/r/app@main"
+
+	if got := edges[configID+"->"+appID+":replacement"]; got != "replacement" {
+		t.Errorf("config -> app replacement edge = %q, want replacement", got)
+	}
+	if got := edges[configID+"->"+overlayID+":replacement"]; got != "replacement" {
+		t.Errorf("config -> overlay var edge = %q, want replacement", got)
+	}
+	if edges[overlayID+"->"+configID] != "resource" {
+		t.Error("resource edge overlay -> config should be kept alongside replacement edges")
+	}
+
+	replacements, ok := nodes[overlayID].Content["replacements"].([]Replacement)
+	if !ok || len(replacements) != 1 {
+		t.Fatalf("overlay replacements = %v, want one loaded replacement", nodes[overlayID].Content["replacements"])
+	}
+	r := replacements[0]
+	if r.Origin != "overlay/replacements.yaml" || r.Source.FieldPath != "data.host" || r.Targets[0].FieldPaths[0] != "data.DB_HOST" {
+		t.Errorf("replacement = %+v, want field paths and origin", r)
+	}
+
+	missingID := "github:o/r/overlay/missing.yaml@main"
+	if nodes[missingID].Type != "error" || edges[overlayID+"->"+missingID] != "replacement" {
+		t.Errorf("missing replacement file = %+v, want error node linked from overlay", nodes[missingID])
+	}
+}
+
+func TestParse_BuildConfigurationFiles(t *testing.T) {
+	repo := testRepo()
+	f := &mockFetcher{
+		PathToContent: map[string]string{
+			"overlay": `crds:
+  - crds/widget.json
+  - crds/missing.json
+openapi:
+  path: schema.json
+configurations:
+  - ../config/name-references.yaml
+`,
+		},
+		Files: map[string]string{
+			"overlay/crds/widget.json":    `{}`,
+			"overlay/schema.json":         `{}`,
+			"config/name-references.yaml": "nameReference: []\n",
+		},
+	}
+	graph, err := NewParser(f, repo).Parse(context.Background(), "overlay")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	nodes, edges := indexGraph(t, graph)
+	overlayID := "github:o/r/overlay@main"
+	for id, kind := range map[string]string{
+		"github:o/r/overlay/crds/widget.json@main":    "crd",
+		"github:o/r/overlay/schema.json@main":         "openapi",
+		"github:o/r/config/name-references.yaml@main": "configuration",
+	} {
+		n := nodes[id]
+		if n.Type != "file" || n.Content["fileKind"] != kind {
+			t.Errorf("node %s = %+v, want file node of kind %s", id, n, kind)
+		}
+		if edges[overlayID+"->"+id] != kind {
+			t.Errorf("edge to %s = %q, want %s", id, edges[overlayID+"->"+id], kind)
+		}
+	}
+	missingID := "github:o/r/overlay/crds/missing.json@main"
+	if nodes[missingID].Type != "error" || edges[overlayID+"->"+missingID] != "crd" {
+		t.Errorf("missing CRD file = %+v, want error node with crd edge", nodes[missingID])
+	}
+}
+
+func TestParse_KindMismatchWarnings(t *testing.T) {
+	repo := testRepo()
+	f := &mockFetcher{
+		PathToContent: map[string]string{
+			"overlay": `resources:
+  - ../base
+  - ../components/monitoring
+components:
+  - ../components/tls
+  - ../not-a-component
+`,
+			"base": `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+`,
+			"components/monitoring": `apiVersion: kustomize.config.k8s.io/v1alpha1
+kind: Component
+`,
+			"components/tls": `apiVersion: kustomize.config.k8s.io/v1alpha1
+kind: Component
+`,
+			"not-a-component": `resources: []
+`,
+		},
+	}
+	graph, err := NewParser(f, repo).Parse(context.Background(), "overlay")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	nodes, _ := indexGraph(t, graph)
+	if kind := nodes["github:o/r/components/tls@main"].Content["kind"]; kind != "Component" {
+		t.Errorf("component kind = %v, want Component", kind)
+	}
+	for _, id := range []string{"github:o/r/base@main", "github:o/r/components/tls@main", "github:o/r/overlay@main"} {
+		if w := nodes[id].Warnings; len(w) != 0 {
+			t.Errorf("node %s warnings = %v, want none", id, w)
+		}
+	}
+	if w := nodes["github:o/r/components/monitoring@main"].Warnings; len(w) != 1 || !strings.Contains(w[0], "listed under resources") {
+		t.Errorf("Component under resources warnings = %v, want one warning", w)
+	}
+	if w := nodes["github:o/r/not-a-component@main"].Warnings; len(w) != 1 || !strings.Contains(w[0], "kind: Kustomization") {
+		t.Errorf("Kustomization under components warnings = %v, want one warning", w)
+	}
+}
+
+func TestParse_ManifestObjects(t *testing.T) {
+	repo := testRepo()
+	f := &mockFetcher{
+		PathToContent: map[string]string{
+			"overlay": `resources:
+  - ../app
+  - missing.yaml
+replacements:
+  - source:
+      kind: ConfigMap
+      name: settings
+      fieldPath: data.host
+    targets:
+      - select:
+          kind: Deployment
+        fieldPaths:
+          - spec.template.spec.containers.0.env.0.value
+configMapGenerator:
+  - name: settings
+    literals: [host=db]
+`,
+			"app": `resources:
+  - app.yaml
+  - list.yaml
+`,
+		},
+		Files: map[string]string{
+			"app/app.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: shop
+---
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`,
+			"app/list.yaml": `apiVersion: v1
+kind: List
+items:
+  - apiVersion: v1
+    kind: ServiceAccount
+    metadata:
+      name: web
+`,
+		},
+	}
+	graph, err := NewParser(f, repo).Parse(context.Background(), "overlay")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	nodes, edges := indexGraph(t, graph)
+
+	objects, ok := nodes["github:o/r/app/app.yaml@main"].Content["objects"].([]ObjectRef)
+	if !ok || len(objects) != 2 {
+		t.Fatalf("app.yaml objects = %v, want 2 objects", nodes["github:o/r/app/app.yaml@main"].Content["objects"])
+	}
+	want := ObjectRef{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "shop", Name: "web"}
+	if objects[0] != want || objects[1].Kind != "Service" {
+		t.Errorf("app.yaml objects = %+v, want Deployment shop/web then Service", objects)
+	}
+	listObjects := nodes["github:o/r/app/list.yaml@main"].Content["objects"].([]ObjectRef)
+	if len(listObjects) != 1 || listObjects[0].Kind != "ServiceAccount" {
+		t.Errorf("list.yaml objects = %+v, want expanded ServiceAccount item", listObjects)
+	}
+	if got := nodes["github:o/r/app@main"].Content["objectCount"]; got != 3 {
+		t.Errorf("app objectCount = %v, want 3", got)
+	}
+	if nodes["github:o/r/overlay/missing.yaml@main"].Type != "error" {
+		t.Errorf("missing resource file should be an error node")
+	}
+
+	// Replacements now resolve objects defined in YAML resources
+	if got := edges["github:o/r/overlay@main->github:o/r/app@main:replacement"]; got != "replacement" {
+		t.Errorf("overlay -> app replacement edge = %q, want replacement", got)
+	}
+}
+
+// defaultBranchGetter returns a fixed default branch for tests.
+type defaultBranchGetter string
+
+func (g defaultBranchGetter) GetDefaultBranch(_ *repository.RepositoryInfo, _ string) (string, error) {
+	return string(g), nil
+}
+
+func TestParse_RecordsGitCloneURLs(t *testing.T) {
+	repo, err := repository.NewGitRepository("git@git.example.com:group/sub/app.git")
+	if err != nil {
+		t.Fatalf("NewGitRepository: %v", err)
+	}
+	f := &mockFetcher{PathToContent: map[string]string{"overlay": "resources:\n  - ../base\n", "base": "resources: []\n"}}
+	graph, err := NewParser(f, repo).Parse(context.Background(), "overlay")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	for _, id := range []string{"git:group/sub/app/overlay@main", "git:group/sub/app/base@main"} {
+		if got := graph.CloneURLs[id]; got != "git@git.example.com:group/sub/app.git" {
+			t.Errorf("CloneURLs[%s] = %q, want the SSH clone URL", id, got)
+		}
+	}
+}
+
+func TestParse_RecordsNodeRepos(t *testing.T) {
+	repo := &repository.RepositoryInfo{Type: repository.GitLab, Owner: "group/sub", Repo: "app", Ref: "main", BaseURL: "https://gitlab.com"}
+	f := &mockFetcher{PathToContent: map[string]string{"deploy/overlay": "resources:\n  - ../base\n", "deploy/base": "resources: []\n"}}
+	graph, err := NewParser(f, repo).Parse(context.Background(), "deploy/overlay")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	for _, id := range []string{"gitlab:group/sub/app/deploy/overlay@main", "gitlab:group/sub/app/deploy/base@main"} {
+		if got := graph.Repos[id]; got != "gitlab:group/sub/app" {
+			t.Errorf("Repos[%s] = %q, want gitlab:group/sub/app", id, got)
+		}
+	}
+}
+
+func TestParse_RemoteManifestIsLeaf(t *testing.T) {
+	repo := &repository.RepositoryInfo{Type: repository.Local, RootPath: "/repo", Ref: "local"}
+	f := &mockFetcher{
+		PathToContent: map[string]string{
+			"overlay": `resources:
+  - https://github.com/org/operator/releases/download/v1.0.0/install.yaml
+`,
+		},
+	}
+	graph, err := NewParser(f, repo).Parse(context.Background(), "overlay")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	var found bool
+	for _, e := range graph.Elements {
+		if e.Group != "nodes" || !strings.HasSuffix(e.Data.Path, "install.yaml") {
+			continue
+		}
+		found = true
+		if e.Data.Type != "resource" || e.Data.Content != nil {
+			t.Errorf("remote manifest node = %s with content %v, want a resource leaf", e.Data.Type, e.Data.Content)
+		}
+	}
+	if !found {
+		t.Error("remote manifest node not found")
+	}
+}
+
+func TestParse_FloatingDefaultBranch(t *testing.T) {
+	repository.SetTestDefaultBranchGetter(defaultBranchGetter("develop"))
+	defer repository.SetTestDefaultBranchGetter(offlineResolver{})
+
+	repo := testRepo()
+	repo.Ref = "v1.0"
+	f := &mockFetcher{
+		PathToContent: map[string]string{
+			"overlay": `resources:
+  - https://github.com/other/lib//base
+  - https://github.com/other/lib//pinned?ref=v2
+`,
+		},
+	}
+	remote := &mockFetcher{
+		PathToContent: map[string]string{"base": "resources: []\n", "pinned": "resources: []\n"},
+	}
+	p := NewParser(f, repo)
+	p.FetcherFactory = func(r *repository.RepositoryInfo, _ string) (fetcher.Fetcher, error) {
+		return remote, nil
+	}
+	graph, err := p.Parse(context.Background(), "overlay")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	nodes, _ := indexGraph(t, graph)
+	if n, ok := nodes["github:other/lib/base@develop"]; !ok || !n.FloatingRef {
+		t.Errorf("remote ref without ?ref= should use the default branch and be floating: %+v", n)
+	}
+	if n, ok := nodes["github:other/lib/pinned@v2"]; !ok || n.FloatingRef {
+		t.Errorf("remote ref with ?ref= should not be floating: %+v", n)
+	}
+	if nodes["github:o/r/overlay@v1.0"].FloatingRef {
+		t.Error("entry pinned to v1.0 should not be floating")
+	}
+}
+
+// commitResolver resolves every ref to a SHA derived from the repo and ref, counting lookups.
+type commitResolver struct{ calls int }
+
+func (c *commitResolver) ResolveCommit(repo *repository.RepositoryInfo, _ string) (string, error) {
+	c.calls++
+	return "sha-" + repo.Repo + "-" + repo.Ref, nil
+}
+
+func TestParse_PinsNodesToCommits(t *testing.T) {
+	resolver := &commitResolver{}
+	repository.SetTestCommitResolver(resolver)
+	defer repository.SetTestCommitResolver(offlineResolver{})
+
+	repo := testRepo()
+	f := &mockFetcher{
+		PathToContent: map[string]string{
+			"overlay": `resources:
+  - ../base
+  - https://github.com/other/lib//a?ref=v2
+  - https://github.com/other/lib//b?ref=v2
+`,
+			"base": "resources: []\n",
+		},
+	}
+	fetchedRefs := map[string][]string{}
+	remote := &mockFetcher{PathToContent: map[string]string{"a": "resources: []\n", "b": "resources: []\n"}}
+	p := NewParser(f, repo)
+	p.FetcherFactory = func(r *repository.RepositoryInfo, _ string) (fetcher.Fetcher, error) {
+		fetchedRefs[r.Repo] = append(fetchedRefs[r.Repo], r.Ref)
+		if r.Repo == "r" {
+			return f, nil
+		}
+		return remote, nil
+	}
+	graph, err := p.Parse(context.Background(), "overlay")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	want := map[string]string{
+		"github:o/r/overlay@main": "sha-r-main",
+		"github:o/r/base@main":    "sha-r-main",
+		"github:other/lib/a@v2":   "sha-lib-v2",
+		"github:other/lib/b@v2":   "sha-lib-v2",
+	}
+	for id, sha := range want {
+		if graph.CommitSHAs[id] != sha {
+			t.Errorf("CommitSHAs[%s] = %q, want %q", id, graph.CommitSHAs[id], sha)
+		}
+	}
+	if resolver.calls != 2 {
+		t.Errorf("commit lookups = %d, want 2 (once per repo@ref)", resolver.calls)
+	}
+	// Both references to other/lib@v2 share one fetcher (e.g. one clone of a git remote)
+	if !reflect.DeepEqual(fetchedRefs["lib"], []string{"sha-lib-v2"}) {
+		t.Errorf("remote fetcher created at refs %v, want once at pinned sha-lib-v2", fetchedRefs["lib"])
+	}
+	// The entry repo is read at the pinned commit too, not at the branch head
+	if !reflect.DeepEqual(fetchedRefs["r"], []string{"sha-r-main"}) {
+		t.Errorf("entry fetcher created at refs %v, want [sha-r-main]", fetchedRefs["r"])
+	}
+}
+
+// tokenResolver resolves refs as is, for the given token only (as a private repo would).
+type tokenResolver string
+
+func (r tokenResolver) ResolveCommit(repo *repository.RepositoryInfo, token string) (string, error) {
+	if token != string(r) {
+		return "", errors.New("401 Unauthorized")
+	}
+	return repo.Ref, nil
+}
+
+func TestParse_CachedPrivateRepoRequiresAccess(t *testing.T) {
+	repository.SetTestCommitResolver(tokenResolver("secret"))
+	defer repository.SetTestCommitResolver(offlineResolver{})
+
+	sha := strings.Repeat("a", 40)
+	repo := testRepo()
+	repo.Repo = "private"
+	repo.Ref = sha
+	store, err := cache.New(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("cache.New: %v", err)
+	}
+	// Cached by an earlier analysis made with a valid token
+	key := cache.Key{Host: repo.Host(), Owner: "o", Repo: "private", SHA: sha, Path: "kustomization:overlay"}
+	if err := store.Put(key, []byte("resources: []\n")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	denied := &mockFetcher{PathToError: map[string]error{"overlay": errors.New("401 Unauthorized")}}
+	parse := func(token string) error {
+		info := *repo
+		p := NewParser(denied, &info)
+		p.SetToken(repository.GitHub, token)
+		p.SetCache(store)
+		p.FetcherFactory = func(*repository.RepositoryInfo, string) (fetcher.Fetcher, error) {
+			return denied, nil
+		}
+		_, err := p.Parse(context.Background(), "overlay")
+		return err
+	}
+
+	if err := parse(""); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Parse without token = %v, want an auth error instead of cached content", err)
+	}
+	if err := parse("wrong"); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Parse with another token = %v, want an auth error instead of cached content", err)
+	}
+	if err := parse("secret"); err != nil {
+		t.Errorf("Parse with the token = %v, want the cached content", err)
+	}
+}
+
+// slowFetcher serves kustomizations and files after a delay and records the peak number of
+// concurrent calls.
+type slowFetcher struct {
+	mockFetcher
+	mu      sync.Mutex
+	active  int
+	maxSeen int
+}
+
+// wait simulates a slow call, counting it while it lasts.
+func (s *slowFetcher) wait() {
+	s.mu.Lock()
+	s.active++
+	if s.active > s.maxSeen {
+		s.maxSeen = s.active
+	}
+	s.mu.Unlock()
+	time.Sleep(5 * time.Millisecond)
+	s.mu.Lock()
+	s.active--
+	s.mu.Unlock()
+}
+
+func (s *slowFetcher) FindKustomizationInPath(ctx context.Context, path string) (string, error) {
+	s.wait()
+	return s.mockFetcher.FindKustomizationInPath(ctx, path)
+}
+
+func (s *slowFetcher) FetchFile(ctx context.Context, path string) ([]byte, error) {
+	s.wait()
+	return s.mockFetcher.FetchFile(ctx, path)
+}
+
+// fanOutParse parses an overlay referencing 12 remote bases (each with a relative
+// component) with the given concurrency and returns the graph and the remote fetcher.
+func fanOutParse(t *testing.T, workers int, perHost map[string]int) (*types.Graph, *slowFetcher) {
+	t.Helper()
+	repository.SetTestCommitResolver(&commitResolver{})
+	defer repository.SetTestCommitResolver(offlineResolver{})
+
+	var overlay strings.Builder
+	overlay.WriteString("resources:\n")
+	remote := &slowFetcher{mockFetcher: mockFetcher{PathToContent: map[string]string{"shared": "resources: []\n"}}}
+	for i := 0; i < 12; i++ {
+		fmt.Fprintf(&overlay, "  - https://github.com/other/lib//app%d?ref=v1\n", i)
+		remote.PathToContent[fmt.Sprintf("app%d", i)] = "components:\n  - ../shared\n"
+	}
+	overlay.WriteString("  - ./missing\n")
+	repo := testRepo()
+	f := &mockFetcher{PathToContent: map[string]string{"overlay": overlay.String()}}
+
+	p := NewParser(f, repo)
+	p.SetConcurrency(workers, perHost)
+	p.FetcherFactory = func(r *repository.RepositoryInfo, _ string) (fetcher.Fetcher, error) {
+		if r.Repo == "r" {
+			return f, nil
+		}
+		return remote, nil
+	}
+	graph, err := p.Parse(context.Background(), "overlay")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return graph, remote
+}
+
+func TestParse_ConcurrentFetchIsDeterministic(t *testing.T) {
+	sequential, remote := fanOutParse(t, 1, nil)
+	if remote.maxSeen != 1 {
+		t.Errorf("workers=1: %d concurrent fetches, want 1", remote.maxSeen)
+	}
+	parallel, remote := fanOutParse(t, 8, nil)
+	if remote.maxSeen < 2 {
+		t.Errorf("workers=8: %d concurrent fetches, want sibling bases fetched in parallel", remote.maxSeen)
+	}
+
+	ids := func(g *types.Graph) []string {
+		var out []string
+		for _, e := range g.Elements {
+			out = append(out, e.Group+" "+e.Data.ID+" "+e.Data.Source+">"+e.Data.Target)
+		}
+		return out
+	}
+	if !reflect.DeepEqual(ids(sequential), ids(parallel)) {
+		t.Errorf("parallel graph differs from sequential graph:\n%v\n%v", ids(sequential), ids(parallel))
+	}
+	if !reflect.DeepEqual(sequential.CommitSHAs, parallel.CommitSHAs) {
+		t.Errorf("CommitSHAs differ: %v vs %v", sequential.CommitSHAs, parallel.CommitSHAs)
+	}
+}
+
+func TestParse_ConcurrentManifestFetch(t *testing.T) {
+	parse := func(workers int) (*types.Graph, *slowFetcher) {
+		var overlay strings.Builder
+		overlay.WriteString("resources:\n")
+		f := &slowFetcher{mockFetcher: mockFetcher{Files: map[string]string{}}}
+		for i := 0; i < 12; i++ {
+			fmt.Fprintf(&overlay, "  - cm%d.yaml\n", i)
+			f.Files[fmt.Sprintf("overlay/cm%d.yaml", i)] = fmt.Sprintf("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm%d\n", i)
+		}
+		overlay.WriteString("  - missing.yaml\n")
+		f.PathToContent = map[string]string{"overlay": overlay.String()}
+		p := NewParser(f, testRepo())
+		p.SetConcurrency(workers, nil)
+		graph, err := p.Parse(context.Background(), "overlay")
+		if err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+		return graph, f
+	}
+
+	sequential, f := parse(1)
+	if f.maxSeen != 1 {
+		t.Errorf("workers=1: %d concurrent fetches, want 1", f.maxSeen)
+	}
+	parallel, f := parse(8)
+	if f.maxSeen < 2 {
+		t.Errorf("workers=8: %d concurrent fetches, want YAML resources fetched in parallel", f.maxSeen)
+	}
+	if !reflect.DeepEqual(sequential.Elements, parallel.Elements) {
+		t.Errorf("parallel graph differs from sequential graph:\n%v\n%v", sequential.Elements, parallel.Elements)
+	}
+	for _, e := range parallel.Elements {
+		if e.Data.ID == "github:o/r/overlay/cm3.yaml@main" {
+			if objects, _ := e.Data.Content["objects"].([]ObjectRef); len(objects) != 1 || objects[0].Name != "cm3" {
+				t.Errorf("cm3.yaml objects = %v", e.Data.Content["objects"])
+			}
+		}
+	}
+}
+
+func TestParse_PerHostConcurrencyLimit(t *testing.T) {
+	_, remote := fanOutParse(t, 8, map[string]int{"github.com": 3})
+	if remote.maxSeen > 3 {
+		t.Errorf("%d concurrent fetches to github.com, want at most 3", remote.maxSeen)
+	}
+}
+
+func TestParse_RateLimitedErrorCategory(t *testing.T) {
+	repo := testRepo()
+	f := &mockFetcher{
+		PathToContent: map[string]string{"overlay": "resources:\n  - ../base\n  - ../missing\nreplacements:\n  - path: replacement.yaml\n"},
+		PathToError: map[string]error{
+			"base":                     &repository.RateLimitError{Host: "github.com", Reset: time.Now(), Err: errors.New("403 API rate limit exceeded")},
+			"missing":                  errors.New("no kustomization file found in path: missing"),
+			"overlay/replacement.yaml": &repository.RateLimitError{Host: "github.com", Reset: time.Now(), Err: errors.New("403 API rate limit exceeded")},
+		},
+	}
+	p := NewParser(f, repo)
+	graph, err := p.Parse(context.Background(), "overlay")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	nodes, _ := indexGraph(t, graph)
+	base := nodes["github:o/r/base@main"]
+	if base.Type != "error" || base.ErrorCategory != types.ErrorRateLimited {
+		t.Errorf("base: type %q, category %q; want error, %q", base.Type, base.ErrorCategory, types.ErrorRateLimited)
+	}
+	if msg, _ := base.Content["error"].(string); !strings.HasPrefix(msg, "Rate limited: ") {
+		t.Errorf("base error = %q, want a rate limited message", msg)
+	}
+	missing := nodes["github:o/r/missing@main"]
+	if missing.ErrorCategory != "" {
+		t.Errorf("missing: category %q, want none", missing.ErrorCategory)
+	}
+	if msg, _ := missing.Content["error"].(string); !strings.HasPrefix(msg, "File not found or inaccessible: ") {
+		t.Errorf("missing error = %q", msg)
+	}
+	replacement := nodes["github:o/r/overlay/replacement.yaml@main"]
+	if replacement.Type != "error" || replacement.ErrorCategory != types.ErrorRateLimited {
+		t.Errorf("replacement file: type %q, category %q; want error, %q", replacement.Type, replacement.ErrorCategory, types.ErrorRateLimited)
+	}
+}
+
+func TestParse_RateLimitedEntryPoint(t *testing.T) {
+	repo := testRepo()
+	f := &mockFetcher{PathToError: map[string]error{
+		"overlay": &repository.RateLimitError{Host: "github.com", Reset: time.Now(), Err: errors.New("403 API rate limit exceeded")},
+	}}
+	graph, err := NewParser(f, repo).Parse(context.Background(), "overlay")
+	if err != nil {
+		t.Fatalf("Parse: %v (want the rate limit reported on the entry node)", err)
+	}
+	if len(graph.Elements) != 1 {
+		t.Fatalf("graph has %d elements, want the entry node only", len(graph.Elements))
+	}
+	if entry := graph.Elements[0].Data; entry.ID != "github:o/r/overlay@main" || entry.ErrorCategory != types.ErrorRateLimited {
+		t.Errorf("entry node %q: category %q, want %q", entry.ID, entry.ErrorCategory, types.ErrorRateLimited)
+	}
+}
+
+// cancelingFetcher cancels the parse context when the kustomization at cancelAt is fetched.
+type cancelingFetcher struct {
+	*mockFetcher
+	cancelAt string
+	cancel   context.CancelFunc
+}
+
+func (c *cancelingFetcher) FindKustomizationInPath(ctx context.Context, path string) (string, error) {
+	if path == c.cancelAt {
+		c.cancel()
+	}
+	return c.mockFetcher.FindKustomizationInPath(ctx, path)
+}
+
+func TestParse_StopsWhenContextCanceled(t *testing.T) {
+	repo := testRepo()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	f := &cancelingFetcher{
+		mockFetcher: &mockFetcher{PathToContent: map[string]string{
+			"overlay": "resources:\n  - ../base\n",
+			"base":    "resources:\n  - ../common\n",
+			"common":  "resources: []\n",
+		}},
+		cancelAt: "base",
+		cancel:   cancel,
+	}
+	_, err := NewParser(f, repo).Parse(ctx, "overlay")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Parse error = %v, want context.Canceled", err)
+	}
+
+	if _, err := NewParser(f.mockFetcher, repo).Parse(ctx, "overlay"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Parse with a canceled context: error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,144 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"strings"
+
+	"github.com/cjeanner/kustomap/internal/repository"
+	"gopkg.in/yaml.v3"
+)
+
+// manifest is the subset of a Kubernetes object needed to identify it.
+// Items is only set for List kinds (e.g. v1/List).
+type manifest struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name      string `yaml:"name"`
+		Namespace string `yaml:"namespace"`
+	} `yaml:"metadata"`
+	Items []manifest `yaml:"items"`
+}
+
+// processManifestFile fetches a plain YAML resource (ref, relative to currentPath) and adds
+// it as a "resource" node whose content lists the objects it defines ("objects", one per
+// document, List items expanded). A file that cannot be fetched or parsed becomes an error
+// node. Remote manifests (e.g. https://.../install.yaml) are not fetched: they stay leaf
+// nodes without objects.
+func (p *Parser) processManifestFile(parentID, edgeType, ref, currentPath string, currentRepo *repository.RepositoryInfo) {
+	filePath := path.Join(currentPath, ref)
+	resourceID := p.buildNodeID(currentRepo, filePath)
+	if isRemoteManifest(ref) {
+		p.addNode(resourceID, "resource", filePath, nil, currentRepo.BaseURL)
+		p.addEdge(parentID, resourceID, edgeType)
+		return
+	}
+
+	target := p.takePrefetched(parentID, ref)
+	if target == nil {
+		target = p.fetchManifest(ref, currentPath, currentRepo)
+	}
+	if target.fetchErr != nil {
+		p.addFetchErrorNode(resourceID, filePath, "File not found or inaccessible: ", target.fetchErr, currentRepo.BaseURL)
+		p.addEdge(parentID, resourceID, edgeType)
+		return
+	}
+	objects, err := parseManifestObjects([]byte(target.content))
+	if err != nil {
+		p.addErrorNode(resourceID, filePath, fmt.Sprintf("Invalid YAML: %v", err), currentRepo.BaseURL)
+		p.addEdge(parentID, resourceID, edgeType)
+		return
+	}
+	log.Printf("Resource %s defines %d object(s)", filePath, len(objects))
+
+	p.addNode(resourceID, "resource", filePath, map[string]interface{}{"objects": objects}, currentRepo.BaseURL)
+	p.addEdge(parentID, resourceID, edgeType)
+}
+
+// isRemoteManifest reports whether a YAML resource is a URL (e.g. https://.../install.yaml).
+func isRemoteManifest(ref string) bool {
+	return strings.Contains(ref, "://")
+}
+
+// fetchManifest fetches the YAML resource ref (relative to currentPath) for
+// processManifestFile. Like resolveReference, it does not modify the graph, so manifests
+// can be fetched by workers (see prefetch).
+func (p *Parser) fetchManifest(ref, currentPath string, currentRepo *repository.RepositoryInfo) *refTarget {
+	filePath := path.Join(currentPath, ref)
+	target := &refTarget{id: p.buildNodeID(currentRepo, filePath), path: filePath, repo: currentRepo, baseURL: currentRepo.BaseURL}
+	f, err := p.fetcherForRepo(currentRepo)
+	var data []byte
+	if err == nil {
+		data, err = f.FetchFile(p.ctx, filePath)
+	}
+	if err != nil {
+		target.fetchErr = err
+		return target
+	}
+	target.content = string(data)
+	return target
+}
+
+// parseManifestObjects splits a multi-document YAML file and returns the identity of each object.
+// Empty documents are skipped.
+func parseManifestObjects(data []byte) ([]ObjectRef, error) {
+	objects := []ObjectRef{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var m manifest
+		err := dec.Decode(&m)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		objects = appendManifest(objects, m)
+	}
+	return objects, nil
+}
+
+// appendManifest appends the identity of m (or of its items for List kinds) to objects.
+func appendManifest(objects []ObjectRef, m manifest) []ObjectRef {
+	if m.Kind == "" && m.APIVersion == "" {
+		return objects // empty document
+	}
+	if strings.HasSuffix(m.Kind, "List") && len(m.Items) > 0 {
+		for _, item := range m.Items {
+			objects = appendManifest(objects, item)
+		}
+		return objects
+	}
+	return append(objects, ObjectRef{
+		APIVersion: m.APIVersion,
+		Kind:       m.Kind,
+		Namespace:  m.Metadata.Namespace,
+		Name:       m.Metadata.Name,
+	})
+}
+
+// countObjects stores in a kustomization's content the number of objects defined by its
+// own YAML resources ("objectCount"); objects from bases and components are not included.
+func (p *Parser) countObjects(nodeID string) {
+	node := p.nodeByID(nodeID)
+	if node == nil || node.Content == nil {
+		return
+	}
+	count := 0
+	for _, elem := range p.graph.Elements {
+		if elem.Group != "edges" || elem.Data.Source != nodeID || elem.Data.EdgeType != "resource" {
+			continue
+		}
+		if child := p.nodeByID(elem.Data.Target); child != nil {
+			if objects, ok := child.Content["objects"].([]ObjectRef); ok {
+				count += len(objects)
+			}
+		}
+	}
+	node.Content["objectCount"] = count
+}
@@ -0,0 +1,105 @@
+package parser
+
+import (
+	"fmt"
+	"log"
+	"path"
+	"strings"
+
+	"github.com/cjeanner/kustomap/internal/repository"
+)
+
+// Patch is an entry of the kustomization "patches" field (also used for the legacy
+// "patchesJson6902" field). Either Path (a file relative to the kustomization) or
+// Patch (an inline strategic merge or JSON6902 patch) is set.
+type Patch struct {
+	Path    string          `yaml:"path,omitempty" json:"path,omitempty"`
+	Patch   string          `yaml:"patch,omitempty" json:"patch,omitempty"`
+	Target  *PatchTarget    `yaml:"target,omitempty" json:"target,omitempty"`
+	Options map[string]bool `yaml:"options,omitempty" json:"options,omitempty"`
+}
+
+// PatchTarget is the selector restricting which resources a patch applies to.
+type PatchTarget struct {
+	Group              string `yaml:"group,omitempty" json:"group,omitempty"`
+	Version            string `yaml:"version,omitempty" json:"version,omitempty"`
+	Kind               string `yaml:"kind,omitempty" json:"kind,omitempty"`
+	Name               string `yaml:"name,omitempty" json:"name,omitempty"`
+	Namespace          string `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+	LabelSelector      string `yaml:"labelSelector,omitempty" json:"labelSelector,omitempty"`
+	AnnotationSelector string `yaml:"annotationSelector,omitempty" json:"annotationSelector,omitempty"`
+}
+
+// deprecatedPatchFields maps legacy patch fields to the message shown in node details.
+var deprecatedPatchFields = map[string]string{
+	"patchesStrategicMerge": "patchesStrategicMerge is deprecated; migrate to patches",
+	"patchesJson6902":       "patchesJson6902 is deprecated; migrate to patches",
+}
+
+// processPatches adds a "patch" node for each entry of a patch field (patches,
+// patchesStrategicMerge or patchesJson6902) and links it to the owning kustomization
+// with a "patch" edge. File patches use the file path as node path; inline patches
+// get a virtual path such as "overlay/patches[0]".
+func (p *Parser) processPatches(parentID, field string, patches []Patch, currentPath string, currentRepo *repository.RepositoryInfo) {
+	for i, patch := range patches {
+		var patchPath string
+		content := map[string]interface{}{"field": field}
+		switch {
+		case patch.Path != "":
+			patchPath = resolvePath(currentPath, patch.Path)
+			content["source"] = "file"
+		case patch.Patch != "":
+			patchPath = inlineNodePath(currentPath, field, i)
+			content["source"] = "inline"
+			content["patch"] = patch.Patch
+		default:
+			patchPath = inlineNodePath(currentPath, field, i)
+			patchID := p.buildNodeID(currentRepo, patchPath)
+			p.addErrorNode(patchID, patchPath, "Patch has neither path nor patch", currentRepo.BaseURL)
+			p.addEdge(parentID, patchID, "patch")
+			continue
+		}
+		if patch.Target != nil {
+			content["target"] = patch.Target
+		}
+		if len(patch.Options) > 0 {
+			content["options"] = patch.Options
+		}
+		if msg, ok := deprecatedPatchFields[field]; ok {
+			content["deprecated"] = msg
+		}
+
+		log.Printf("Processing patch: %s", patchPath)
+		patchID := p.buildNodeID(currentRepo, patchPath)
+		p.addNode(patchID, "patch", patchPath, content, currentRepo.BaseURL)
+		p.addEdge(parentID, patchID, "patch")
+	}
+}
+
+// strategicMergePatches converts patchesStrategicMerge entries to Patch values.
+// An entry is either a file path or an inline patch (YAML or JSON document).
+func strategicMergePatches(entries []string) []Patch {
+	patches := make([]Patch, 0, len(entries))
+	for _, entry := range entries {
+		if isInlineDocument(entry) {
+			patches = append(patches, Patch{Patch: entry})
+		} else {
+			patches = append(patches, Patch{Path: entry})
+		}
+	}
+	return patches
+}
+
+// isInlineDocument reports whether a kustomization list entry (patchesStrategicMerge,
+// transformers, generators, validators) is an inline YAML/JSON document rather than
+// a path (paths never span lines or contain "key: value").
+func isInlineDocument(entry string) bool {
+	entry = strings.TrimSpace(entry)
+	return strings.Contains(entry, "\n") || strings.HasPrefix(entry, "{") || strings.Contains(entry, ": ")
+}
+
+// inlineNodePath returns a virtual node path for an inline entry of a kustomization
+// field (e.g. "overlay/patches[0]"), so inline content gets a stable, unique node ID.
+func inlineNodePath(currentPath, field string, index int) string {
+	return path.Join(currentPath, fmt.Sprintf("%s[%d]", field, index))
+}
@@ -0,0 +1,208 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"strings"
+
+	"github.com/cjeanner/kustomap/internal/repository"
+	"gopkg.in/yaml.v3"
+)
+
+// functionAnnotations are the annotations declaring a KRM function on a plugin config
+// (the second one is the legacy name).
+var functionAnnotations = []string{
+	"config.kubernetes.io/function",
+	"config.k8s.io/function",
+}
+
+// PluginInfo describes one plugin config (transformer, generator or validator) and the
+// external code it runs: a KRM function container image, an exec/starlark path, a
+// kustomize builtin, or a legacy plugin looked up by apiVersion/kind.
+type PluginInfo struct {
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Runtime    string `json:"runtime"` // "container", "exec", "starlark", "builtin", "legacy"
+	Image      string `json:"image,omitempty"`
+	Path       string `json:"path,omitempty"`
+	Network    bool   `json:"network,omitempty"`
+}
+
+// pluginConfig is the subset of a plugin config document needed to identify the plugin.
+type pluginConfig struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name        string            `yaml:"name"`
+		Annotations map[string]string `yaml:"annotations"`
+	} `yaml:"metadata"`
+}
+
+// functionSpec is the content of the config.kubernetes.io/function annotation.
+type functionSpec struct {
+	Container *struct {
+		Image   string `yaml:"image"`
+		Network bool   `yaml:"network"`
+	} `yaml:"container"`
+	Exec *struct {
+		Path string `yaml:"path"`
+	} `yaml:"exec"`
+	Starlark *struct {
+		Path string `yaml:"path"`
+		URL  string `yaml:"url"`
+	} `yaml:"starlark"`
+}
+
+// processPlugins follows the entries of a transformers, generators or validators field.
+// Plugin config files and inline configs become "plugin" nodes describing the code they run;
+// directories and remote references are followed like resources (their kustomization
+// becomes a "plugin" node). Edge types are "transformer", "generator" and "validator".
+func (p *Parser) processPlugins(parentID, field string, entries []string, currentPath string, currentRepo *repository.RepositoryInfo) {
+	edgeType := strings.TrimSuffix(field, "s")
+	for i, entry := range entries {
+		switch {
+		case isInlineDocument(entry):
+			pluginPath := inlineNodePath(currentPath, field, i)
+			pluginID := p.buildNodeID(currentRepo, pluginPath)
+			plugins, err := parsePluginConfigs([]byte(entry))
+			if err != nil {
+				p.addErrorNode(pluginID, pluginPath, fmt.Sprintf("Invalid plugin config: %v", err), currentRepo.BaseURL)
+			} else {
+				p.addNode(pluginID, "plugin", pluginPath, pluginContent(field, plugins), currentRepo.BaseURL)
+			}
+			p.addEdge(parentID, pluginID, edgeType)
+		case isYAMLFile(entry) && !strings.Contains(entry, "://"):
+			p.processPluginFile(parentID, field, edgeType, resolvePath(currentPath, entry), currentRepo)
+		default:
+			if err := p.processReference(parentID, entry, edgeType, currentPath, currentRepo); err != nil {
+				log.Printf("Warning: failed to process %s %s: %v", edgeType, entry, err)
+			}
+		}
+	}
+}
+
+// processPluginFile fetches a plugin config file and adds it as a "plugin" node
+// (or an error node when it cannot be fetched or parsed).
+func (p *Parser) processPluginFile(parentID, field, edgeType, filePath string, currentRepo *repository.RepositoryInfo) {
+	log.Printf("Processing plugin config: %s", filePath)
+	pluginID := p.buildNodeID(currentRepo, filePath)
+
+	f, err := p.fetcherForRepo(currentRepo)
+	if err != nil {
+		p.addErrorNode(pluginID, filePath, fmt.Sprintf("Failed to create fetcher: %v", err), currentRepo.BaseURL)
+		p.addEdge(parentID, pluginID, edgeType)
+		return
+	}
+	data, err := f.FetchFile(p.ctx, filePath)
+	if err != nil {
+		p.addFetchErrorNode(pluginID, filePath, "File not found or inaccessible: ", err, currentRepo.BaseURL)
+		p.addEdge(parentID, pluginID, edgeType)
+		return
+	}
+	plugins, err := parsePluginConfigs(data)
+	if err != nil {
+		p.addErrorNode(pluginID, filePath, fmt.Sprintf("Invalid plugin config: %v", err), currentRepo.BaseURL)
+		p.addEdge(parentID, pluginID, edgeType)
+		return
+	}
+	p.addNode(pluginID, "plugin", filePath, pluginContent(field, plugins), currentRepo.BaseURL)
+	p.addEdge(parentID, pluginID, edgeType)
+}
+
+// pluginContent returns the node content for plugin configs. field is omitted when empty
+// (plugin config files listed as resources of a plugin kustomization).
+func pluginContent(field string, plugins []PluginInfo) map[string]interface{} {
+	content := map[string]interface{}{"plugins": plugins}
+	if field != "" {
+		content["field"] = field
+	}
+	return content
+}
+
+// parsePluginConfigs parses a (possibly multi-document) plugin config and returns
+// the plugin described by each document.
+func parsePluginConfigs(data []byte) ([]PluginInfo, error) {
+	var plugins []PluginInfo
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var cfg pluginConfig
+		err := dec.Decode(&cfg)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if cfg.APIVersion == "" && cfg.Kind == "" {
+			continue // empty document
+		}
+		info, err := describePlugin(&cfg)
+		if err != nil {
+			return nil, err
+		}
+		plugins = append(plugins, info)
+	}
+	if len(plugins) == 0 {
+		return nil, fmt.Errorf("no plugin config found")
+	}
+	return plugins, nil
+}
+
+// describePlugin identifies the code a plugin config runs.
+func describePlugin(cfg *pluginConfig) (PluginInfo, error) {
+	info := PluginInfo{APIVersion: cfg.APIVersion, Kind: cfg.Kind, Name: cfg.Metadata.Name}
+
+	for _, annotation := range functionAnnotations {
+		raw, ok := cfg.Metadata.Annotations[annotation]
+		if !ok {
+			continue
+		}
+		var spec functionSpec
+		if err := yaml.Unmarshal([]byte(raw), &spec); err != nil {
+			return info, fmt.Errorf("invalid %s annotation: %w", annotation, err)
+		}
+		switch {
+		case spec.Container != nil:
+			info.Runtime = "container"
+			info.Image = spec.Container.Image
+			info.Network = spec.Container.Network
+		case spec.Exec != nil:
+			info.Runtime = "exec"
+			info.Path = spec.Exec.Path
+		case spec.Starlark != nil:
+			info.Runtime = "starlark"
+			info.Path = spec.Starlark.Path
+			if info.Path == "" {
+				info.Path = spec.Starlark.URL
+			}
+		default:
+			return info, fmt.Errorf("%s annotation has no container, exec or starlark", annotation)
+		}
+		return info, nil
+	}
+
+	if cfg.APIVersion == "builtin" {
+		info.Runtime = "builtin"
+		return info, nil
+	}
+	// Legacy exec/Go plugins are looked up under $XDG_CONFIG_HOME/kustomize/plugin
+	info.Runtime = "legacy"
+	info.Path = path.Join("kustomize/plugin", cfg.APIVersion, strings.ToLower(cfg.Kind), cfg.Kind)
+	return info, nil
+}
+
+// nodeTypeForRef returns the node type of a kustomization reached through an edge of
+// the given type: kustomizations listed under transformers/generators/validators are plugins.
+func nodeTypeForRef(refType string) string {
+	switch refType {
+	case "transformer", "generator", "validator":
+		return "plugin"
+	default:
+		return refType
+	}
+}
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"strings"
@@ -32,18 +33,20 @@ const (
 // Formats supported:
 // - https://github.com/org/repo//path?ref=branch
 // - git@github.com:org/repo.git//path?ref=branch
+// - ssh://git@host/org/repo.git//path?ref=branch
 // - ../relative/path (explicit relative)
 // - ./relative/path (explicit relative)
 // - relative/path (implicit relative - no prefix)
-func ParseReference(ref string, token string) (*KustomizeReference, error) {
+// ctx bounds the probes detecting the type of remote hosts.
+func ParseReference(ctx context.Context, ref string, token string) (*KustomizeReference, error) {
 	// Remote references (HTTP/HTTPS)
 	if strings.HasPrefix(ref, "https://") || strings.HasPrefix(ref, "http://") {
-		return parseHTTPReference(ref, token)
+		return parseHTTPReference(ctx, ref, token)
 	}
 
 	// Git SSH format
-	if strings.HasPrefix(ref, "git@") {
-		return parseGitSSHReference(ref, token)
+	if strings.HasPrefix(ref, "git@") || strings.HasPrefix(ref, "ssh://") {
+		return parseGitSSHReference(ctx, ref, token)
 	}
 
 	// Explicit relative paths
@@ -67,7 +70,7 @@ func ParseReference(ref string, token string) (*KustomizeReference, error) {
 
 // parseHTTPReference parses HTTP(S) Kustomize references
 // Format: https://github.com/org/repo//path?ref=branch
-func parseHTTPReference(ref string, token string) (*KustomizeReference, error) {
+func parseHTTPReference(ctx context.Context, ref string, token string) (*KustomizeReference, error) {
 	var repoURL string
 	var path string
 	var refOverride string
@@ -109,14 +112,25 @@ func parseHTTPReference(ref string, token string) (*KustomizeReference, error) {
 		// Extract ref query parameter (branch/tag for fetching)
 		if q := u.Query().Get("ref"); q != "" {
 			refOverride = q
+		} else if q := u.Query().Get("at"); q != "" {
+			// Bitbucket Server browse URLs: ?at=refs/heads/branch
+			refOverride = strings.TrimPrefix(strings.TrimPrefix(q, "refs/heads/"), "refs/tags/")
+		} else if q := repository.AzureRefFromVersion(u.Query().Get("version")); q != "" {
+			// Azure DevOps browse URLs: ?path=/dir&version=GBbranch
+			refOverride = q
 		}
 
-		if len(pathParts) >= 2 {
+		if n := repoSegments(pathParts); len(pathParts) >= n {
 			// Repo URL = scheme + host + /owner/repo
-			repoURL = fmt.Sprintf("%s://%s/%s/%s", u.Scheme, u.Host, pathParts[0], pathParts[1])
+			repoURL = fmt.Sprintf("%s://%s/%s", u.Scheme, u.Host, strings.Join(pathParts[:n], "/"))
 			// Path = reste du chemin
-			if len(pathParts) > 2 {
-				path = strings.Join(pathParts[2:], "/")
+			rest := pathParts[n:]
+			if len(rest) > 0 && rest[0] == "browse" {
+				rest = rest[1:]
+			}
+			path = strings.Join(rest, "/")
+			if q := u.Query().Get("path"); q != "" && path == "" {
+				path = q
 			}
 		} else {
 			repoURL = fmt.Sprintf("%s://%s%s", u.Scheme, u.Host, u.Path)
@@ -124,13 +138,14 @@ func parseHTTPReference(ref string, token string) (*KustomizeReference, error) {
 	}
 
 	// Le reste du code demeure identique
-	repoInfo, err := repository.DetectRepository(repoURL, token)
+	repoInfo, err := repository.DetectRepository(ctx, repoURL, token)
 	if err != nil {
 		return nil, fmt.Errorf("failed to detect repository type: %w", err)
 	}
 
 	if refOverride != "" {
 		repoInfo.Ref = refOverride
+		repoInfo.FloatingRef = false
 	}
 
 	return &KustomizeReference{
@@ -141,15 +156,64 @@ func parseHTTPReference(ref string, token string) (*KustomizeReference, error) {
 	}, nil
 }
 
+// repoSegments returns how many leading URL path segments name the repository:
+// 4 for Bitbucket Server (projects/KEY/repos/repo, users/name/repos/repo),
+// 3 for Bitbucket Server clone URLs (scm/key/repo), up to and including the
+// repository after "_git" for Azure DevOps (org/project/_git/repo), 2 (owner/repo) otherwise.
+func repoSegments(pathParts []string) int {
+	for i, part := range pathParts {
+		if part == "_git" && i+1 < len(pathParts) {
+			return i + 2
+		}
+	}
+	switch {
+	case len(pathParts) >= 4 && (pathParts[0] == "projects" || pathParts[0] == "users") && pathParts[2] == "repos":
+		return 4
+	case len(pathParts) >= 3 && pathParts[0] == "scm":
+		return 3
+	default:
+		return 2
+	}
+}
+
 // parseGitSSHReference parses Git SSH format
-// Format: git@github.com:org/repo.git//path?ref=branch
-func parseGitSSHReference(ref string, token string) (*KustomizeReference, error) {
+// Format: git@github.com:org/repo.git//path?ref=branch or ssh://git@host/org/repo.git//path?ref=branch
+// Plain git remotes keep their SSH clone URL; forges are accessed through their HTTPS API.
+func parseGitSSHReference(ctx context.Context, ref string, token string) (*KustomizeReference, error) {
 	// Convert git@github.com:org/repo.git to https://github.com/org/repo
-	ref = strings.TrimPrefix(ref, "git@")
-	ref = strings.Replace(ref, ":", "/", 1)
-	ref = "https://" + ref
+	httpRef := ref
+	if strings.HasPrefix(httpRef, "ssh://") {
+		httpRef = strings.TrimPrefix(httpRef, "ssh://")
+		if at := strings.Index(httpRef, "@"); at >= 0 && at < strings.Index(httpRef, "/") {
+			httpRef = httpRef[at+1:]
+		}
+	} else {
+		httpRef = strings.TrimPrefix(httpRef, "git@")
+		httpRef = strings.Replace(httpRef, ":", "/", 1)
+	}
+	httpRef = "https://" + httpRef
+
+	kustomizeRef, err := parseHTTPReference(ctx, httpRef, token)
+	if err != nil {
+		return nil, err
+	}
+	if kustomizeRef.RepoInfo.Type == repository.Git {
+		kustomizeRef.RepoInfo.CloneURL = sshCloneURL(ref, kustomizeRef.RepoInfo)
+	}
+	return kustomizeRef, nil
+}
 
-	return parseHTTPReference(ref, token)
+// sshCloneURL rebuilds the SSH clone URL of a repository referenced as ref.
+func sshCloneURL(ref string, info *repository.RepositoryInfo) string {
+	host := strings.TrimPrefix(info.BaseURL, "https://")
+	if strings.HasPrefix(ref, "ssh://") {
+		user := "git"
+		if u, _, ok := strings.Cut(strings.TrimPrefix(ref, "ssh://"), "@"); ok && !strings.Contains(u, "/") {
+			user = u
+		}
+		return fmt.Sprintf("ssh://%s@%s/%s/%s.git", user, host, info.Owner, info.Repo)
+	}
+	return fmt.Sprintf("git@%s:%s/%s.git", host, info.Owner, info.Repo)
 }
 
 func (r *KustomizeReference) String() string {
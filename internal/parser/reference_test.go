@@ -1,8 +1,10 @@
 package parser
 
 import (
+	"context"
 	"testing"
 
+	"github.com/cjeanner/kustomap/internal/repository"
 	"gopkg.in/yaml.v3"
 )
 
@@ -22,7 +24,7 @@ func TestParseReference_Relative(t *testing.T) {
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			got, err := ParseReference(c.ref, c.token)
+			got, err := ParseReference(context.Background(), c.ref, c.token)
 			if err != nil {
 				t.Fatalf("ParseReference(%q) error: %v", c.ref, err)
 			}
@@ -39,7 +41,7 @@ func TestParseReference_Relative(t *testing.T) {
 func TestParseReference_HTTP_KustomizeFormat(t *testing.T) {
 	// Kustomize format: https://github.com/org/repo//path?ref=branch
 	ref := "https://github.com/owner/repo//some/overlay?ref=v1.0"
-	got, err := ParseReference(ref, "")
+	got, err := ParseReference(context.Background(), ref, "")
 	if err != nil {
 		t.Fatalf("ParseReference error: %v", err)
 	}
@@ -63,7 +65,7 @@ func TestParseReference_HTTP_KustomizeFormat(t *testing.T) {
 func TestParseReference_HTTP_StandardFormat(t *testing.T) {
 	// Standard: https://github.com/org/repo/path?ref=branch
 	ref := "https://github.com/owner/repo/deploy/base?ref=main"
-	got, err := ParseReference(ref, "")
+	got, err := ParseReference(context.Background(), ref, "")
 	if err != nil {
 		t.Fatalf("ParseReference error: %v", err)
 	}
@@ -86,7 +88,7 @@ func TestParseReference_HTTP_StandardFormat(t *testing.T) {
 
 func TestParseReference_HTTP_NoRef(t *testing.T) {
 	ref := "https://github.com/owner/repo/deploy/base"
-	got, err := ParseReference(ref, "")
+	got, err := ParseReference(context.Background(), ref, "")
 	if err != nil {
 		t.Fatalf("ParseReference error: %v", err)
 	}
@@ -98,7 +100,7 @@ func TestParseReference_HTTP_NoRef(t *testing.T) {
 func TestParseReference_GitSSH(t *testing.T) {
 	// git@github.com:org/repo.git//path?ref=branch -> converted to HTTPS and parsed
 	ref := "git@github.com:owner/repo.git//kustomize/base?ref=develop"
-	got, err := ParseReference(ref, "")
+	got, err := ParseReference(context.Background(), ref, "")
 	if err != nil {
 		t.Fatalf("ParseReference error: %v", err)
 	}
@@ -121,7 +123,7 @@ func TestParseReference_GitSSH(t *testing.T) {
 
 func TestParseReference_HTTP_GitLab(t *testing.T) {
 	ref := "https://gitlab.com/group/subgroup/project//deploy/overlay?ref=main"
-	got, err := ParseReference(ref, "")
+	got, err := ParseReference(context.Background(), ref, "")
 	if err != nil {
 		t.Fatalf("ParseReference error: %v", err)
 	}
@@ -139,6 +141,70 @@ func TestParseReference_HTTP_GitLab(t *testing.T) {
 	}
 }
 
+func TestParseReference_HTTP_BitbucketServer(t *testing.T) {
+	cases := []struct {
+		name string
+		ref  string
+		path string
+		wantRef string
+	}{
+		{"browse URL", "https://bitbucket.example.com/projects/PRJ/repos/infra/browse/deploy/overlay?at=refs/heads/dev", "deploy/overlay", "dev"},
+		{"kustomize format", "https://bitbucket.example.com/scm/PRJ/infra.git//deploy/overlay?ref=dev", "deploy/overlay", "dev"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := ParseReference(context.Background(), c.ref, "")
+			if err != nil {
+				t.Fatalf("ParseReference error: %v", err)
+			}
+			if got.RepoInfo == nil {
+				t.Fatal("RepoInfo is nil")
+			}
+			if got.RepoInfo.Type != repository.Bitbucket {
+				t.Errorf("Type = %s, want bitbucket", got.RepoInfo.Type)
+			}
+			if got.RepoInfo.Owner != "PRJ" || got.RepoInfo.Repo != "infra" {
+				t.Errorf("RepoInfo = %s/%s, want PRJ/infra", got.RepoInfo.Owner, got.RepoInfo.Repo)
+			}
+			if got.Path != c.path || got.RepoInfo.Ref != c.wantRef {
+				t.Errorf("Path, Ref = %q, %q; want %q, %q", got.Path, got.RepoInfo.Ref, c.path, c.wantRef)
+			}
+		})
+	}
+}
+
+func TestParseReference_HTTP_AzureDevOps(t *testing.T) {
+	cases := []struct {
+		name    string
+		ref     string
+		path    string
+		wantRef string
+	}{
+		{"kustomize format", "https://dev.azure.com/contoso/platform/_git/gitops//deploy/overlay?ref=v1.0", "deploy/overlay", "v1.0"},
+		{"browse URL", "https://dev.azure.com/contoso/platform/_git/gitops?path=/deploy/overlay&version=GBdev", "deploy/overlay", "dev"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := ParseReference(context.Background(), c.ref, "")
+			if err != nil {
+				t.Fatalf("ParseReference error: %v", err)
+			}
+			if got.RepoInfo == nil {
+				t.Fatal("RepoInfo is nil")
+			}
+			if got.RepoInfo.Type != repository.AzureDevOps {
+				t.Errorf("Type = %s, want azure", got.RepoInfo.Type)
+			}
+			if got.RepoInfo.Owner != "contoso/platform" || got.RepoInfo.Repo != "gitops" {
+				t.Errorf("RepoInfo = %s/%s, want contoso/platform/gitops", got.RepoInfo.Owner, got.RepoInfo.Repo)
+			}
+			if got.Path != c.path || got.RepoInfo.Ref != c.wantRef {
+				t.Errorf("Path, Ref = %q, %q; want %q, %q", got.Path, got.RepoInfo.Ref, c.path, c.wantRef)
+			}
+		})
+	}
+}
+
 func TestKustomizeReference_String(t *testing.T) {
 	rel := &KustomizeReference{Type: ReferenceRelative, RelativePath: "./base"}
 	if got := rel.String(); got != "relative:./base" {
@@ -176,7 +242,7 @@ func TestParseReference_KustomizationWithBarePaths(t *testing.T) {
 	wantPaths := []string{"deployment-02", "nodeset", "components/foo"}
 
 	for i, raw := range refs {
-		ref, err := ParseReference(raw, "")
+		ref, err := ParseReference(context.Background(), raw, "")
 		if err != nil {
 			t.Fatalf("ParseReference(%q): %v", raw, err)
 		}
@@ -221,7 +287,7 @@ func TestParseReference_RealKustomizationContent(t *testing.T) {
 	}
 
 	for i, rawURL := range kust.Components {
-		ref, err := ParseReference(rawURL, "")
+		ref, err := ParseReference(context.Background(), rawURL, "")
 		if err != nil {
 			t.Fatalf("component[%d] ParseReference(%q): %v", i, rawURL, err)
 		}
@@ -285,7 +351,7 @@ func TestParseReference_KustomizationWithBranchWithSlashes(t *testing.T) {
 	}
 
 	rawURL := kust.Components[0]
-	ref, err := ParseReference(rawURL, "")
+	ref, err := ParseReference(context.Background(), rawURL, "")
 	if err != nil {
 		t.Fatalf("ParseReference(%q): %v", rawURL, err)
 	}
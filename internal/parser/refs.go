@@ -0,0 +1,105 @@
+package parser
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/cjeanner/kustomap/internal/repository"
+)
+
+// repoKey identifies a remote repository (without ref).
+func repoKey(repo *repository.RepositoryInfo) string {
+	return fmt.Sprintf("%s:%s/%s/%s", repo.Type, repo.BaseURL, repo.Owner, repo.Repo)
+}
+
+// repoRefKey identifies a remote repository at a ref.
+func repoRefKey(repo *repository.RepositoryInfo) string {
+	return repoKey(repo) + "@" + repo.Ref
+}
+
+// fetcherKey identifies the content a fetcher reads: repo@ref for remote repos, the root
+// directory for local ones.
+func fetcherKey(repo *repository.RepositoryInfo) string {
+	if repo.Type == repository.Local {
+		return fmt.Sprintf("%s:%s@%s", repo.Type, repo.RootPath, repo.Ref)
+	}
+	return repoRefKey(repo)
+}
+
+// resolveDefaultBranch sets the ref of a remote repo referenced without ?ref= to the
+// repository's default branch (looked up once per repo). When the lookup fails the
+// ref stays "main", as before.
+func (p *Parser) resolveDefaultBranch(repo *repository.RepositoryInfo, token string) {
+	if !repo.FloatingRef {
+		return
+	}
+	key := repoKey(repo)
+	defer p.lockKey("branch:" + key)()
+	p.mu.Lock()
+	branch, ok := p.defaultBranch[key]
+	p.mu.Unlock()
+	if ok {
+		repo.Ref = branch
+		return
+	}
+	if err := repository.ResolveDefaultBranch(p.ctx, repo, token); err != nil {
+		log.Printf("Warning: could not resolve default branch of %s/%s, using %s: %v", repo.Owner, repo.Repo, repo.Ref, err)
+	}
+	p.mu.Lock()
+	p.defaultBranch[key] = repo.Ref
+	p.mu.Unlock()
+}
+
+// pinCommit returns the commit SHA the ref of a remote repo points to, resolved the first
+// time the repo@ref is touched so that every node of that repo refers to the same commit.
+// Returns "" for local repos or when the lookup fails (the ref is then used as is).
+// Refs that already are commit SHAs are looked up too: only a pinned repo is read from the
+// content cache, and the lookup is what checks that token can read the repository.
+func (p *Parser) pinCommit(repo *repository.RepositoryInfo, token string) string {
+	if repo.Type == repository.Local {
+		return ""
+	}
+	key := repoRefKey(repo)
+	defer p.lockKey("commit:" + key)()
+	p.mu.Lock()
+	sha, ok := p.commitSHA[key]
+	p.mu.Unlock()
+	if ok {
+		return sha
+	}
+	if resolved, err := repository.ResolveCommitSHA(p.ctx, repo, token); err != nil {
+		log.Printf("Warning: could not resolve %s to a commit, nodes are not pinned: %v", repo.String(), err)
+	} else {
+		sha = resolved
+		log.Printf("Pinned %s to commit %s", repo.String(), sha)
+	}
+	p.mu.Lock()
+	p.commitSHA[key] = sha
+	p.mu.Unlock()
+	return sha
+}
+
+// annotateRefs records in the graph the repository and commit SHA of each node
+// (Graph.Repos, Graph.CommitSHAs) and the clone URL of plain git nodes (Graph.CloneURLs),
+// and flags the nodes whose ref is a repository default branch resolved at analysis time
+// (no branch or tag in the URL): their content may change without the graph knowing.
+func (p *Parser) annotateRefs() {
+	for i := range p.graph.Elements {
+		elem := &p.graph.Elements[i]
+		if elem.Group != "nodes" {
+			continue
+		}
+		if p.floatingIDs[elem.Data.ID] {
+			elem.Data.FloatingRef = true
+		}
+		if sha := p.nodeCommits[elem.Data.ID]; sha != "" {
+			p.graph.CommitSHAs[elem.Data.ID] = sha
+		}
+		if cloneURL := p.cloneURLs[elem.Data.ID]; cloneURL != "" {
+			p.graph.CloneURLs[elem.Data.ID] = cloneURL
+		}
+		if repo := p.repos[elem.Data.ID]; repo != "" {
+			p.graph.Repos[elem.Data.ID] = repo
+		}
+	}
+}
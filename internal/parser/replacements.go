@@ -0,0 +1,269 @@
+package parser
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/cjeanner/kustomap/internal/repository"
+	"gopkg.in/yaml.v3"
+)
+
+// Replacement is an entry of the kustomization "replacements" field: either inline
+// (Source and Targets) or a Path to a file holding one or more replacements.
+// Origin is the file a replacement was loaded from (node details only).
+type Replacement struct {
+	Path    string              `yaml:"path,omitempty" json:"path,omitempty"`
+	Source  *ReplacementSource  `yaml:"source,omitempty" json:"source,omitempty"`
+	Targets []ReplacementTarget `yaml:"targets,omitempty" json:"targets,omitempty"`
+	Origin  string              `yaml:"-" json:"origin,omitempty"`
+}
+
+// ReplacementSource selects the object and field a replacement copies its value from.
+type ReplacementSource struct {
+	PatchTarget `yaml:",inline"`
+	FieldPath   string                 `yaml:"fieldPath,omitempty" json:"fieldPath,omitempty"`
+	Options     map[string]interface{} `yaml:"options,omitempty" json:"options,omitempty"`
+}
+
+// ReplacementTarget selects the objects and fields a replacement writes to.
+type ReplacementTarget struct {
+	Select     *PatchTarget           `yaml:"select,omitempty" json:"select,omitempty"`
+	Reject     []PatchTarget          `yaml:"reject,omitempty" json:"reject,omitempty"`
+	FieldPaths []string               `yaml:"fieldPaths,omitempty" json:"fieldPaths,omitempty"`
+	Options    map[string]interface{} `yaml:"options,omitempty" json:"options,omitempty"`
+}
+
+// Var is an entry of the deprecated kustomization "vars" field.
+type Var struct {
+	Name   string `yaml:"name" json:"name"`
+	ObjRef struct {
+		APIVersion string `yaml:"apiVersion,omitempty" json:"apiVersion,omitempty"`
+		Kind       string `yaml:"kind,omitempty" json:"kind,omitempty"`
+		Name       string `yaml:"name,omitempty" json:"name,omitempty"`
+		Namespace  string `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+	} `yaml:"objref" json:"objref"`
+	FieldRef struct {
+		FieldPath string `yaml:"fieldpath,omitempty" json:"fieldpath,omitempty"`
+	} `yaml:"fieldref,omitempty" json:"fieldref,omitempty"`
+}
+
+// ObjectRef identifies a Kubernetes object known to the graph.
+type ObjectRef struct {
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name,omitempty"`
+}
+
+// processReplacements loads the replacements of a kustomization (inline entries and
+// "path:" files) and records them, with their field paths, in the node content.
+// Edges are added by linkReplacements once the whole graph is known.
+// A replacement file that cannot be fetched or parsed becomes an error node.
+func (p *Parser) processReplacements(nodeID string, entries []Replacement, currentPath string, currentRepo *repository.RepositoryInfo) {
+	var resolved []Replacement
+	for _, entry := range entries {
+		if entry.Path == "" {
+			resolved = append(resolved, entry)
+			continue
+		}
+		filePath := resolvePath(currentPath, entry.Path)
+		loaded, err := p.loadReplacementFile(filePath, currentRepo)
+		if err != nil {
+			errorID := p.buildNodeID(currentRepo, filePath)
+			p.addFetchErrorNode(errorID, filePath, "Failed to load replacements: ", err, currentRepo.BaseURL)
+			p.addEdge(nodeID, errorID, "replacement")
+			continue
+		}
+		resolved = append(resolved, loaded...)
+	}
+	if len(resolved) == 0 {
+		return
+	}
+	p.replacements[nodeID] = resolved
+	if node := p.nodeByID(nodeID); node != nil && node.Content != nil {
+		node.Content["replacements"] = resolved
+	}
+}
+
+// loadReplacementFile fetches a replacement file, which holds either a single
+// replacement or a list of them.
+func (p *Parser) loadReplacementFile(filePath string, currentRepo *repository.RepositoryInfo) ([]Replacement, error) {
+	f, err := p.fetcherForRepo(currentRepo)
+	if err != nil {
+		return nil, err
+	}
+	data, err := f.FetchFile(p.ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+	var list []Replacement
+	if err := yaml.Unmarshal(data, &list); err != nil {
+		var single Replacement
+		if err2 := yaml.Unmarshal(data, &single); err2 != nil {
+			return nil, err
+		}
+		list = []Replacement{single}
+	}
+	for i := range list {
+		list[i].Origin = filePath
+	}
+	return list, nil
+}
+
+// linkReplacements adds "replacement" edges from the kustomization defining the source
+// object of each replacement (or var) to the kustomizations defining the objects it
+// rewrites. Objects are looked up among the kustomization's own resources and those of
+// its bases and components, which is the scope kustomize applies replacements to.
+// Objects are known from generators and from expanded YAML resources; label and
+// annotation selectors are not evaluated, so such selectors may over-approximate.
+// Must run once the whole graph is built.
+func (p *Parser) linkReplacements() {
+	ids := make([]string, 0, len(p.kustomizations))
+	for id := range p.kustomizations {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids) // deterministic edge order
+
+	for _, nodeID := range ids {
+		kust := p.kustomizations[nodeID]
+		replacements := p.replacements[nodeID]
+		if len(replacements) == 0 && len(kust.Vars) == 0 {
+			continue
+		}
+		objects := p.objectsInScope(nodeID)
+
+		for _, r := range replacements {
+			if r.Source == nil {
+				continue
+			}
+			sources := ownersMatching(objects, func(obj ObjectRef) bool { return r.Source.matches(obj) })
+			targets := ownersMatching(objects, func(obj ObjectRef) bool {
+				for _, t := range r.Targets {
+					if t.matches(obj) {
+						return true
+					}
+				}
+				return false
+			})
+			if len(sources) == 0 {
+				log.Printf("No source object found for replacement %s/%s in %s", r.Source.Kind, r.Source.Name, nodeID)
+			}
+			p.addReplacementEdges(sources, targets)
+		}
+
+		// Vars are substituted in the objects of the kustomization that declares them
+		for _, v := range kust.Vars {
+			ref := PatchTarget{Kind: v.ObjRef.Kind, Name: v.ObjRef.Name, Namespace: v.ObjRef.Namespace}
+			ref.Group, ref.Version = splitAPIVersion(v.ObjRef.APIVersion)
+			sources := ownersMatching(objects, ref.matches)
+			if len(sources) == 0 {
+				log.Printf("No source object found for var %s in %s", v.Name, nodeID)
+			}
+			p.addReplacementEdges(sources, []string{nodeID})
+		}
+	}
+}
+
+// addReplacementEdges links every source kustomization to every target kustomization.
+// Replacements within a single kustomization add no edge.
+func (p *Parser) addReplacementEdges(sources, targets []string) {
+	for _, src := range sources {
+		for _, tgt := range targets {
+			if src != tgt {
+				// Distinct ID: a resource/component edge may already link the same pair
+				p.addEdgeWithID(fmt.Sprintf("%s->%s:replacement", src, tgt), src, tgt, "replacement")
+			}
+		}
+	}
+}
+
+// objectsInScope returns the objects known in the build of a kustomization (its own
+// and those of its bases and components), keyed by the kustomization that defines them.
+func (p *Parser) objectsInScope(rootID string) map[string][]ObjectRef {
+	objects := make(map[string][]ObjectRef)
+	visited := map[string]bool{rootID: true}
+	queue := []string{rootID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, elem := range p.graph.Elements {
+			if elem.Group != "edges" || elem.Data.Source != id {
+				continue
+			}
+			target := p.nodeByID(elem.Data.Target)
+			if target == nil {
+				continue
+			}
+			switch {
+			case elem.Data.EdgeType == "generator" && target.Type == "generator":
+				name, _ := target.Content["name"].(string)
+				kind, _ := target.Content["kind"].(string)
+				namespace, _ := target.Content["namespace"].(string)
+				objects[id] = append(objects[id], ObjectRef{APIVersion: "v1", Kind: kind, Namespace: namespace, Name: name})
+			case elem.Data.EdgeType == "resource" || elem.Data.EdgeType == "component":
+				if refs, ok := target.Content["objects"].([]ObjectRef); ok {
+					objects[id] = append(objects[id], refs...)
+				}
+				if _, isKust := p.kustomizations[target.ID]; isKust && !visited[target.ID] {
+					visited[target.ID] = true
+					queue = append(queue, target.ID)
+				}
+			}
+		}
+	}
+	return objects
+}
+
+// ownersMatching returns the kustomizations (sorted by ID) that define at least one matching object.
+func ownersMatching(objects map[string][]ObjectRef, match func(ObjectRef) bool) []string {
+	var owners []string
+	for owner, refs := range objects {
+		for _, obj := range refs {
+			if match(obj) {
+				owners = append(owners, owner)
+				break
+			}
+		}
+	}
+	sort.Strings(owners)
+	return owners
+}
+
+// matches reports whether obj is selected by the source selector.
+func (s *ReplacementSource) matches(obj ObjectRef) bool {
+	return s.PatchTarget.matches(obj)
+}
+
+// matches reports whether obj is selected by the target (and not rejected).
+func (t *ReplacementTarget) matches(obj ObjectRef) bool {
+	if t.Select == nil || !t.Select.matches(obj) {
+		return false
+	}
+	for _, reject := range t.Reject {
+		if reject.matches(obj) {
+			return false
+		}
+	}
+	return true
+}
+
+// matches reports whether obj is selected by group/version/kind/name/namespace.
+// Label and annotation selectors are ignored (object labels are not known).
+func (s *PatchTarget) matches(obj ObjectRef) bool {
+	group, version := splitAPIVersion(obj.APIVersion)
+	return (s.Group == "" || s.Group == group) &&
+		(s.Version == "" || s.Version == version) &&
+		(s.Kind == "" || s.Kind == obj.Kind) &&
+		(s.Name == "" || s.Name == obj.Name) &&
+		(s.Namespace == "" || s.Namespace == obj.Namespace)
+}
+
+// splitAPIVersion splits "group/version" (or a core "version") into group and version.
+func splitAPIVersion(apiVersion string) (group, version string) {
+	if g, v, ok := strings.Cut(apiVersion, "/"); ok {
+		return g, v
+	}
+	return "", apiVersion
+}
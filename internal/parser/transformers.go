@@ -0,0 +1,156 @@
+package parser
+
+// Image is an entry of the kustomization "images" field.
+type Image struct {
+	Name    string `yaml:"name" json:"name"`
+	NewName string `yaml:"newName,omitempty" json:"newName,omitempty"`
+	NewTag  string `yaml:"newTag,omitempty" json:"newTag,omitempty"`
+	Digest  string `yaml:"digest,omitempty" json:"digest,omitempty"`
+}
+
+// Replica is an entry of the kustomization "replicas" field.
+type Replica struct {
+	Name  string `yaml:"name" json:"name"`
+	Count int64  `yaml:"count" json:"count"`
+}
+
+// Label is an entry of the kustomization "labels" field.
+type Label struct {
+	Pairs            map[string]string `yaml:"pairs" json:"pairs"`
+	IncludeSelectors bool              `yaml:"includeSelectors,omitempty" json:"includeSelectors,omitempty"`
+	IncludeTemplates bool              `yaml:"includeTemplates,omitempty" json:"includeTemplates,omitempty"`
+}
+
+// EffectiveImage is the image override that applies to a kustomization once all
+// its bases are taken into account. SetBy is the ID of the node that set it.
+type EffectiveImage struct {
+	Image
+	SetBy string `json:"setBy"`
+}
+
+// EffectiveReplica is the replica count that applies to a kustomization once all its bases are taken into account.
+type EffectiveReplica struct {
+	Replica
+	SetBy string `json:"setBy"`
+}
+
+// EffectiveTransformers is the "effective" view of a kustomization node: the image tags,
+// namespace, name prefix/suffix, replicas, labels and annotations that its build applies,
+// combining its own settings with those of its resources and components (outermost wins).
+type EffectiveTransformers struct {
+	Namespace      string                      `json:"namespace,omitempty"`
+	NamespaceSetBy string                      `json:"namespaceSetBy,omitempty"`
+	NamePrefix     string                      `json:"namePrefix,omitempty"`
+	NameSuffix     string                      `json:"nameSuffix,omitempty"`
+	Images         map[string]EffectiveImage   `json:"images,omitempty"`
+	Replicas       map[string]EffectiveReplica `json:"replicas,omitempty"`
+	Labels         map[string]string           `json:"labels,omitempty"`
+	Annotations    map[string]string           `json:"annotations,omitempty"`
+}
+
+// computeEffectiveTransformers stores an "effective" entry in the content of every
+// kustomization node. Must run once the whole graph is built.
+func (p *Parser) computeEffectiveTransformers() {
+	children := make(map[string][]string)
+	for _, elem := range p.graph.Elements {
+		if elem.Group == "edges" && (elem.Data.EdgeType == "resource" || elem.Data.EdgeType == "component") {
+			children[elem.Data.Source] = append(children[elem.Data.Source], elem.Data.Target)
+		}
+	}
+
+	memo := make(map[string]*EffectiveTransformers)
+	inProgress := make(map[string]bool)
+	var effective func(id string) *EffectiveTransformers
+	effective = func(id string) *EffectiveTransformers {
+		if eff, ok := memo[id]; ok {
+			return eff
+		}
+		kust, ok := p.kustomizations[id]
+		if !ok || inProgress[id] {
+			// Not a kustomization (plain YAML, error node) or a reference cycle
+			return nil
+		}
+		inProgress[id] = true
+		defer delete(inProgress, id)
+
+		eff := &EffectiveTransformers{
+			Images:      make(map[string]EffectiveImage),
+			Replicas:    make(map[string]EffectiveReplica),
+			Labels:      make(map[string]string),
+			Annotations: make(map[string]string),
+		}
+		// Bases first, in declaration order; the first base to set a value wins among siblings.
+		for _, childID := range children[id] {
+			child := effective(childID)
+			if child == nil {
+				continue
+			}
+			if eff.Namespace == "" && child.Namespace != "" {
+				eff.Namespace, eff.NamespaceSetBy = child.Namespace, child.NamespaceSetBy
+			}
+			if eff.NamePrefix == "" {
+				eff.NamePrefix = child.NamePrefix
+			}
+			if eff.NameSuffix == "" {
+				eff.NameSuffix = child.NameSuffix
+			}
+			for name, img := range child.Images {
+				if _, ok := eff.Images[name]; !ok {
+					eff.Images[name] = img
+				}
+			}
+			for name, rep := range child.Replicas {
+				if _, ok := eff.Replicas[name]; !ok {
+					eff.Replicas[name] = rep
+				}
+			}
+			for k, v := range child.Labels {
+				if _, ok := eff.Labels[k]; !ok {
+					eff.Labels[k] = v
+				}
+			}
+			for k, v := range child.Annotations {
+				if _, ok := eff.Annotations[k]; !ok {
+					eff.Annotations[k] = v
+				}
+			}
+		}
+
+		// Then this kustomization's own settings, which override its bases.
+		if kust.Namespace != "" {
+			eff.Namespace, eff.NamespaceSetBy = kust.Namespace, id
+		}
+		eff.NamePrefix = kust.NamePrefix + eff.NamePrefix
+		eff.NameSuffix = eff.NameSuffix + kust.NameSuffix
+		for _, img := range kust.Images {
+			eff.Images[img.Name] = EffectiveImage{Image: img, SetBy: id}
+		}
+		for _, rep := range kust.Replicas {
+			eff.Replicas[rep.Name] = EffectiveReplica{Replica: rep, SetBy: id}
+		}
+		for k, v := range kust.CommonLabels {
+			eff.Labels[k] = v
+		}
+		for _, label := range kust.Labels {
+			for k, v := range label.Pairs {
+				eff.Labels[k] = v
+			}
+		}
+		for k, v := range kust.CommonAnnotations {
+			eff.Annotations[k] = v
+		}
+
+		memo[id] = eff
+		return eff
+	}
+
+	for i := range p.graph.Elements {
+		elem := &p.graph.Elements[i]
+		if elem.Group != "nodes" || elem.Data.Content == nil {
+			continue
+		}
+		if eff := effective(elem.Data.ID); eff != nil {
+			elem.Data.Content["effective"] = eff
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// apiGet performs a GET on a forge REST API, authenticated by setAuth, and returns the body.
+// Non-200 responses are returned as errors (with the start of the body for context).
+// Rate-limited requests are retried as described in WithRateLimitRetry.
+func apiGet(ctx context.Context, client *http.Client, apiURL string, setAuth func(*http.Request)) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	setAuth(req)
+	var body []byte
+	err = WithRateLimitRetry(ctx, req.URL.Hostname(), func() (*http.Response, error) {
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		body, err = io.ReadAll(resp.Body)
+		if err != nil {
+			return resp, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			if len(body) > 256 {
+				body = body[:256]
+			}
+			return resp, fmt.Errorf("GET %s: %s: %s", apiURL, resp.Status, strings.TrimSpace(string(body)))
+		}
+		return resp, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return body, nil
+}
@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// azureAPIVersion is the Azure DevOps REST API version used for all calls.
+const azureAPIVersion = "7.0"
+
+// azureClient is the HTTP client used for Azure DevOps REST API calls.
+var azureClient = &http.Client{Timeout: 30 * time.Second}
+
+// IsAzureDevOpsHost reports whether host serves Azure DevOps Repos
+// (dev.azure.com, ssh.dev.azure.com or a legacy {org}.visualstudio.com host).
+func IsAzureDevOpsHost(host string) bool {
+	return host == "dev.azure.com" || host == "ssh.dev.azure.com" || strings.HasSuffix(host, ".visualstudio.com")
+}
+
+// AzureRepoAPI returns the Git REST API URL of an Azure DevOps repository:
+// {base}/{org}/{project}/_apis/git/repositories/{repo} (Owner is "org/project").
+func AzureRepoAPI(info *RepositoryInfo) string {
+	owner := strings.Split(info.Owner, "/")
+	for i := range owner {
+		owner[i] = url.PathEscape(owner[i])
+	}
+	return fmt.Sprintf("%s/%s/_apis/git/repositories/%s",
+		strings.TrimSuffix(info.BaseURL, "/"), strings.Join(owner, "/"), url.PathEscape(info.Repo))
+}
+
+// AzureAPIURL returns the URL of an Azure DevOps repository API resource (e.g. "items",
+// "refs", or "" for the repository itself) with the given query and the API version.
+func AzureAPIURL(info *RepositoryInfo, resource string, query url.Values) string {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("api-version", azureAPIVersion)
+	apiURL := AzureRepoAPI(info)
+	if resource != "" {
+		apiURL += "/" + resource
+	}
+	return apiURL + "?" + query.Encode()
+}
+
+// SetAzureAuth adds a personal access token to an Azure DevOps request (basic auth, empty user).
+func SetAzureAuth(req *http.Request, token string) {
+	if token != "" {
+		req.SetBasicAuth("", token)
+	}
+}
+
+// AzureGet performs an authenticated GET on the Azure DevOps API and returns the body.
+// Non-200 responses are returned as errors.
+func AzureGet(ctx context.Context, client *http.Client, apiURL, token string) ([]byte, error) {
+	return apiGet(ctx, client, apiURL, func(req *http.Request) { SetAzureAuth(req, token) })
+}
+
+// AzureVersion returns the versionDescriptor query parameters selecting ref: a commit for
+// SHAs, otherwise the given version type ("branch" or "tag").
+func AzureVersion(ref, versionType string) url.Values {
+	if IsCommitHash(ref) {
+		versionType = "commit"
+	}
+	return url.Values{
+		"versionDescriptor.version":     {ref},
+		"versionDescriptor.versionType": {versionType},
+	}
+}
+
+// parseAzureDevOpsURL extracts owner ("org/project"), repo, path and ref from an Azure
+// DevOps URL: org/project/_git/repo (dev.azure.com), project/_git/repo ({org}.visualstudio.com)
+// or v3/org/project/repo (SSH remotes). Browse URLs carry the path and ref in the query:
+// ?path=/deploy&version=GBbranch (GT for tags, GC for commits).
+func parseAzureDevOpsURL(host, path string, query url.Values) (*RepositoryInfo, error) {
+	parts := strings.Split(path, "/")
+	var org, project, repo string
+
+	switch {
+	case host == "ssh.dev.azure.com" && len(parts) >= 4 && parts[0] == "v3":
+		org, project, repo = parts[1], parts[2], parts[3]
+	case strings.HasSuffix(host, ".visualstudio.com"):
+		org = strings.TrimSuffix(host, ".visualstudio.com")
+		if len(parts) > 0 && parts[0] == "DefaultCollection" {
+			parts = parts[1:]
+		}
+		if len(parts) >= 3 && parts[1] == "_git" {
+			project, repo = parts[0], parts[2]
+		} else if len(parts) >= 2 && parts[0] == "_git" {
+			project, repo = parts[1], parts[1]
+		}
+	default:
+		if len(parts) >= 4 && parts[2] == "_git" {
+			org, project, repo = parts[0], parts[1], parts[3]
+		} else if len(parts) >= 3 && parts[1] == "_git" {
+			// org/_git/repo: project named after the repository
+			org, project, repo = parts[0], parts[2], parts[2]
+		}
+	}
+	if org == "" || project == "" || repo == "" {
+		return nil, fmt.Errorf("invalid Azure DevOps repository path: %s", path)
+	}
+
+	// Legacy {org}.visualstudio.com hosts are served by the dev.azure.com API too
+	info := &RepositoryInfo{
+		Type:        AzureDevOps,
+		Owner:       org + "/" + project,
+		Repo:        strings.TrimSuffix(repo, ".git"),
+		Ref:         "main",
+		BaseURL:     "https://dev.azure.com",
+		Path:        strings.Trim(query.Get("path"), "/"),
+		FloatingRef: true,
+	}
+	if ref := AzureRefFromVersion(query.Get("version")); ref != "" {
+		info.Ref = ref
+		info.FloatingRef = false
+	}
+	return info, nil
+}
+
+// AzureRefFromVersion returns the branch, tag or commit of a browse URL "version"
+// parameter (GBbranch, GTtag, GCsha), or "" when unset or unknown.
+func AzureRefFromVersion(version string) string {
+	if len(version) > 2 {
+		switch version[:2] {
+		case "GB", "GT", "GC":
+			return version[2:]
+		}
+	}
+	return ""
+}
+
+// getAzureDevOpsDefaultBranch reads the default branch from the repository metadata
+func getAzureDevOpsDefaultBranch(ctx context.Context, repoInfo *RepositoryInfo, token string) (string, error) {
+	body, err := AzureGet(ctx, azureClient, AzureAPIURL(repoInfo, "", nil), token)
+	if err != nil {
+		return "", fmt.Errorf("failed to get repository: %w", err)
+	}
+	var repo struct {
+		DefaultBranch string `json:"defaultBranch"`
+	}
+	if err := json.Unmarshal(body, &repo); err != nil {
+		return "", fmt.Errorf("invalid repository response: %w", err)
+	}
+	return strings.TrimPrefix(repo.DefaultBranch, "refs/heads/"), nil
+}
+
+// azureRef is an entry of the Azure DevOps refs API.
+type azureRef struct {
+	Name           string `json:"name"`
+	ObjectID       string `json:"objectId"`
+	PeeledObjectID string `json:"peeledObjectId"` // commit of an annotated tag
+}
+
+// listAzureRefs lists the refs of a repository whose name starts with filter ("heads/", "tags/", ...).
+func listAzureRefs(ctx context.Context, repoInfo *RepositoryInfo, filter, token string) ([]azureRef, error) {
+	body, err := AzureGet(ctx, azureClient, AzureAPIURL(repoInfo, "refs", url.Values{"filter": {filter}, "peelTags": {"true"}}), token)
+	if err != nil {
+		return nil, err
+	}
+	var refs struct {
+		Value []azureRef `json:"value"`
+	}
+	if err := json.Unmarshal(body, &refs); err != nil {
+		return nil, fmt.Errorf("invalid refs response: %w", err)
+	}
+	return refs.Value, nil
+}
+
+// AzureRefType returns the versionType of ref: "commit" for SHAs, "branch" when a branch
+// of that name exists, "tag" otherwise.
+func AzureRefType(ctx context.Context, repoInfo *RepositoryInfo, token string) string {
+	if IsCommitHash(repoInfo.Ref) {
+		return "commit"
+	}
+	refs, err := listAzureRefs(ctx, repoInfo, "heads/"+repoInfo.Ref, token)
+	if err == nil {
+		for _, ref := range refs {
+			if ref.Name == "refs/heads/"+repoInfo.Ref {
+				return "branch"
+			}
+		}
+	}
+	refs, err = listAzureRefs(ctx, repoInfo, "tags/"+repoInfo.Ref, token)
+	if err == nil {
+		for _, ref := range refs {
+			if ref.Name == "refs/tags/"+repoInfo.Ref {
+				return "tag"
+			}
+		}
+	}
+	return "branch"
+}
+
+// resolveAzureDevOpsCommit returns the commit a branch or tag of an Azure DevOps repository points to.
+func resolveAzureDevOpsCommit(ctx context.Context, repoInfo *RepositoryInfo, token string) (string, error) {
+	if IsCommitHash(repoInfo.Ref) {
+		// Look the commit up anyway: callers rely on the lookup to check access
+		body, err := AzureGet(ctx, azureClient, AzureAPIURL(repoInfo, "commits/"+url.PathEscape(repoInfo.Ref), nil), token)
+		if err != nil {
+			return "", err
+		}
+		var commit struct {
+			CommitID string `json:"commitId"`
+		}
+		if err := json.Unmarshal(body, &commit); err != nil {
+			return "", fmt.Errorf("invalid commit response: %w", err)
+		}
+		return commit.CommitID, nil
+	}
+	for _, prefix := range []string{"heads/", "tags/"} {
+		refs, err := listAzureRefs(ctx, repoInfo, prefix+repoInfo.Ref, token)
+		if err != nil {
+			return "", err
+		}
+		for _, ref := range refs {
+			if ref.Name != "refs/"+prefix+repoInfo.Ref {
+				continue // filter is a prefix match
+			}
+			if ref.PeeledObjectID != "" {
+				return ref.PeeledObjectID, nil
+			}
+			return ref.ObjectID, nil
+		}
+	}
+	return "", nil
+}
+
+// listAzureDevOpsBranchesAndTags returns the branch and tag names of an Azure DevOps repository.
+func listAzureDevOpsBranchesAndTags(ctx context.Context, repoInfo *RepositoryInfo, token string) ([]string, error) {
+	var names []string
+	for _, prefix := range []string{"heads/", "tags/"} {
+		refs, err := listAzureRefs(ctx, repoInfo, prefix, token)
+		if err != nil {
+			if prefix == "tags/" {
+				break // tags are optional, like on GitHub/GitLab
+			}
+			return nil, fmt.Errorf("failed to list branches: %w", err)
+		}
+		for _, ref := range refs {
+			names = append(names, strings.TrimPrefix(ref.Name, "refs/"+prefix))
+		}
+	}
+	log.Printf("Found %d branches/tags for %s/%s", len(names), repoInfo.Owner, repoInfo.Repo)
+	return names, nil
+}
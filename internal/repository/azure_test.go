@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDetectRepository_AzureDevOps(t *testing.T) {
+	cases := []struct {
+		name     string
+		repoURL  string
+		owner    string
+		repo     string
+		path     string
+		ref      string
+		floating bool
+	}{
+		{
+			name:     "repository root",
+			repoURL:  "https://dev.azure.com/contoso/platform/_git/gitops",
+			owner:    "contoso/platform",
+			repo:     "gitops",
+			ref:      "main",
+			floating: true,
+		},
+		{
+			name:    "browse URL with branch",
+			repoURL: "https://dev.azure.com/contoso/platform/_git/gitops?path=/deploy/overlay&version=GBrelease/1.0",
+			owner:   "contoso/platform",
+			repo:    "gitops",
+			path:    "deploy/overlay",
+			ref:     "release/1.0",
+		},
+		{
+			name:    "browse URL with tag",
+			repoURL: "https://dev.azure.com/contoso/platform/_git/gitops?path=/deploy&version=GTv1.2.0",
+			owner:   "contoso/platform",
+			repo:    "gitops",
+			path:    "deploy",
+			ref:     "v1.2.0",
+		},
+		{
+			name:     "project named after the repository",
+			repoURL:  "https://dev.azure.com/contoso/_git/gitops",
+			owner:    "contoso/gitops",
+			repo:     "gitops",
+			ref:      "main",
+			floating: true,
+		},
+		{
+			name:     "legacy visualstudio.com host",
+			repoURL:  "https://contoso.visualstudio.com/DefaultCollection/platform/_git/gitops",
+			owner:    "contoso/platform",
+			repo:     "gitops",
+			ref:      "main",
+			floating: true,
+		},
+		{
+			name:     "ssh remote converted to https",
+			repoURL:  "https://ssh.dev.azure.com/v3/contoso/platform/gitops",
+			owner:    "contoso/platform",
+			repo:     "gitops",
+			ref:      "main",
+			floating: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			info, err := DetectRepository(context.Background(), c.repoURL, "")
+			if err != nil {
+				t.Fatalf("DetectRepository error: %v", err)
+			}
+			if info.Type != AzureDevOps {
+				t.Errorf("Type = %s, want azure", info.Type)
+			}
+			if info.Owner != c.owner || info.Repo != c.repo {
+				t.Errorf("Owner/Repo = %s/%s, want %s/%s", info.Owner, info.Repo, c.owner, c.repo)
+			}
+			if info.BaseURL != "https://dev.azure.com" {
+				t.Errorf("BaseURL = %q, want https://dev.azure.com", info.BaseURL)
+			}
+			if info.Path != c.path {
+				t.Errorf("Path = %q, want %q", info.Path, c.path)
+			}
+			if info.Ref != c.ref {
+				t.Errorf("Ref = %q, want %q", info.Ref, c.ref)
+			}
+			if info.FloatingRef != c.floating {
+				t.Errorf("FloatingRef = %v, want %v", info.FloatingRef, c.floating)
+			}
+		})
+	}
+
+	if _, err := DetectRepository(context.Background(), "https://dev.azure.com/contoso/platform", ""); err == nil {
+		t.Error("DetectRepository without _git should error")
+	}
+}
+
+func TestAzureDevOps_Resolve(t *testing.T) {
+	const repoAPI = "/contoso/platform/_apis/git/repositories/gitops"
+	mux := http.NewServeMux()
+	mux.HandleFunc(repoAPI, func(w http.ResponseWriter, r *http.Request) {
+		if _, pat, _ := r.BasicAuth(); pat != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"defaultBranch":"refs/heads/develop"}`))
+	})
+	mux.HandleFunc(repoAPI+"/refs", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("api-version") == "" {
+			t.Error("missing api-version")
+		}
+		switch r.URL.Query().Get("filter") {
+		case "heads/":
+			w.Write([]byte(`{"value":[{"name":"refs/heads/develop"},{"name":"refs/heads/feature/x"}]}`))
+		case "tags/":
+			w.Write([]byte(`{"value":[{"name":"refs/tags/v1.0"}]}`))
+		case "heads/v1.0":
+			w.Write([]byte(`{"value":[]}`))
+		case "tags/v1.0":
+			w.Write([]byte(`{"value":[{"name":"refs/tags/v1.0","objectId":"1111111111111111111111111111111111111111","peeledObjectId":"2222222222222222222222222222222222222222"}]}`))
+		case "heads/develop":
+			// Prefix match: also returns develop-old
+			w.Write([]byte(`{"value":[{"name":"refs/heads/develop-old","objectId":"3333333333333333333333333333333333333333"},{"name":"refs/heads/develop","objectId":"4444444444444444444444444444444444444444"}]}`))
+		default:
+			w.Write([]byte(`{"value":[]}`))
+		}
+	})
+	mux.HandleFunc(repoAPI+"/commits/5555555555555555555555555555555555555555", func(w http.ResponseWriter, r *http.Request) {
+		if _, pat, _ := r.BasicAuth(); pat != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"commitId":"5555555555555555555555555555555555555555"}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	info := &RepositoryInfo{Type: AzureDevOps, Owner: "contoso/platform", Repo: "gitops", Ref: "main", BaseURL: srv.URL, FloatingRef: true}
+
+	branch, path, err := ResolveBranchAndPath(context.Background(), info, "feature/x/deploy", "")
+	if err != nil {
+		t.Fatalf("ResolveBranchAndPath: %v", err)
+	}
+	if branch != "feature/x" || path != "deploy" {
+		t.Errorf("ResolveBranchAndPath = %q, %q; want feature/x, deploy", branch, path)
+	}
+
+	if err := ResolveDefaultBranch(context.Background(), info, "secret"); err != nil {
+		t.Fatalf("ResolveDefaultBranch: %v", err)
+	}
+	if info.Ref != "develop" {
+		t.Errorf("Ref = %q, want develop", info.Ref)
+	}
+
+	sha, err := ResolveCommitSHA(context.Background(), info, "")
+	if err != nil {
+		t.Fatalf("ResolveCommitSHA(context.Background(), develop): %v", err)
+	}
+	if sha != "4444444444444444444444444444444444444444" {
+		t.Errorf("ResolveCommitSHA(context.Background(), develop) = %q", sha)
+	}
+
+	// Annotated tags resolve to the commit they point to
+	info.Ref = "v1.0"
+	sha, err = ResolveCommitSHA(context.Background(), info, "")
+	if err != nil {
+		t.Fatalf("ResolveCommitSHA(context.Background(), v1.0): %v", err)
+	}
+	if sha != "2222222222222222222222222222222222222222" {
+		t.Errorf("ResolveCommitSHA(context.Background(), v1.0) = %q", sha)
+	}
+	if got := AzureRefType(context.Background(), info, ""); got != "tag" {
+		t.Errorf("AzureRefType(context.Background(), v1.0) = %q, want tag", got)
+	}
+
+	// Commit SHAs are looked up too, so that resolving one checks access
+	info.Ref = "5555555555555555555555555555555555555555"
+	if sha, err := ResolveCommitSHA(context.Background(), info, "secret"); err != nil || sha != info.Ref {
+		t.Errorf("ResolveCommitSHA(context.Background(), sha) = %q, %v; want the SHA", sha, err)
+	}
+	if _, err := ResolveCommitSHA(context.Background(), info, ""); err == nil {
+		t.Error("ResolveCommitSHA(context.Background(), sha) without token should fail")
+	}
+}
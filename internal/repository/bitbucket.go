@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// bitbucketCloudAPI is the Bitbucket Cloud REST API base URL (overridden in tests).
+var bitbucketCloudAPI = "https://api.bitbucket.org/2.0"
+
+// bitbucketClient is the HTTP client used for Bitbucket REST API calls.
+var bitbucketClient = &http.Client{Timeout: 30 * time.Second}
+
+// IsBitbucketCloud reports whether baseURL is Bitbucket Cloud (bitbucket.org) rather than
+// a Bitbucket Server / Data Center instance.
+func IsBitbucketCloud(baseURL string) bool {
+	u, err := url.Parse(baseURL)
+	return err == nil && (u.Host == "bitbucket.org" || u.Host == "www.bitbucket.org")
+}
+
+// BitbucketRepoAPI returns the REST API URL of a Bitbucket repository:
+// {api}/repositories/{workspace}/{repo} on Cloud, {base}/rest/api/1.0/projects/{key}/repos/{repo} on Server.
+func BitbucketRepoAPI(info *RepositoryInfo) string {
+	if IsBitbucketCloud(info.BaseURL) {
+		return fmt.Sprintf("%s/repositories/%s/%s", bitbucketCloudAPI, url.PathEscape(info.Owner), url.PathEscape(info.Repo))
+	}
+	return fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s",
+		strings.TrimSuffix(info.BaseURL, "/"), url.PathEscape(info.Owner), url.PathEscape(info.Repo))
+}
+
+// SetBitbucketAuth adds the token to a Bitbucket API request: "user:app-password" is sent
+// as basic auth, anything else as a bearer (HTTP/repository access) token.
+func SetBitbucketAuth(req *http.Request, token string) {
+	if token == "" {
+		return
+	}
+	if user, password, ok := strings.Cut(token, ":"); ok {
+		req.SetBasicAuth(user, password)
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+}
+
+// BitbucketGet performs an authenticated GET on the Bitbucket API and returns the body.
+// Non-200 responses are returned as errors.
+func BitbucketGet(ctx context.Context, client *http.Client, apiURL, token string) ([]byte, error) {
+	return apiGet(ctx, client, apiURL, func(req *http.Request) { SetBitbucketAuth(req, token) })
+}
+
+// BitbucketPages calls visit with each page of a paged Bitbucket API listing, following
+// "next" links on Cloud and start/nextPageStart on Server.
+func BitbucketPages(ctx context.Context, client *http.Client, apiURL, token string, cloud bool, visit func(page []byte) error) error {
+	start := 0
+	for apiURL != "" {
+		pageURL := apiURL
+		if !cloud {
+			sep := "?"
+			if strings.Contains(apiURL, "?") {
+				sep = "&"
+			}
+			pageURL = apiURL + sep + "limit=1000&start=" + strconv.Itoa(start)
+		}
+		body, err := BitbucketGet(ctx, client, pageURL, token)
+		if err != nil {
+			return err
+		}
+		if err := visit(body); err != nil {
+			return err
+		}
+		var page struct {
+			Next          string `json:"next"`
+			IsLastPage    bool   `json:"isLastPage"`
+			NextPageStart int    `json:"nextPageStart"`
+		}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return fmt.Errorf("invalid bitbucket API response: %w", err)
+		}
+		if cloud {
+			apiURL = page.Next
+		} else if page.IsLastPage || page.NextPageStart <= start {
+			apiURL = ""
+		} else {
+			start = page.NextPageStart
+		}
+	}
+	return nil
+}
+
+// isBitbucketServerInstance checks if the URL is a Bitbucket Server / Data Center instance
+func isBitbucketServerInstance(ctx context.Context, baseURL, token string) bool {
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+	body, err := BitbucketGet(ctx, client, baseURL+"/rest/api/1.0/application-properties", token)
+	if err != nil {
+		return false
+	}
+	var props struct {
+		Version string `json:"version"`
+	}
+	return json.Unmarshal(body, &props) == nil && props.Version != ""
+}
+
+// parseBitbucketURL extracts owner/repo/path/ref from a Bitbucket URL.
+// Server: projects/KEY/repos/repo/browse/path?at=refs/heads/branch, users/name/repos/repo/...
+// (owner "~name") or scm/key/repo.git. Cloud: workspace/repo/src/ref/path, where ref and
+// path are mixed and stored in AmbiguousPath.
+func parseBitbucketURL(path, at, baseURL string) (*RepositoryInfo, error) {
+	parts := strings.Split(path, "/")
+	info := &RepositoryInfo{
+		Type:        Bitbucket,
+		Ref:         "main",
+		BaseURL:     baseURL,
+		FloatingRef: true,
+	}
+
+	switch {
+	case IsBitbucketCloud(baseURL):
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("invalid Bitbucket repository path: %s", path)
+		}
+		info.Owner = parts[0]
+		info.Repo = strings.TrimSuffix(parts[1], ".git")
+		if len(parts) >= 4 && parts[2] == "src" {
+			info.AmbiguousPath = strings.Join(parts[3:], "/")
+			info.FloatingRef = false
+		}
+		return info, nil
+	case isBitbucketServerPath(path):
+		info.Owner = parts[1]
+		if parts[0] == "users" {
+			info.Owner = "~" + parts[1]
+		}
+		info.Repo = parts[3]
+		if len(parts) > 5 && parts[4] == "browse" {
+			info.Path = strings.Join(parts[5:], "/")
+		}
+	case len(parts) >= 3 && parts[0] == "scm":
+		info.Owner = parts[1]
+		info.Repo = strings.TrimSuffix(parts[2], ".git")
+		info.Path = strings.Join(parts[3:], "/")
+	default:
+		return nil, fmt.Errorf("invalid Bitbucket repository path: %s", path)
+	}
+
+	if at != "" {
+		info.Ref = strings.TrimPrefix(strings.TrimPrefix(at, "refs/heads/"), "refs/tags/")
+		info.FloatingRef = false
+	}
+	return info, nil
+}
+
+// getBitbucketDefaultBranch reads the default branch from the Bitbucket repository metadata
+func getBitbucketDefaultBranch(ctx context.Context, repoInfo *RepositoryInfo, token string) (string, error) {
+	if IsBitbucketCloud(repoInfo.BaseURL) {
+		body, err := BitbucketGet(ctx, bitbucketClient, BitbucketRepoAPI(repoInfo), token)
+		if err != nil {
+			return "", fmt.Errorf("failed to get repository: %w", err)
+		}
+		var repo struct {
+			MainBranch struct {
+				Name string `json:"name"`
+			} `json:"mainbranch"`
+		}
+		if err := json.Unmarshal(body, &repo); err != nil {
+			return "", fmt.Errorf("invalid repository response: %w", err)
+		}
+		return repo.MainBranch.Name, nil
+	}
+	body, err := BitbucketGet(ctx, bitbucketClient, BitbucketRepoAPI(repoInfo)+"/default-branch", token)
+	if err != nil {
+		return "", fmt.Errorf("failed to get default branch: %w", err)
+	}
+	var branch struct {
+		DisplayID string `json:"displayId"`
+	}
+	if err := json.Unmarshal(body, &branch); err != nil {
+		return "", fmt.Errorf("invalid default branch response: %w", err)
+	}
+	return branch.DisplayID, nil
+}
+
+// resolveBitbucketCommit returns the commit a branch, tag or SHA of a Bitbucket repository points to.
+func resolveBitbucketCommit(ctx context.Context, repoInfo *RepositoryInfo, token string) (string, error) {
+	cloud := IsBitbucketCloud(repoInfo.BaseURL)
+	apiURL := BitbucketRepoAPI(repoInfo) + "/commits/" + url.PathEscape(repoInfo.Ref)
+	if cloud {
+		apiURL = BitbucketRepoAPI(repoInfo) + "/commit/" + url.PathEscape(repoInfo.Ref)
+	}
+	body, err := BitbucketGet(ctx, bitbucketClient, apiURL, token)
+	if err != nil {
+		return "", err
+	}
+	var commit struct {
+		ID   string `json:"id"`   // Server
+		Hash string `json:"hash"` // Cloud
+	}
+	if err := json.Unmarshal(body, &commit); err != nil {
+		return "", fmt.Errorf("invalid commit response: %w", err)
+	}
+	if cloud {
+		return commit.Hash, nil
+	}
+	return commit.ID, nil
+}
+
+// listBitbucketBranchesAndTags returns the branch and tag names of a Bitbucket repository.
+func listBitbucketBranchesAndTags(ctx context.Context, repoInfo *RepositoryInfo, token string) ([]string, error) {
+	cloud := IsBitbucketCloud(repoInfo.BaseURL)
+	var names []string
+	for _, kind := range []string{"branches", "tags"} {
+		apiURL := BitbucketRepoAPI(repoInfo) + "/" + kind
+		if cloud {
+			apiURL = BitbucketRepoAPI(repoInfo) + "/refs/" + kind + "?pagelen=100"
+		}
+		err := BitbucketPages(ctx, bitbucketClient, apiURL, token, cloud, func(body []byte) error {
+			var page struct {
+				Values []struct {
+					Name      string `json:"name"`      // Cloud
+					DisplayID string `json:"displayId"` // Server
+				} `json:"values"`
+			}
+			if err := json.Unmarshal(body, &page); err != nil {
+				return fmt.Errorf("invalid %s response: %w", kind, err)
+			}
+			for _, v := range page.Values {
+				if cloud {
+					names = append(names, v.Name)
+				} else {
+					names = append(names, v.DisplayID)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			if kind == "tags" {
+				break // tags are optional, like on GitHub/GitLab
+			}
+			return nil, fmt.Errorf("failed to list branches: %w", err)
+		}
+	}
+	log.Printf("Found %d branches/tags for %s/%s", len(names), repoInfo.Owner, repoInfo.Repo)
+	return names, nil
+}
+
+// isBitbucketServerPath reports whether a URL path has the Bitbucket Server repository
+// layout (projects/KEY/repos/repo or users/name/repos/repo).
+func isBitbucketServerPath(path string) bool {
+	parts := strings.Split(path, "/")
+	return len(parts) >= 4 && (parts[0] == "projects" || parts[0] == "users") && parts[2] == "repos"
+}
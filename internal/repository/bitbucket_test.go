@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDetectRepository_Bitbucket(t *testing.T) {
+	cases := []struct {
+		name      string
+		repoURL   string
+		owner     string
+		repo      string
+		path      string
+		ref       string
+		ambiguous string
+		floating  bool
+	}{
+		{
+			name:    "server browse with at",
+			repoURL: "https://bitbucket.example.com/projects/PRJ/repos/infra/browse/deploy/overlay?at=refs/heads/release/1.0",
+			owner:   "PRJ",
+			repo:    "infra",
+			path:    "deploy/overlay",
+			ref:     "release/1.0",
+		},
+		{
+			name:     "server repository root",
+			repoURL:  "https://bitbucket.example.com/projects/PRJ/repos/infra",
+			owner:    "PRJ",
+			repo:     "infra",
+			ref:      "main",
+			floating: true,
+		},
+		{
+			name:     "server personal repository",
+			repoURL:  "https://bitbucket.example.com/users/jdoe/repos/sandbox/browse",
+			owner:    "~jdoe",
+			repo:     "sandbox",
+			ref:      "main",
+			floating: true,
+		},
+		{
+			name:     "server clone URL",
+			repoURL:  "https://bitbucket.example.com/scm/prj/infra.git",
+			owner:    "prj",
+			repo:     "infra",
+			ref:      "main",
+			floating: true,
+		},
+		{
+			name:      "cloud src",
+			repoURL:   "https://bitbucket.org/team/infra/src/main/deploy/base",
+			owner:     "team",
+			repo:      "infra",
+			ref:       "main",
+			ambiguous: "main/deploy/base",
+		},
+		{
+			name:     "cloud repository root",
+			repoURL:  "https://bitbucket.org/team/infra.git",
+			owner:    "team",
+			repo:     "infra",
+			ref:      "main",
+			floating: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			info, err := DetectRepository(context.Background(), c.repoURL, "")
+			if err != nil {
+				t.Fatalf("DetectRepository error: %v", err)
+			}
+			if info.Type != Bitbucket {
+				t.Errorf("Type = %s, want bitbucket", info.Type)
+			}
+			if info.Owner != c.owner || info.Repo != c.repo {
+				t.Errorf("Owner/Repo = %s/%s, want %s/%s", info.Owner, info.Repo, c.owner, c.repo)
+			}
+			if info.Path != c.path {
+				t.Errorf("Path = %q, want %q", info.Path, c.path)
+			}
+			if info.Ref != c.ref {
+				t.Errorf("Ref = %q, want %q", info.Ref, c.ref)
+			}
+			if info.AmbiguousPath != c.ambiguous {
+				t.Errorf("AmbiguousPath = %q, want %q", info.AmbiguousPath, c.ambiguous)
+			}
+			if info.FloatingRef != c.floating {
+				t.Errorf("FloatingRef = %v, want %v", info.FloatingRef, c.floating)
+			}
+		})
+	}
+}
+
+// bitbucketServerStub serves the Bitbucket Server endpoints used by the resolver.
+func bitbucketServerStub(t *testing.T) *httptest.Server {
+	t.Helper()
+	const repoAPI = "/rest/api/1.0/projects/PRJ/repos/infra"
+	mux := http.NewServeMux()
+	mux.HandleFunc(repoAPI+"/branches", func(w http.ResponseWriter, r *http.Request) {
+		// Two pages to exercise start/nextPageStart paging
+		if r.URL.Query().Get("start") == "0" {
+			w.Write([]byte(`{"values":[{"displayId":"main"}],"isLastPage":false,"nextPageStart":1}`))
+			return
+		}
+		w.Write([]byte(`{"values":[{"displayId":"feature/x"}],"isLastPage":true}`))
+	})
+	mux.HandleFunc(repoAPI+"/tags", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"values":[{"displayId":"v1.0"}],"isLastPage":true}`))
+	})
+	mux.HandleFunc(repoAPI+"/default-branch", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"id":"refs/heads/develop","displayId":"develop"}`))
+	})
+	mux.HandleFunc(repoAPI+"/commits/develop", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"0123456789abcdef0123456789abcdef01234567"}`))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestBitbucketServer_Resolve(t *testing.T) {
+	srv := bitbucketServerStub(t)
+	info := &RepositoryInfo{Type: Bitbucket, Owner: "PRJ", Repo: "infra", Ref: "main", BaseURL: srv.URL, FloatingRef: true}
+
+	branch, path, err := ResolveBranchAndPath(context.Background(), info, "feature/x/deploy/overlay", "")
+	if err != nil {
+		t.Fatalf("ResolveBranchAndPath: %v", err)
+	}
+	if branch != "feature/x" || path != "deploy/overlay" {
+		t.Errorf("ResolveBranchAndPath = %q, %q; want feature/x, deploy/overlay", branch, path)
+	}
+
+	if err := ResolveDefaultBranch(context.Background(), info, "secret"); err != nil {
+		t.Fatalf("ResolveDefaultBranch: %v", err)
+	}
+	if info.Ref != "develop" {
+		t.Errorf("Ref = %q, want develop", info.Ref)
+	}
+
+	sha, err := ResolveCommitSHA(context.Background(), info, "")
+	if err != nil {
+		t.Fatalf("ResolveCommitSHA: %v", err)
+	}
+	if sha != "0123456789abcdef0123456789abcdef01234567" {
+		t.Errorf("ResolveCommitSHA = %q", sha)
+	}
+}
+
+func TestBitbucketCloud_Resolve(t *testing.T) {
+	var srv *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/team/infra", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"mainbranch":{"name":"trunk"}}`))
+	})
+	mux.HandleFunc("/repositories/team/infra/refs/branches", func(w http.ResponseWriter, r *http.Request) {
+		// Two pages to exercise "next" links
+		if r.URL.Query().Get("page") == "" {
+			w.Write([]byte(`{"values":[{"name":"trunk"}],"next":"` + srv.URL + `/repositories/team/infra/refs/branches?page=2"}`))
+			return
+		}
+		w.Write([]byte(`{"values":[{"name":"release"}]}`))
+	})
+	mux.HandleFunc("/repositories/team/infra/refs/tags", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	mux.HandleFunc("/repositories/team/infra/commit/trunk", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"hash":"89abcdef0123456789abcdef0123456789abcdef"}`))
+	})
+	srv = httptest.NewServer(mux)
+	defer srv.Close()
+
+	saved := bitbucketCloudAPI
+	bitbucketCloudAPI = srv.URL
+	defer func() { bitbucketCloudAPI = saved }()
+
+	info := &RepositoryInfo{Type: Bitbucket, Owner: "team", Repo: "infra", Ref: "main", BaseURL: "https://bitbucket.org", FloatingRef: true}
+
+	branch, path, err := ResolveBranchAndPath(context.Background(), info, "release/deploy", "")
+	if err != nil {
+		t.Fatalf("ResolveBranchAndPath: %v", err)
+	}
+	if branch != "release" || path != "deploy" {
+		t.Errorf("ResolveBranchAndPath = %q, %q; want release, deploy", branch, path)
+	}
+
+	if err := ResolveDefaultBranch(context.Background(), info, ""); err != nil {
+		t.Fatalf("ResolveDefaultBranch: %v", err)
+	}
+	if info.Ref != "trunk" {
+		t.Errorf("Ref = %q, want trunk", info.Ref)
+	}
+
+	sha, err := ResolveCommitSHA(context.Background(), info, "")
+	if err != nil {
+		t.Fatalf("ResolveCommitSHA: %v", err)
+	}
+	if sha != "89abcdef0123456789abcdef0123456789abcdef" {
+		t.Errorf("ResolveCommitSHA = %q", sha)
+	}
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -13,10 +14,14 @@ import (
 type RepositoryType string
 
 const (
-	GitHub  RepositoryType = "github"
-	GitLab  RepositoryType = "gitlab"
-	Local   RepositoryType = "local"
-	Unknown RepositoryType = "unknown"
+	GitHub      RepositoryType = "github"
+	GitLab      RepositoryType = "gitlab"
+	Bitbucket   RepositoryType = "bitbucket"
+	Gitea       RepositoryType = "gitea"
+	AzureDevOps RepositoryType = "azure"
+	Local       RepositoryType = "local"
+	Git         RepositoryType = "git"
+	Unknown     RepositoryType = "unknown"
 )
 
 type RepositoryInfo struct {
@@ -28,12 +33,20 @@ type RepositoryInfo struct {
 	Path          string
 	AmbiguousPath string
 
+	// FloatingRef is true when the URL did not name a branch or tag: Ref is the
+	// repository's default branch ("main" until ResolveDefaultBranch looks it up).
+	FloatingRef bool
+
 	// RootPath is the absolute path to the repository root. Used only when Type == Local.
 	RootPath string
+
+	// CloneURL is the HTTPS or SSH URL the repository is cloned from. Used only when Type == Git.
+	CloneURL string
 }
 
-// DetectRepository parses the URL and determines the repository type
-func DetectRepository(repoURL string, token string) (*RepositoryInfo, error) {
+// DetectRepository parses the URL and determines the repository type. Hosts that cannot be
+// told apart by their URL are probed, until ctx is done.
+func DetectRepository(ctx context.Context, repoURL string, token string) (*RepositoryInfo, error) {
 	parsedURL, err := url.Parse(repoURL)
 	if err != nil {
 		return nil, fmt.Errorf("invalid URL: %w", err)
@@ -54,26 +67,50 @@ func DetectRepository(repoURL string, token string) (*RepositoryInfo, error) {
 		return parseGitLabURL(path, baseURL)
 	}
 
+	// Bitbucket Cloud, or Bitbucket Server by hostname or URL structure
+	if strings.Contains(host, "bitbucket") || isBitbucketServerPath(path) {
+		log.Printf("Detected Bitbucket from hostname or URL structure")
+		return parseBitbucketURL(path, parsedURL.Query().Get("at"), baseURL)
+	}
+
+	// Azure DevOps - detect by hostname
+	if IsAzureDevOpsHost(host) {
+		log.Printf("Detected Azure DevOps from hostname")
+		return parseAzureDevOpsURL(host, path, parsedURL.Query())
+	}
+
+	// Gitea/Forgejo - detect by hostname (codeberg.org runs Forgejo)
+	if strings.Contains(host, "gitea") || strings.Contains(host, "forgejo") || host == "codeberg.org" {
+		log.Printf("Detected Gitea from hostname")
+		return parseGiteaURL(path, baseURL)
+	}
+
 	// For ambiguous cases, try probing with token
-	repoType := probeRepositoryType(baseURL, token)
+	repoType := probeRepositoryType(ctx, baseURL, token)
 
 	switch repoType {
 	case GitLab:
 		return parseGitLabURL(path, baseURL)
 	case GitHub:
 		return parseGitHubURL(path, baseURL)
+	case Bitbucket:
+		return parseBitbucketURL(path, parsedURL.Query().Get("at"), baseURL)
+	case Gitea:
+		return parseGiteaURL(path, baseURL)
 	default:
-		return nil, fmt.Errorf("unable to detect repository type for: %s", host)
+		// No known forge API: fall back to plain git over HTTPS
+		log.Printf("No forge API detected on %s, using plain git", host)
+		return NewGitRepository(repoURL)
 	}
 }
 
 // isGitLabInstance checks if the URL is a GitLab instance
-func isGitLabInstance(baseURL, token string) bool {
+func isGitLabInstance(ctx context.Context, baseURL, token string) bool {
 	client := &http.Client{
 		Timeout: 10 * time.Second,
 	}
 
-	req, err := http.NewRequest("GET", baseURL+"/api/v4/version", nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", baseURL+"/api/v4/version", nil)
 	if err != nil {
 		return false
 	}
@@ -108,13 +145,13 @@ func isGitLabInstance(baseURL, token string) bool {
 }
 
 // isGitHubInstance checks if the URL is a GitHub instance
-func isGitHubInstance(baseURL, token string) bool {
+func isGitHubInstance(ctx context.Context, baseURL, token string) bool {
 	client := &http.Client{
 		Timeout: 10 * time.Second,
 	}
 
 	// Try GitHub API
-	req, err := http.NewRequest("GET", baseURL+"/api/v3", nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", baseURL+"/api/v3", nil)
 	if err != nil {
 		return false
 	}
@@ -134,17 +171,27 @@ func isGitHubInstance(baseURL, token string) bool {
 }
 
 // probeRepositoryType attempts to detect the repository type by probing APIs
-func probeRepositoryType(baseURL, token string) RepositoryType {
+func probeRepositoryType(ctx context.Context, baseURL, token string) RepositoryType {
 	// Try GitLab first
-	if isGitLabInstance(baseURL, token) {
+	if isGitLabInstance(ctx, baseURL, token) {
 		return GitLab
 	}
 
 	// Try GitHub
-	if isGitHubInstance(baseURL, token) {
+	if isGitHubInstance(ctx, baseURL, token) {
 		return GitHub
 	}
 
+	// Try Bitbucket Server
+	if isBitbucketServerInstance(ctx, baseURL, token) {
+		return Bitbucket
+	}
+
+	// Try Gitea/Forgejo
+	if isGiteaInstance(ctx, baseURL, token) {
+		return Gitea
+	}
+
 	return Unknown
 }
 
@@ -163,12 +210,14 @@ func parseGitHubURL(path, baseURL string) (*RepositoryInfo, error) {
 		BaseURL:       baseURL,
 		Path:          "",
 		AmbiguousPath: "",
+		FloatingRef:   true,
 	}
 
 	// Handle /tree/branch/path or /blob/branch/path URLs
 	if len(parts) >= 4 && (parts[2] == "tree" || parts[2] == "blob") {
 		// Everything after "tree" is ambiguous (branch + path mixed)
 		info.AmbiguousPath = strings.Join(parts[3:], "/")
+		info.FloatingRef = false
 	}
 
 	return info, nil
@@ -229,7 +278,8 @@ func parseGitLabURL(path, baseURL string) (*RepositoryInfo, error) {
 		Ref:           "main", // Will be resolved later
 		BaseURL:       baseURL,
 		Path:          "",
-		AmbiguousPath: ambiguousPath, // Store for later resolution
+		AmbiguousPath: ambiguousPath,       // Store for later resolution
+		FloatingRef:   ambiguousPath == "", // No branch in the URL: default branch
 	}
 
 	return info, nil
@@ -241,3 +291,14 @@ func (r *RepositoryInfo) String() string {
 	}
 	return fmt.Sprintf("%s:%s/%s@%s", r.Type, r.Owner, r.Repo, r.Ref)
 }
+
+// Host returns the host name of a remote repository (from BaseURL), or "" for local ones.
+func (r *RepositoryInfo) Host() string {
+	if r.Type == Local {
+		return ""
+	}
+	if u, err := url.Parse(r.BaseURL); err == nil {
+		return u.Hostname()
+	}
+	return ""
+}
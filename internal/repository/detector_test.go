@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"testing"
 )
 
@@ -54,7 +55,7 @@ func TestDetectRepository_GitHub(t *testing.T) {
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			info, err := DetectRepository(c.repoURL, "")
+			info, err := DetectRepository(context.Background(), c.repoURL, "")
 			if err != nil {
 				t.Fatalf("DetectRepository error: %v", err)
 			}
@@ -124,7 +125,7 @@ func TestDetectRepository_GitLab(t *testing.T) {
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			info, err := DetectRepository(c.repoURL, "")
+			info, err := DetectRepository(context.Background(), c.repoURL, "")
 			if err != nil {
 				t.Fatalf("DetectRepository error: %v", err)
 			}
@@ -222,7 +223,7 @@ func TestParseGitLabURL(t *testing.T) {
 }
 
 func TestDetectRepository_InvalidURL(t *testing.T) {
-	_, err := DetectRepository("://invalid", "")
+	_, err := DetectRepository(context.Background(), "://invalid", "")
 	if err == nil {
 		t.Fatal("expected error for invalid URL")
 	}
@@ -265,7 +266,7 @@ func TestDetectRepository_RealURLs(t *testing.T) {
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			info, err := DetectRepository(c.repoURL, "")
+			info, err := DetectRepository(context.Background(), c.repoURL, "")
 			if err != nil {
 				t.Fatalf("DetectRepository error: %v", err)
 			}
@@ -284,3 +285,76 @@ func TestDetectRepository_RealURLs(t *testing.T) {
 		})
 	}
 }
+
+func TestNewGitRepository(t *testing.T) {
+	cases := []struct {
+		name     string
+		cloneURL string
+		owner    string
+		repo     string
+		path     string
+		baseURL  string
+		wantURL  string
+	}{
+		{
+			name:     "https without .git",
+			cloneURL: "https://git.example.com/org/repo",
+			owner:    "org",
+			repo:     "repo",
+			baseURL:  "https://git.example.com",
+			wantURL:  "https://git.example.com/org/repo",
+		},
+		{
+			name:     "https nested groups with path after .git",
+			cloneURL: "https://git.example.com/team/sub/repo.git/deploy/base",
+			owner:    "team/sub",
+			repo:     "repo",
+			path:     "deploy/base",
+			baseURL:  "https://git.example.com",
+			wantURL:  "https://git.example.com/team/sub/repo.git",
+		},
+		{
+			name:     "scp-like ssh",
+			cloneURL: "git@git.example.com:org/repo.git",
+			owner:    "org",
+			repo:     "repo",
+			baseURL:  "https://git.example.com",
+			wantURL:  "git@git.example.com:org/repo.git",
+		},
+		{
+			name:     "ssh scheme",
+			cloneURL: "ssh://git@git.example.com:2222/org/repo.git",
+			owner:    "org",
+			repo:     "repo",
+			baseURL:  "https://git.example.com",
+			wantURL:  "ssh://git@git.example.com:2222/org/repo.git",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			info, err := NewGitRepository(c.cloneURL)
+			if err != nil {
+				t.Fatalf("NewGitRepository error: %v", err)
+			}
+			if info.Type != Git {
+				t.Errorf("Type = %s, want git", info.Type)
+			}
+			if info.Owner != c.owner || info.Repo != c.repo || info.Path != c.path {
+				t.Errorf("got %s/%s path %q, want %s/%s path %q", info.Owner, info.Repo, info.Path, c.owner, c.repo, c.path)
+			}
+			if info.BaseURL != c.baseURL {
+				t.Errorf("BaseURL = %q, want %q", info.BaseURL, c.baseURL)
+			}
+			if got := GitCloneURL(info); got != c.wantURL {
+				t.Errorf("GitCloneURL = %q, want %q", got, c.wantURL)
+			}
+			if !info.FloatingRef {
+				t.Error("FloatingRef = false, want true")
+			}
+		})
+	}
+
+	if _, err := NewGitRepository("https://git.example.com/repo"); err == nil {
+		t.Error("NewGitRepository with a single path segment should error")
+	}
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// scpLikeURL matches SSH remotes in scp syntax: [user@]host:org/repo.git
+var scpLikeURL = regexp.MustCompile(`^(?:[\w.-]+@)?([\w.-]+):(.+)$`)
+
+// commitHash matches a full git commit SHA (SHA-1 or SHA-256).
+var commitHash = regexp.MustCompile(`^([0-9a-f]{40}|[0-9a-f]{64})$`)
+
+// NewGitRepository returns the RepositoryInfo of a plain git remote on any host, from its
+// HTTPS or SSH clone URL (https://host/org/repo.git, ssh://git@host/org/repo.git or
+// git@host:org/repo.git). Without a ".git" suffix the whole URL path is the repository;
+// with one, whatever follows it is stored in Path. BaseURL is always https://host.
+func NewGitRepository(cloneURL string) (*RepositoryInfo, error) {
+	var host, repoPath string
+	if m := scpLikeURL.FindStringSubmatch(cloneURL); m != nil && !strings.Contains(cloneURL, "://") {
+		host, repoPath = m[1], m[2]
+	} else {
+		u, err := url.Parse(cloneURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid git URL: %w", err)
+		}
+		if u.Host == "" {
+			return nil, fmt.Errorf("invalid git URL: missing host: %s", cloneURL)
+		}
+		host, repoPath = u.Hostname(), u.Path
+	}
+
+	parts := strings.Split(strings.Trim(repoPath, "/"), "/")
+	end := len(parts)
+	for i, part := range parts {
+		if strings.HasSuffix(part, ".git") {
+			end = i + 1
+			break
+		}
+	}
+	if end < 2 {
+		return nil, fmt.Errorf("invalid git repository path: %s", repoPath)
+	}
+
+	repoParts := parts[:end]
+	if i := strings.Index(cloneURL, strings.Join(repoParts, "/")); i >= 0 {
+		cloneURL = cloneURL[:i+len(strings.Join(repoParts, "/"))]
+	}
+	return &RepositoryInfo{
+		Type:        Git,
+		Owner:       strings.Join(repoParts[:end-1], "/"),
+		Repo:        strings.TrimSuffix(repoParts[end-1], ".git"),
+		Ref:         "main",
+		BaseURL:     "https://" + host,
+		Path:        strings.Join(parts[end:], "/"),
+		CloneURL:    cloneURL,
+		FloatingRef: true,
+	}, nil
+}
+
+// GitCloneURL returns the URL to clone a repository from: CloneURL for plain git remotes,
+// otherwise the HTTPS URL derived from BaseURL, Owner and Repo.
+func GitCloneURL(info *RepositoryInfo) string {
+	if info.CloneURL != "" {
+		return info.CloneURL
+	}
+	return fmt.Sprintf("%s/%s/%s.git", strings.TrimSuffix(info.BaseURL, "/"), info.Owner, info.Repo)
+}
+
+// GitAuth returns the auth method for a git remote: the token as HTTP basic auth password
+// for HTTPS remotes; nil otherwise (SSH remotes use the SSH agent).
+func GitAuth(cloneURL, token string) transport.AuthMethod {
+	if token == "" || !strings.HasPrefix(cloneURL, "http") {
+		return nil
+	}
+	return &githttp.BasicAuth{Username: "git", Password: token}
+}
+
+// IsCommitHash reports whether ref is a full commit SHA.
+func IsCommitHash(ref string) bool {
+	return commitHash.MatchString(ref)
+}
+
+// peeledSuffix ends the names of the peeled entries listGitRefs appends for annotated tags:
+// refs/tags/X^{} is the commit of tag X, refs/tags/X the tag object.
+const peeledSuffix = "^{}"
+
+// listGitRefs lists the references advertised by a git remote (like git ls-remote),
+// including the peeled entries of annotated tags.
+func listGitRefs(ctx context.Context, info *RepositoryInfo, token string) ([]*plumbing.Reference, error) {
+	cloneURL := GitCloneURL(info)
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{Name: "origin", URLs: []string{cloneURL}})
+	refs, err := remote.ListContext(ctx, &git.ListOptions{Auth: GitAuth(cloneURL, token), PeelingOption: git.AppendPeeled})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list refs of %s: %w", cloneURL, err)
+	}
+	return refs, nil
+}
+
+// getGitDefaultBranch returns the branch the remote HEAD points to.
+func getGitDefaultBranch(ctx context.Context, info *RepositoryInfo, token string) (string, error) {
+	refs, err := listGitRefs(ctx, info, token)
+	if err != nil {
+		return "", err
+	}
+	for _, ref := range refs {
+		if ref.Name() == plumbing.HEAD && ref.Type() == plumbing.SymbolicReference {
+			return ref.Target().Short(), nil
+		}
+	}
+	return "", fmt.Errorf("remote HEAD not advertised")
+}
+
+// resolveGitCommit returns the commit a branch or tag of a git remote points to (the
+// tagged commit for annotated tags, not the tag object). Commit
+// SHAs are returned as is, once the remote has been listed: callers rely on the lookup to
+// check access (git cannot look up a single commit without fetching it).
+func resolveGitCommit(ctx context.Context, info *RepositoryInfo, token string) (string, error) {
+	refs, err := listGitRefs(ctx, info, token)
+	if err != nil {
+		return "", err
+	}
+	if IsCommitHash(info.Ref) {
+		return info.Ref, nil
+	}
+	tag := plumbing.NewTagReferenceName(info.Ref)
+	for _, name := range []plumbing.ReferenceName{
+		plumbing.NewBranchReferenceName(info.Ref),
+		tag + peeledSuffix,
+		tag,
+	} {
+		for _, ref := range refs {
+			if ref.Name() == name {
+				return ref.Hash().String(), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no branch or tag %s", info.Ref)
+}
+
+// listGitBranchesAndTags returns the branch and tag names of a git remote.
+func listGitBranchesAndTags(ctx context.Context, info *RepositoryInfo, token string) ([]string, error) {
+	refs, err := listGitRefs(ctx, info, token)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, ref := range refs {
+		if strings.HasSuffix(ref.Name().String(), peeledSuffix) {
+			continue
+		}
+		if ref.Name().IsBranch() || ref.Name().IsTag() {
+			names = append(names, ref.Name().Short())
+		}
+	}
+	return names, nil
+}
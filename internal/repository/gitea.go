@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// giteaClient is the HTTP client used for Gitea/Forgejo REST API calls.
+var giteaClient = &http.Client{Timeout: 30 * time.Second}
+
+// giteaPageSize is the page size requested from paged Gitea API listings.
+const giteaPageSize = 50
+
+// GiteaRepoAPI returns the REST API URL of a Gitea/Forgejo repository: {base}/api/v1/repos/{owner}/{repo}.
+func GiteaRepoAPI(info *RepositoryInfo) string {
+	return fmt.Sprintf("%s/api/v1/repos/%s/%s",
+		strings.TrimSuffix(info.BaseURL, "/"), url.PathEscape(info.Owner), url.PathEscape(info.Repo))
+}
+
+// SetGiteaAuth adds the access token to a Gitea/Forgejo API request.
+func SetGiteaAuth(req *http.Request, token string) {
+	if token != "" {
+		req.Header.Set("Authorization", "token "+token)
+	}
+}
+
+// GiteaGet performs an authenticated GET on the Gitea/Forgejo API and returns the body.
+// Non-200 responses are returned as errors.
+func GiteaGet(ctx context.Context, client *http.Client, apiURL, token string) ([]byte, error) {
+	return apiGet(ctx, client, apiURL, func(req *http.Request) { SetGiteaAuth(req, token) })
+}
+
+// isGiteaInstance checks if the URL is a Gitea or Forgejo instance
+func isGiteaInstance(ctx context.Context, baseURL, token string) bool {
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+	body, err := GiteaGet(ctx, client, baseURL+"/api/v1/version", token)
+	if err != nil {
+		log.Printf("Failed to probe Gitea API: %v", err)
+		return false
+	}
+	var version struct {
+		Version string `json:"version"`
+	}
+	return json.Unmarshal(body, &version) == nil && version.Version != ""
+}
+
+// parseGiteaURL extracts owner/repo/path from a Gitea/Forgejo URL.
+// Browse URLs are owner/repo/src/{branch,tag,commit}/ref/path (or the older
+// owner/repo/src/ref/path); ref and path are mixed and stored in AmbiguousPath.
+func parseGiteaURL(path, baseURL string) (*RepositoryInfo, error) {
+	parts := strings.Split(path, "/")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("invalid Gitea repository path: %s", path)
+	}
+
+	info := &RepositoryInfo{
+		Type:        Gitea,
+		Owner:       parts[0],
+		Repo:        strings.TrimSuffix(parts[1], ".git"),
+		Ref:         "main",
+		BaseURL:     baseURL,
+		FloatingRef: true,
+	}
+
+	// Handle /src/branch/name/path, /src/tag/name/path and /src/commit/sha/path URLs
+	if len(parts) >= 4 && (parts[2] == "src" || parts[2] == "raw") {
+		rest := parts[3:]
+		if len(rest) >= 2 && (rest[0] == "branch" || rest[0] == "tag" || rest[0] == "commit") {
+			rest = rest[1:]
+		}
+		info.AmbiguousPath = strings.Join(rest, "/")
+		info.FloatingRef = false
+	}
+
+	return info, nil
+}
+
+// giteaPages calls visit with each page of a paged Gitea API listing until a page
+// holds fewer than giteaPageSize entries (visit returns the entry count).
+func giteaPages(ctx context.Context, apiURL, token string, visit func(page []byte) (int, error)) error {
+	sep := "?"
+	if strings.Contains(apiURL, "?") {
+		sep = "&"
+	}
+	for page := 1; ; page++ {
+		body, err := GiteaGet(ctx, giteaClient, fmt.Sprintf("%s%spage=%d&limit=%d", apiURL, sep, page, giteaPageSize), token)
+		if err != nil {
+			return err
+		}
+		n, err := visit(body)
+		if err != nil {
+			return err
+		}
+		if n < giteaPageSize {
+			return nil
+		}
+	}
+}
+
+// getGiteaDefaultBranch reads the default branch from the Gitea repository metadata
+func getGiteaDefaultBranch(ctx context.Context, repoInfo *RepositoryInfo, token string) (string, error) {
+	body, err := GiteaGet(ctx, giteaClient, GiteaRepoAPI(repoInfo), token)
+	if err != nil {
+		return "", fmt.Errorf("failed to get repository: %w", err)
+	}
+	var repo struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	if err := json.Unmarshal(body, &repo); err != nil {
+		return "", fmt.Errorf("invalid repository response: %w", err)
+	}
+	return repo.DefaultBranch, nil
+}
+
+// resolveGiteaCommit returns the commit a branch, tag or SHA of a Gitea repository points to.
+func resolveGiteaCommit(ctx context.Context, repoInfo *RepositoryInfo, token string) (string, error) {
+	apiURL := fmt.Sprintf("%s/commits?sha=%s&limit=1&stat=false&verification=false&files=false",
+		GiteaRepoAPI(repoInfo), url.QueryEscape(repoInfo.Ref))
+	body, err := GiteaGet(ctx, giteaClient, apiURL, token)
+	if err != nil {
+		return "", err
+	}
+	var commits []struct {
+		SHA string `json:"sha"`
+	}
+	if err := json.Unmarshal(body, &commits); err != nil {
+		return "", fmt.Errorf("invalid commits response: %w", err)
+	}
+	if len(commits) == 0 {
+		return "", nil
+	}
+	return commits[0].SHA, nil
+}
+
+// listGiteaBranchesAndTags returns the branch and tag names of a Gitea repository.
+func listGiteaBranchesAndTags(ctx context.Context, repoInfo *RepositoryInfo, token string) ([]string, error) {
+	var names []string
+	for _, kind := range []string{"branches", "tags"} {
+		err := giteaPages(ctx, GiteaRepoAPI(repoInfo)+"/"+kind, token, func(body []byte) (int, error) {
+			var refs []struct {
+				Name string `json:"name"`
+			}
+			if err := json.Unmarshal(body, &refs); err != nil {
+				return 0, fmt.Errorf("invalid %s response: %w", kind, err)
+			}
+			for _, ref := range refs {
+				names = append(names, ref.Name)
+			}
+			return len(refs), nil
+		})
+		if err != nil {
+			if kind == "tags" {
+				break // tags are optional, like on GitHub/GitLab
+			}
+			return nil, fmt.Errorf("failed to list branches: %w", err)
+		}
+	}
+	log.Printf("Found %d branches/tags for %s/%s", len(names), repoInfo.Owner, repoInfo.Repo)
+	return names, nil
+}
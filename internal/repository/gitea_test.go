@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDetectRepository_GiteaByHostname(t *testing.T) {
+	cases := []struct {
+		name      string
+		repoURL   string
+		ambiguous string
+		floating  bool
+	}{
+		{"repository root", "https://codeberg.org/org/infra", "", true},
+		{"branch browse URL", "https://gitea.example.com/org/infra/src/branch/main/deploy/base", "main/deploy/base", false},
+		{"tag browse URL", "https://forgejo.example.com/org/infra.git/src/tag/v1.0/deploy", "v1.0/deploy", false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			info, err := DetectRepository(context.Background(), c.repoURL, "")
+			if err != nil {
+				t.Fatalf("DetectRepository error: %v", err)
+			}
+			if info.Type != Gitea {
+				t.Errorf("Type = %s, want gitea", info.Type)
+			}
+			if info.Owner != "org" || info.Repo != "infra" {
+				t.Errorf("Owner/Repo = %s/%s, want org/infra", info.Owner, info.Repo)
+			}
+			if info.AmbiguousPath != c.ambiguous {
+				t.Errorf("AmbiguousPath = %q, want %q", info.AmbiguousPath, c.ambiguous)
+			}
+			if info.FloatingRef != c.floating {
+				t.Errorf("FloatingRef = %v, want %v", info.FloatingRef, c.floating)
+			}
+		})
+	}
+}
+
+// giteaStub serves the Gitea/Forgejo endpoints used by detection and the resolver.
+func giteaStub(t *testing.T) *httptest.Server {
+	t.Helper()
+	const repoAPI = "/api/v1/repos/org/infra"
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/version", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"version":"7.0.0+gitea-1.21.0"}`))
+	})
+	mux.HandleFunc(repoAPI, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token secret" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"default_branch":"develop"}`))
+	})
+	mux.HandleFunc(repoAPI+"/branches", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"name":"develop"},{"name":"feature/x"}]`))
+	})
+	mux.HandleFunc(repoAPI+"/tags", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"name":"v1.0"}]`))
+	})
+	mux.HandleFunc(repoAPI+"/commits", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("sha") != "develop" {
+			w.Write([]byte(`[]`))
+			return
+		}
+		w.Write([]byte(`[{"sha":"0123456789abcdef0123456789abcdef01234567"}]`))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestDetectRepository_GiteaProbe(t *testing.T) {
+	srv := giteaStub(t)
+	info, err := DetectRepository(context.Background(), srv.URL+"/org/infra", "")
+	if err != nil {
+		t.Fatalf("DetectRepository error: %v", err)
+	}
+	if info.Type != Gitea {
+		t.Errorf("Type = %s, want gitea (detected from /api/v1/version)", info.Type)
+	}
+}
+
+func TestDetectRepository_ProbeStopsWhenContextDone(t *testing.T) {
+	srv := giteaStub(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	info, err := DetectRepository(ctx, srv.URL+"/org/infra", "")
+	if err != nil {
+		t.Fatalf("DetectRepository error: %v", err)
+	}
+	// The probes are not sent, so no forge API is found
+	if info.Type != Git {
+		t.Errorf("Type = %s, want git once ctx is done", info.Type)
+	}
+}
+
+func TestGitea_Resolve(t *testing.T) {
+	srv := giteaStub(t)
+	info := &RepositoryInfo{Type: Gitea, Owner: "org", Repo: "infra", Ref: "main", BaseURL: srv.URL, FloatingRef: true}
+
+	branch, path, err := ResolveBranchAndPath(context.Background(), info, "feature/x/deploy/overlay", "")
+	if err != nil {
+		t.Fatalf("ResolveBranchAndPath: %v", err)
+	}
+	if branch != "feature/x" || path != "deploy/overlay" {
+		t.Errorf("ResolveBranchAndPath = %q, %q; want feature/x, deploy/overlay", branch, path)
+	}
+
+	if err := ResolveDefaultBranch(context.Background(), info, "secret"); err != nil {
+		t.Fatalf("ResolveDefaultBranch: %v", err)
+	}
+	if info.Ref != "develop" {
+		t.Errorf("Ref = %q, want develop", info.Ref)
+	}
+
+	sha, err := ResolveCommitSHA(context.Background(), info, "")
+	if err != nil {
+		t.Fatalf("ResolveCommitSHA: %v", err)
+	}
+	if sha != "0123456789abcdef0123456789abcdef01234567" {
+		t.Errorf("ResolveCommitSHA = %q", sha)
+	}
+
+	info.Ref = "missing"
+	if _, err := ResolveCommitSHA(context.Background(), info, ""); err == nil {
+		t.Error("ResolveCommitSHA of an unknown ref should error")
+	}
+}
@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v82/github"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// maxRateLimitRetries is how many times a rate-limited request is retried.
+const maxRateLimitRetries = 3
+
+// maxRateLimitWait bounds how long a request waits for a rate limit to lift: when the
+// quota resets later than that, the request fails with a RateLimitError right away.
+// A variable so tests can shorten it.
+var maxRateLimitWait = 30 * time.Second
+
+// rateLimitBackoff is the first wait when a response gives no reset time (doubled on each retry).
+var rateLimitBackoff = time.Second
+
+// RateLimitError reports a request refused because the API quota of a host is exhausted.
+type RateLimitError struct {
+	Host  string
+	Reset time.Time // when the request may be retried
+	Err   error
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limited by %s until %s: %v", e.Host, e.Reset.Format(time.RFC3339), e.Err)
+}
+
+func (e *RateLimitError) Unwrap() error { return e.Err }
+
+// IsRateLimited reports whether err (or an error it wraps) is a RateLimitError.
+func IsRateLimited(err error) bool {
+	var rle *RateLimitError
+	return errors.As(err, &rle)
+}
+
+// RateLimit is the API quota of a host, as reported by its last response.
+type RateLimit struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"reset,omitempty"`
+}
+
+// RateLimits collects the last quota reported per host during one analysis: each analysis
+// records its own, so concurrent analyses (made with other tokens) do not see or
+// overwrite each other's quota.
+type RateLimits struct {
+	mu     sync.Mutex
+	byHost map[string]RateLimit
+}
+
+type rateLimitsKey struct{}
+
+// WithRateLimits returns a copy of ctx in which WithRateLimitRetry records the quotas it
+// sees, and the RateLimits they are recorded in.
+func WithRateLimits(ctx context.Context) (context.Context, *RateLimits) {
+	limits := &RateLimits{byHost: make(map[string]RateLimit)}
+	return context.WithValue(ctx, rateLimitsKey{}, limits), limits
+}
+
+// For returns the last quota reported by host, if any.
+func (r *RateLimits) For(host string) (RateLimit, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rl, ok := r.byHost[host]
+	return rl, ok
+}
+
+// All returns the last quota reported by each host, or nil when none was.
+func (r *RateLimits) All() map[string]RateLimit {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.byHost) == 0 {
+		return nil
+	}
+	all := make(map[string]RateLimit, len(r.byHost))
+	for host, rl := range r.byHost {
+		all[host] = rl
+	}
+	return all
+}
+
+// githubHTTPResponse returns the HTTP response of a go-github call (nil when there is none).
+func githubHTTPResponse(resp *github.Response) *http.Response {
+	if resp == nil {
+		return nil
+	}
+	return resp.Response
+}
+
+// gitlabHTTPResponse returns the HTTP response of a GitLab client call (nil when there is none).
+func gitlabHTTPResponse(resp *gitlab.Response) *http.Response {
+	if resp == nil {
+		return nil
+	}
+	return resp.Response
+}
+
+// headerInt returns the first integer header among names.
+func headerInt(h http.Header, names ...string) (int64, bool) {
+	for _, name := range names {
+		if v := h.Get(name); v != "" {
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return n, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// recordRateLimit stores the quota reported by resp (X-RateLimit-* headers for GitHub,
+// RateLimit-* headers for GitLab) in the RateLimits of ctx, if any.
+func recordRateLimit(ctx context.Context, host string, resp *http.Response) {
+	limits, _ := ctx.Value(rateLimitsKey{}).(*RateLimits)
+	if limits == nil || resp == nil {
+		return
+	}
+	remaining, ok := headerInt(resp.Header, "X-RateLimit-Remaining", "RateLimit-Remaining")
+	if !ok {
+		return
+	}
+	rl := RateLimit{Remaining: int(remaining)}
+	if limit, ok := headerInt(resp.Header, "X-RateLimit-Limit", "RateLimit-Limit"); ok {
+		rl.Limit = int(limit)
+	}
+	if reset, ok := headerInt(resp.Header, "X-RateLimit-Reset", "RateLimit-Reset"); ok {
+		rl.Reset = time.Unix(reset, 0)
+	}
+	limits.mu.Lock()
+	limits.byHost[host] = rl
+	limits.mu.Unlock()
+}
+
+// rateLimitWait reports whether a failed request was rate limited and how long to wait
+// before retrying: Retry-After, then the quota reset time, else an exponential backoff.
+func rateLimitWait(resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	backoff := rateLimitBackoff << attempt
+
+	// go-github reports rate limits as typed errors (also when it refuses a request
+	// locally because the quota is known to be exhausted)
+	var primary *github.RateLimitError
+	if errors.As(err, &primary) {
+		return untilReset(primary.Rate.Reset.Time, backoff), true
+	}
+	var secondary *github.AbuseRateLimitError
+	if errors.As(err, &secondary) {
+		if secondary.RetryAfter != nil {
+			return *secondary.RetryAfter, true
+		}
+		return backoff, true
+	}
+
+	if resp == nil {
+		return 0, false
+	}
+	remaining, hasRemaining := headerInt(resp.Header, "X-RateLimit-Remaining", "RateLimit-Remaining")
+	retryAfter := resp.Header.Get("Retry-After")
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+	case resp.StatusCode == http.StatusForbidden && (retryAfter != "" || hasRemaining && remaining == 0):
+	default:
+		return 0, false
+	}
+	if retryAfter != "" {
+		if secs, err := strconv.Atoi(retryAfter); err == nil {
+			return time.Duration(secs) * time.Second, true
+		}
+		if at, err := http.ParseTime(retryAfter); err == nil {
+			return untilReset(at, backoff), true
+		}
+	}
+	if reset, ok := headerInt(resp.Header, "X-RateLimit-Reset", "RateLimit-Reset"); ok {
+		return untilReset(time.Unix(reset, 0), backoff), true
+	}
+	return backoff, true
+}
+
+// untilReset returns the time left until reset, or backoff when reset is unknown or past.
+func untilReset(reset time.Time, backoff time.Duration) time.Duration {
+	if reset.IsZero() {
+		return backoff
+	}
+	if wait := time.Until(reset); wait > 0 {
+		return wait
+	}
+	return backoff
+}
+
+// WithRateLimitRetry runs call, a request to the API of host, recording the quota it reports
+// in the RateLimits of ctx (see WithRateLimits).
+// Rate-limited requests are retried after the wait the response asks for, up to
+// maxRateLimitRetries times and as long as the wait stays under maxRateLimitWait; past that
+// the error is returned as a *RateLimitError. Waiting stops when ctx is done.
+func WithRateLimitRetry(ctx context.Context, host string, call func() (*http.Response, error)) error {
+	for attempt := 0; ; attempt++ {
+		resp, err := call()
+		recordRateLimit(ctx, host, resp)
+		if err == nil {
+			return nil
+		}
+		wait, limited := rateLimitWait(resp, err, attempt)
+		if !limited {
+			return err
+		}
+		if attempt >= maxRateLimitRetries || wait > maxRateLimitWait {
+			return &RateLimitError{Host: host, Reset: time.Now().Add(wait).Truncate(time.Second), Err: err}
+		}
+		log.Printf("⏳ Rate limited by %s, retrying in %s (attempt %d/%d)", host, wait, attempt+1, maxRateLimitRetries)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v82/github"
+)
+
+// limitedResponse returns an HTTP response with the given status and headers.
+func limitedResponse(status int, headers map[string]string) *http.Response {
+	resp := &http.Response{StatusCode: status, Header: make(http.Header)}
+	for k, v := range headers {
+		resp.Header.Set(k, v)
+	}
+	return resp
+}
+
+func TestWithRateLimitRetry_RetriesAfterRetryAfter(t *testing.T) {
+	ctx, limits := WithRateLimits(context.Background())
+	calls := 0
+	err := WithRateLimitRetry(ctx, "gitlab.example.com", func() (*http.Response, error) {
+		calls++
+		if calls == 1 {
+			return limitedResponse(http.StatusTooManyRequests, map[string]string{"Retry-After": "0"}), errors.New("429 Too Many Requests")
+		}
+		return limitedResponse(http.StatusOK, map[string]string{
+			"RateLimit-Limit": "2000", "RateLimit-Remaining": "1999", "RateLimit-Reset": "1700000000",
+		}), nil
+	})
+	if err != nil {
+		t.Fatalf("WithRateLimitRetry: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+	rl, ok := limits.For("gitlab.example.com")
+	if !ok || rl.Limit != 2000 || rl.Remaining != 1999 || !rl.Reset.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("For = %+v, %v", rl, ok)
+	}
+}
+
+func TestWithRateLimitRetry_QuotaResetTooFar(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Unix()
+	ctx, limits := WithRateLimits(context.Background())
+	calls := 0
+	err := WithRateLimitRetry(ctx, "github.com", func() (*http.Response, error) {
+		calls++
+		return limitedResponse(http.StatusForbidden, map[string]string{
+			"X-RateLimit-Limit": "60", "X-RateLimit-Remaining": "0", "X-RateLimit-Reset": strconv.FormatInt(reset, 10),
+		}), errors.New("403 API rate limit exceeded")
+	})
+	var rle *RateLimitError
+	if !errors.As(err, &rle) {
+		t.Fatalf("err = %v, want a RateLimitError", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1 (reset is beyond the maximum wait)", calls)
+	}
+	if rle.Host != "github.com" || rle.Reset.Before(time.Now().Add(59*time.Minute)) {
+		t.Errorf("RateLimitError = %+v", rle)
+	}
+	if rl, _ := limits.For("github.com"); rl.Remaining != 0 || rl.Limit != 60 {
+		t.Errorf("For(github.com) = %+v", rl)
+	}
+}
+
+func TestWithRateLimits_PerContext(t *testing.T) {
+	quota := func(remaining string) func() (*http.Response, error) {
+		return func() (*http.Response, error) {
+			return limitedResponse(http.StatusOK, map[string]string{"X-RateLimit-Limit": "5000", "X-RateLimit-Remaining": remaining}), nil
+		}
+	}
+	ctxA, limitsA := WithRateLimits(context.Background())
+	ctxB, limitsB := WithRateLimits(context.Background())
+	if err := WithRateLimitRetry(ctxA, "github.com", quota("4999")); err != nil {
+		t.Fatalf("WithRateLimitRetry: %v", err)
+	}
+	if err := WithRateLimitRetry(ctxB, "github.com", quota("10")); err != nil {
+		t.Fatalf("WithRateLimitRetry: %v", err)
+	}
+	// Without RateLimits the quota is not recorded anywhere
+	if err := WithRateLimitRetry(context.Background(), "github.com", quota("0")); err != nil {
+		t.Fatalf("WithRateLimitRetry: %v", err)
+	}
+
+	if rl, _ := limitsA.For("github.com"); rl.Remaining != 4999 {
+		t.Errorf("first analysis: remaining = %d, want 4999", rl.Remaining)
+	}
+	if all := limitsB.All(); len(all) != 1 || all["github.com"].Remaining != 10 {
+		t.Errorf("second analysis: All = %+v", all)
+	}
+	if _, limits := WithRateLimits(context.Background()); limits.All() != nil {
+		t.Errorf("All = %+v, want nil when no quota was reported", limits.All())
+	}
+}
+
+func TestWithRateLimitRetry_GivesUpAfterRetries(t *testing.T) {
+	defer func(d time.Duration) { rateLimitBackoff = d }(rateLimitBackoff)
+	rateLimitBackoff = time.Millisecond
+
+	calls := 0
+	retryAfter := time.Duration(0)
+	err := WithRateLimitRetry(context.Background(), "github.com", func() (*http.Response, error) {
+		calls++
+		return nil, &github.AbuseRateLimitError{Message: "secondary rate limit", RetryAfter: &retryAfter}
+	})
+	if !IsRateLimited(err) {
+		t.Fatalf("err = %v, want rate limited", err)
+	}
+	if calls != maxRateLimitRetries+1 {
+		t.Errorf("calls = %d, want %d", calls, maxRateLimitRetries+1)
+	}
+}
+
+func TestWithRateLimitRetry_OtherErrors(t *testing.T) {
+	notFound := errors.New("404 Not Found")
+	calls := 0
+	err := WithRateLimitRetry(context.Background(), "github.com", func() (*http.Response, error) {
+		calls++
+		return limitedResponse(http.StatusNotFound, nil), notFound
+	})
+	if err != notFound || calls != 1 {
+		t.Errorf("err = %v after %d calls, want the original error after 1 call", err, calls)
+	}
+	// A 403 without rate limit headers is a permission error
+	err = WithRateLimitRetry(context.Background(), "github.com", func() (*http.Response, error) {
+		return limitedResponse(http.StatusForbidden, nil), errors.New("403 Forbidden")
+	})
+	if IsRateLimited(err) {
+		t.Errorf("403 without rate limit headers should not be rate limited: %v", err)
+	}
+}
+
+func TestWithRateLimitRetry_StopsWhenContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := WithRateLimitRetry(ctx, "github.com", func() (*http.Response, error) {
+		calls++
+		cancel()
+		return limitedResponse(http.StatusTooManyRequests, map[string]string{"Retry-After": "10"}), errors.New("429 Too Many Requests")
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestAPIGet_RetriesAndRecordsQuota(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("X-RateLimit-Limit", "1000")
+		w.Header().Set("X-RateLimit-Remaining", "998")
+		w.Write([]byte(`{"version":"1.21.0"}`))
+	}))
+	defer srv.Close()
+
+	ctx, limits := WithRateLimits(context.Background())
+	body, err := GiteaGet(ctx, srv.Client(), srv.URL+"/api/v1/version", "")
+	if err != nil {
+		t.Fatalf("GiteaGet: %v", err)
+	}
+	if string(body) != `{"version":"1.21.0"}` || calls != 2 {
+		t.Errorf("GiteaGet = %q after %d calls, want the version after a retry", body, calls)
+	}
+	if rl, ok := limits.For("127.0.0.1"); !ok || rl.Remaining != 998 {
+		t.Errorf("For(127.0.0.1) = %+v, %v", rl, ok)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"strings"
 
 	"github.com/google/go-github/v82/github"
@@ -26,9 +27,204 @@ func SetTestRefLister(l RefLister) {
 	testRefLister = l
 }
 
+// DefaultBranchGetter returns the default branch of a repository. Used for testing so
+// ResolveDefaultBranch can be tested without calling real GitHub/GitLab APIs.
+type DefaultBranchGetter interface {
+	GetDefaultBranch(repoInfo *RepositoryInfo, token string) (string, error)
+}
+
+// testDefaultBranchGetter is set by tests to mock default branch lookups. When non-nil,
+// ResolveDefaultBranch uses it instead of the real API clients.
+var testDefaultBranchGetter DefaultBranchGetter
+
+// SetTestDefaultBranchGetter sets the DefaultBranchGetter used by ResolveDefaultBranch.
+// Only for tests; call with nil to restore real API behavior.
+func SetTestDefaultBranchGetter(g DefaultBranchGetter) {
+	testDefaultBranchGetter = g
+}
+
+// ResolveDefaultBranch sets repoInfo.Ref to the repository's default branch when the URL
+// did not name a ref (repoInfo.FloatingRef). On error Ref is left unchanged ("main").
+func ResolveDefaultBranch(ctx context.Context, repoInfo *RepositoryInfo, token string) error {
+	if !repoInfo.FloatingRef {
+		return nil
+	}
+	var branch string
+	var err error
+	switch {
+	case testDefaultBranchGetter != nil:
+		branch, err = testDefaultBranchGetter.GetDefaultBranch(repoInfo, token)
+	case repoInfo.Type == GitHub:
+		branch, err = getGitHubDefaultBranch(ctx, repoInfo, token)
+	case repoInfo.Type == GitLab:
+		branch, err = getGitLabDefaultBranch(ctx, repoInfo, token)
+	case repoInfo.Type == Bitbucket:
+		branch, err = getBitbucketDefaultBranch(ctx, repoInfo, token)
+	case repoInfo.Type == Gitea:
+		branch, err = getGiteaDefaultBranch(ctx, repoInfo, token)
+	case repoInfo.Type == AzureDevOps:
+		branch, err = getAzureDevOpsDefaultBranch(ctx, repoInfo, token)
+	case repoInfo.Type == Git:
+		branch, err = getGitDefaultBranch(ctx, repoInfo, token)
+	default:
+		return fmt.Errorf("unsupported repository type: %s", repoInfo.Type)
+	}
+	if err != nil {
+		return err
+	}
+	if branch == "" {
+		return fmt.Errorf("no default branch reported for %s/%s", repoInfo.Owner, repoInfo.Repo)
+	}
+	log.Printf("Resolved default branch for %s/%s: %s", repoInfo.Owner, repoInfo.Repo, branch)
+	repoInfo.Ref = branch
+	return nil
+}
+
+// getGitHubDefaultBranch reads the default branch from the GitHub repository metadata
+func getGitHubDefaultBranch(ctx context.Context, repoInfo *RepositoryInfo, token string) (string, error) {
+	client := github.NewClient(nil)
+	if token != "" {
+		client = client.WithAuthToken(token)
+	}
+	var repo *github.Repository
+	err := WithRateLimitRetry(ctx, repoInfo.Host(), func() (*http.Response, error) {
+		var resp *github.Response
+		var err error
+		repo, resp, err = client.Repositories.Get(ctx, repoInfo.Owner, repoInfo.Repo)
+		return githubHTTPResponse(resp), err
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get repository: %w", err)
+	}
+	return repo.GetDefaultBranch(), nil
+}
+
+// getGitLabDefaultBranch reads the default branch from the GitLab project metadata
+func getGitLabDefaultBranch(ctx context.Context, repoInfo *RepositoryInfo, token string) (string, error) {
+	client, err := gitlab.NewClient(token, gitlab.WithBaseURL(repoInfo.BaseURL+"/api/v4"))
+	if err != nil {
+		return "", fmt.Errorf("failed to create GitLab client: %w", err)
+	}
+	projectID := fmt.Sprintf("%s/%s", repoInfo.Owner, repoInfo.Repo)
+	var project *gitlab.Project
+	err = WithRateLimitRetry(ctx, repoInfo.Host(), func() (*http.Response, error) {
+		var resp *gitlab.Response
+		var err error
+		project, resp, err = client.Projects.GetProject(projectID, nil, gitlab.WithContext(ctx))
+		return gitlabHTTPResponse(resp), err
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get project: %w", err)
+	}
+	if project == nil {
+		return "", fmt.Errorf("project %s not found", projectID)
+	}
+	return project.DefaultBranch, nil
+}
+
+// CommitResolver resolves a ref to a commit SHA. Used for testing so
+// ResolveCommitSHA can be tested without calling real GitHub/GitLab APIs.
+type CommitResolver interface {
+	ResolveCommit(repoInfo *RepositoryInfo, token string) (string, error)
+}
+
+// testCommitResolver is set by tests to mock commit lookups. When non-nil,
+// ResolveCommitSHA uses it instead of the real API clients.
+var testCommitResolver CommitResolver
+
+// SetTestCommitResolver sets the CommitResolver used by ResolveCommitSHA. Only for
+// tests; call with nil to restore real API behavior.
+func SetTestCommitResolver(r CommitResolver) {
+	testCommitResolver = r
+}
+
+// ResolveCommitSHA returns the commit SHA that repoInfo.Ref (branch, tag or SHA) currently points to.
+// SHAs are looked up too, so a successful call means token can read the repository.
+func ResolveCommitSHA(ctx context.Context, repoInfo *RepositoryInfo, token string) (string, error) {
+	if testCommitResolver != nil {
+		return testCommitResolver.ResolveCommit(repoInfo, token)
+	}
+	switch repoInfo.Type {
+	case GitHub:
+		client := github.NewClient(nil)
+		if token != "" {
+			client = client.WithAuthToken(token)
+		}
+		var sha string
+		err := WithRateLimitRetry(ctx, repoInfo.Host(), func() (*http.Response, error) {
+			var resp *github.Response
+			var err error
+			sha, resp, err = client.Repositories.GetCommitSHA1(ctx, repoInfo.Owner, repoInfo.Repo, repoInfo.Ref, "")
+			return githubHTTPResponse(resp), err
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve %s: %w", repoInfo.Ref, err)
+		}
+		if sha == "" {
+			return "", fmt.Errorf("no commit found for %s", repoInfo.Ref)
+		}
+		return sha, nil
+	case GitLab:
+		client, err := gitlab.NewClient(token, gitlab.WithBaseURL(repoInfo.BaseURL+"/api/v4"))
+		if err != nil {
+			return "", fmt.Errorf("failed to create GitLab client: %w", err)
+		}
+		projectID := fmt.Sprintf("%s/%s", repoInfo.Owner, repoInfo.Repo)
+		var commit *gitlab.Commit
+		err = WithRateLimitRetry(ctx, repoInfo.Host(), func() (*http.Response, error) {
+			var resp *gitlab.Response
+			var err error
+			commit, resp, err = client.Commits.GetCommit(projectID, repoInfo.Ref, nil, gitlab.WithContext(ctx))
+			return gitlabHTTPResponse(resp), err
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve %s: %w", repoInfo.Ref, err)
+		}
+		if commit == nil || commit.ID == "" {
+			return "", fmt.Errorf("no commit found for %s", repoInfo.Ref)
+		}
+		return commit.ID, nil
+	case Bitbucket:
+		sha, err := resolveBitbucketCommit(ctx, repoInfo, token)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve %s: %w", repoInfo.Ref, err)
+		}
+		if sha == "" {
+			return "", fmt.Errorf("no commit found for %s", repoInfo.Ref)
+		}
+		return sha, nil
+	case Gitea:
+		sha, err := resolveGiteaCommit(ctx, repoInfo, token)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve %s: %w", repoInfo.Ref, err)
+		}
+		if sha == "" {
+			return "", fmt.Errorf("no commit found for %s", repoInfo.Ref)
+		}
+		return sha, nil
+	case AzureDevOps:
+		sha, err := resolveAzureDevOpsCommit(ctx, repoInfo, token)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve %s: %w", repoInfo.Ref, err)
+		}
+		if sha == "" {
+			return "", fmt.Errorf("no commit found for %s", repoInfo.Ref)
+		}
+		return sha, nil
+	case Git:
+		sha, err := resolveGitCommit(ctx, repoInfo, token)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve %s: %w", repoInfo.Ref, err)
+		}
+		return sha, nil
+	default:
+		return "", fmt.Errorf("unsupported repository type: %s", repoInfo.Type)
+	}
+}
+
 // ResolveBranchAndPath resolves ambiguous URLs by listing branches
 // Returns: (branch/ref, path, error)
-func ResolveBranchAndPath(repoInfo *RepositoryInfo, urlPath string, token string) (string, string, error) {
+func ResolveBranchAndPath(ctx context.Context, repoInfo *RepositoryInfo, urlPath string, token string) (string, string, error) {
 	if testRefLister != nil {
 		branches, err := testRefLister.ListBranchesAndTags(repoInfo, token)
 		if err != nil {
@@ -38,17 +234,40 @@ func ResolveBranchAndPath(repoInfo *RepositoryInfo, urlPath string, token string
 	}
 	switch repoInfo.Type {
 	case GitHub:
-		return resolveGitHubBranchAndPath(repoInfo, urlPath, token)
+		return resolveGitHubBranchAndPath(ctx, repoInfo, urlPath, token)
 	case GitLab:
-		return resolveGitLabBranchAndPath(repoInfo, urlPath, token)
+		return resolveGitLabBranchAndPath(ctx, repoInfo, urlPath, token)
+	case Bitbucket:
+		refs, err := listBitbucketBranchesAndTags(ctx, repoInfo, token)
+		if err != nil {
+			return "", "", err
+		}
+		return findLongestMatch(refs, urlPath)
+	case Gitea:
+		refs, err := listGiteaBranchesAndTags(ctx, repoInfo, token)
+		if err != nil {
+			return "", "", err
+		}
+		return findLongestMatch(refs, urlPath)
+	case AzureDevOps:
+		refs, err := listAzureDevOpsBranchesAndTags(ctx, repoInfo, token)
+		if err != nil {
+			return "", "", err
+		}
+		return findLongestMatch(refs, urlPath)
+	case Git:
+		refs, err := listGitBranchesAndTags(ctx, repoInfo, token)
+		if err != nil {
+			return "", "", err
+		}
+		return findLongestMatch(refs, urlPath)
 	default:
 		return "", "", fmt.Errorf("unsupported repository type: %s", repoInfo.Type)
 	}
 }
 
 // resolveGitHubBranchAndPath resolves GitHub branch and path
-func resolveGitHubBranchAndPath(repoInfo *RepositoryInfo, urlPath string, token string) (string, string, error) {
-	ctx := context.Background()
+func resolveGitHubBranchAndPath(ctx context.Context, repoInfo *RepositoryInfo, urlPath string, token string) (string, string, error) {
 	var client *github.Client
 	if token != "" {
 		client = github.NewClient(nil).WithAuthToken(token)
@@ -63,12 +282,18 @@ func resolveGitHubBranchAndPath(repoInfo *RepositoryInfo, urlPath string, token
 
 	var allBranches []string
 	for {
-		branches, resp, err := client.Repositories.ListBranches(
-			ctx,
-			repoInfo.Owner,
-			repoInfo.Repo,
-			opts,
-		)
+		var branches []*github.Branch
+		var resp *github.Response
+		err := WithRateLimitRetry(ctx, repoInfo.Host(), func() (*http.Response, error) {
+			var err error
+			branches, resp, err = client.Repositories.ListBranches(
+				ctx,
+				repoInfo.Owner,
+				repoInfo.Repo,
+				opts,
+			)
+			return githubHTTPResponse(resp), err
+		})
 		if err != nil {
 			return "", "", fmt.Errorf("failed to list branches: %w", err)
 		}
@@ -85,7 +310,13 @@ func resolveGitHubBranchAndPath(repoInfo *RepositoryInfo, urlPath string, token
 
 	// Also list tags
 	tagOpts := &github.ListOptions{PerPage: 100}
-	tags, _, err := client.Repositories.ListTags(ctx, repoInfo.Owner, repoInfo.Repo, tagOpts)
+	var tags []*github.RepositoryTag
+	err := WithRateLimitRetry(ctx, repoInfo.Host(), func() (*http.Response, error) {
+		var resp *github.Response
+		var err error
+		tags, resp, err = client.Repositories.ListTags(ctx, repoInfo.Owner, repoInfo.Repo, tagOpts)
+		return githubHTTPResponse(resp), err
+	})
 	if err == nil {
 		for _, tag := range tags {
 			allBranches = append(allBranches, tag.GetName())
@@ -97,7 +328,7 @@ func resolveGitHubBranchAndPath(repoInfo *RepositoryInfo, urlPath string, token
 }
 
 // resolveGitLabBranchAndPath resolves GitLab branch and path
-func resolveGitLabBranchAndPath(repoInfo *RepositoryInfo, urlPath string, token string) (string, string, error) {
+func resolveGitLabBranchAndPath(ctx context.Context, repoInfo *RepositoryInfo, urlPath string, token string) (string, string, error) {
 	var client *gitlab.Client
 	var err error
 
@@ -120,7 +351,13 @@ func resolveGitLabBranchAndPath(repoInfo *RepositoryInfo, urlPath string, token
 
 	var allBranches []string
 	for {
-		branches, resp, err := client.Branches.ListBranches(projectID, opts)
+		var branches []*gitlab.Branch
+		var resp *gitlab.Response
+		err := WithRateLimitRetry(ctx, repoInfo.Host(), func() (*http.Response, error) {
+			var err error
+			branches, resp, err = client.Branches.ListBranches(projectID, opts, gitlab.WithContext(ctx))
+			return gitlabHTTPResponse(resp), err
+		})
 		if err != nil {
 			return "", "", fmt.Errorf("failed to list branches: %w", err)
 		}
@@ -139,7 +376,13 @@ func resolveGitLabBranchAndPath(repoInfo *RepositoryInfo, urlPath string, token
 	tagOpts := &gitlab.ListTagsOptions{
 		ListOptions: gitlab.ListOptions{PerPage: 100},
 	}
-	tags, _, err := client.Tags.ListTags(projectID, tagOpts)
+	var tags []*gitlab.Tag
+	err = WithRateLimitRetry(ctx, repoInfo.Host(), func() (*http.Response, error) {
+		var resp *gitlab.Response
+		var err error
+		tags, resp, err = client.Tags.ListTags(projectID, tagOpts, gitlab.WithContext(ctx))
+		return gitlabHTTPResponse(resp), err
+	})
 	if err == nil {
 		for _, tag := range tags {
 			allBranches = append(allBranches, tag.Name)
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"fmt"
 	"testing"
 )
@@ -31,7 +32,7 @@ func TestResolveBranchAndPath_WithMock(t *testing.T) {
 		Repo:  "repo",
 	}
 
-	branch, path, err := ResolveBranchAndPath(repoInfo, "develop/kustomize/base", "")
+	branch, path, err := ResolveBranchAndPath(context.Background(), repoInfo, "develop/kustomize/base", "")
 	if err != nil {
 		t.Fatalf("ResolveBranchAndPath: %v", err)
 	}
@@ -56,7 +57,7 @@ func TestResolveBranchAndPath_WithMock_GitLab(t *testing.T) {
 		Repo:  "project",
 	}
 
-	branch, path, err := ResolveBranchAndPath(repoInfo, "staging/deploy", "")
+	branch, path, err := ResolveBranchAndPath(context.Background(), repoInfo, "staging/deploy", "")
 	if err != nil {
 		t.Fatalf("ResolveBranchAndPath: %v", err)
 	}
@@ -76,7 +77,7 @@ func TestResolveBranchAndPath_WithMock_Error(t *testing.T) {
 	defer SetTestRefLister(nil)
 
 	repoInfo := &RepositoryInfo{Type: GitHub, Owner: "o", Repo: "r"}
-	_, _, err := ResolveBranchAndPath(repoInfo, "main/path", "")
+	_, _, err := ResolveBranchAndPath(context.Background(), repoInfo, "main/path", "")
 	if err == nil {
 		t.Fatal("expected error from mock, got nil")
 	}
@@ -93,7 +94,7 @@ func TestResolveBranchAndPath_WithMock_NoMatch(t *testing.T) {
 	defer SetTestRefLister(nil)
 
 	repoInfo := &RepositoryInfo{Type: GitHub, Owner: "o", Repo: "r"}
-	_, _, err := ResolveBranchAndPath(repoInfo, "unknown-branch/path", "")
+	_, _, err := ResolveBranchAndPath(context.Background(), repoInfo, "unknown-branch/path", "")
 	if err == nil {
 		t.Fatal("expected error when no branch matches, got nil")
 	}
@@ -138,7 +139,7 @@ func TestResolveBranchAndPath_RealURLs(t *testing.T) {
 			defer SetTestRefLister(nil)
 
 			repoInfo := &RepositoryInfo{Type: GitHub, Owner: "o", Repo: "r"}
-			branch, path, err := ResolveBranchAndPath(repoInfo, c.ambiguousPath, "")
+			branch, path, err := ResolveBranchAndPath(context.Background(), repoInfo, c.ambiguousPath, "")
 			if err != nil {
 				t.Fatalf("ResolveBranchAndPath: %v", err)
 			}
@@ -243,3 +244,68 @@ func TestFindLongestMatch(t *testing.T) {
 		})
 	}
 }
+
+// mockDefaultBranchGetter returns a fixed default branch for tests.
+type mockDefaultBranchGetter struct {
+	branch string
+	err    error
+	calls  int
+}
+
+func (m *mockDefaultBranchGetter) GetDefaultBranch(_ *RepositoryInfo, _ string) (string, error) {
+	m.calls++
+	return m.branch, m.err
+}
+
+func TestResolveDefaultBranch_WithMock(t *testing.T) {
+	mock := &mockDefaultBranchGetter{branch: "master"}
+	SetTestDefaultBranchGetter(mock)
+	defer SetTestDefaultBranchGetter(nil)
+
+	repoInfo, err := DetectRepository(context.Background(), "https://github.com/owner/repo", "")
+	if err != nil {
+		t.Fatalf("DetectRepository: %v", err)
+	}
+	if !repoInfo.FloatingRef {
+		t.Fatal("URL without branch should have a floating ref")
+	}
+	if err := ResolveDefaultBranch(context.Background(), repoInfo, ""); err != nil {
+		t.Fatalf("ResolveDefaultBranch: %v", err)
+	}
+	if repoInfo.Ref != "master" {
+		t.Errorf("Ref = %q, want master", repoInfo.Ref)
+	}
+}
+
+func TestResolveDefaultBranch_PinnedRefUntouched(t *testing.T) {
+	mock := &mockDefaultBranchGetter{branch: "master"}
+	SetTestDefaultBranchGetter(mock)
+	defer SetTestDefaultBranchGetter(nil)
+
+	repoInfo, err := DetectRepository(context.Background(), "https://gitlab.com/group/project/-/tree/develop/deploy", "")
+	if err != nil {
+		t.Fatalf("DetectRepository: %v", err)
+	}
+	if repoInfo.FloatingRef {
+		t.Fatal("URL with a tree path should not have a floating ref")
+	}
+	if err := ResolveDefaultBranch(context.Background(), repoInfo, ""); err != nil {
+		t.Fatalf("ResolveDefaultBranch: %v", err)
+	}
+	if mock.calls != 0 || repoInfo.Ref != "main" {
+		t.Errorf("pinned ref: calls = %d, Ref = %q; want no lookup", mock.calls, repoInfo.Ref)
+	}
+}
+
+func TestResolveDefaultBranch_ErrorKeepsRef(t *testing.T) {
+	SetTestDefaultBranchGetter(&mockDefaultBranchGetter{err: fmt.Errorf("API rate limit")})
+	defer SetTestDefaultBranchGetter(nil)
+
+	repoInfo := &RepositoryInfo{Type: GitHub, Owner: "o", Repo: "r", Ref: "main", FloatingRef: true}
+	if err := ResolveDefaultBranch(context.Background(), repoInfo, ""); err == nil {
+		t.Fatal("expected error")
+	}
+	if repoInfo.Ref != "main" {
+		t.Errorf("Ref = %q, want main after failed lookup", repoInfo.Ref)
+	}
+}
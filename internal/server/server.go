@@ -1,7 +1,9 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log"
@@ -9,6 +11,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -18,6 +21,7 @@ import (
 
 	"github.com/cjeanner/kustomap/internal/build"
 	"github.com/cjeanner/kustomap/internal/cacert"
+	"github.com/cjeanner/kustomap/internal/cache"
 	"github.com/cjeanner/kustomap/internal/export"
 	"github.com/cjeanner/kustomap/internal/fetcher"
 	"github.com/cjeanner/kustomap/internal/parser"
@@ -32,18 +36,30 @@ const (
 	maxBuildBodyBytes   = 32 * 1024  // 32 KB for build (tokens only)
 )
 
+// DefaultRequestTimeout bounds analyze and build requests when Config.RequestTimeout is unset.
+const DefaultRequestTimeout = 5 * time.Minute
+
 // Config holds optional server configuration.
 // nil is safe; LocalEnabled defaults to false, Port defaults to 3000 (caller's responsibility).
 type Config struct {
-	LocalEnabled bool // Enable local repository browsing (paths under $HOME)
-	Port         int  // HTTP listener port (e.g. for config API; main uses this for ListenAndServe)
+	LocalEnabled   bool           // Enable local repository browsing (paths under $HOME)
+	Port           int            // HTTP listener port (e.g. for config API; main uses this for ListenAndServe)
+	CacheDir       string         // On-disk content cache directory; empty disables the cache
+	CacheMaxBytes  int64          // Content cache size limit (<= 0: cache.DefaultMaxBytes)
+	Workers        int            // References fetched in parallel per analysis (<= 0: parser.DefaultWorkers)
+	HostLimits     map[string]int // Max parallel fetches per host (e.g. "github.com": 4); default Workers
+	RequestTimeout time.Duration  // Deadline of analyze and build requests (<= 0: DefaultRequestTimeout)
 }
 
 // AnalyzeRequest is the JSON body for POST /api/v1/analyze.
 type AnalyzeRequest struct {
-	URL         string `json:"url"`
-	GitHubToken string `json:"github_token"`
-	GitLabToken string `json:"gitlab_token"`
+	URL            string `json:"url"`
+	GitHubToken    string `json:"github_token"`
+	GitLabToken    string `json:"gitlab_token"`
+	BitbucketToken string `json:"bitbucket_token"`
+	GiteaToken     string `json:"gitea_token"`
+	AzureToken     string `json:"azure_token"`
+	GitToken       string `json:"git_token"`
 }
 
 // AnalyzeResponse is the JSON response for analyze and error responses.
@@ -51,6 +67,8 @@ type AnalyzeResponse struct {
 	ID      string `json:"id"`
 	Status  string `json:"status"`
 	Message string `json:"message,omitempty"`
+	// RateLimits is the remaining API quota of each host queried for the graph (GitHub, GitLab)
+	RateLimits map[string]repository.RateLimit `json:"rate_limits,omitempty"`
 }
 
 // New builds a chi router with API and static file routes.
@@ -63,6 +81,29 @@ func New(store storage.Storage, webRoot fs.FS, caCollector *cacert.Collector, cf
 	if cfg != nil && cfg.Port > 0 {
 		port = cfg.Port
 	}
+	workers := parser.DefaultWorkers
+	var hostLimits map[string]int
+	requestTimeout := DefaultRequestTimeout
+	if cfg != nil {
+		if cfg.Workers > 0 {
+			workers = cfg.Workers
+		}
+		hostLimits = cfg.HostLimits
+		if cfg.RequestTimeout > 0 {
+			requestTimeout = cfg.RequestTimeout
+		}
+	}
+	var contentCache *cache.Store
+	if cfg != nil && cfg.CacheDir != "" {
+		maxBytes := cfg.CacheMaxBytes
+		if maxBytes <= 0 {
+			maxBytes = cache.DefaultMaxBytes
+		}
+		var err error
+		if contentCache, err = cache.New(cfg.CacheDir, maxBytes); err != nil {
+			log.Printf("Warning: content cache disabled: %v", err)
+		}
+	}
 
 	r := chi.NewRouter()
 	r.Use(middleware.Logger)
@@ -73,11 +114,11 @@ func New(store storage.Storage, webRoot fs.FS, caCollector *cacert.Collector, cf
 		r.Get("/config", handleConfig(localEnabled, port))
 		r.Get("/browse", handleBrowse(localEnabled))
 		r.Post("/browse", handleBrowse(localEnabled))
-		r.Post("/analyze", handleAnalyze(store, caCollector, localEnabled))
+		r.With(withTimeout(requestTimeout)).Post("/analyze", handleAnalyze(store, caCollector, contentCache, workers, hostLimits, localEnabled))
 		r.Get("/graph/{id}", handleGetGraph(store))
 		r.Get("/graph/{id}/ca-bundle", handleGetCABundle(store))
 		r.Get("/node/{graphID}/{nodeID}", handleGetNode(store))
-		r.Post("/node/{graphID}/{nodeID}/build", handleBuildNode(store))
+		r.With(withTimeout(requestTimeout)).Post("/node/{graphID}/{nodeID}/build", handleBuildNode(store))
 	})
 
 	r.Get("/*", func(w http.ResponseWriter, r *http.Request) {
@@ -87,6 +128,33 @@ func New(store storage.Storage, webRoot fs.FS, caCollector *cacert.Collector, cf
 	return r
 }
 
+// withTimeout sets a deadline on the request context. Fetches and downloads made for the
+// request stop at the deadline, or as soon as the client disconnects.
+func withTimeout(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// requestAborted reports whether the request context ended (deadline or client gone),
+// answering 504 on timeouts; a disconnected client gets no response.
+func requestAborted(w http.ResponseWriter, r *http.Request) bool {
+	err := r.Context().Err()
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		respondError(w, http.StatusGatewayTimeout, "Request timed out")
+	} else {
+		log.Printf("Client disconnected: %s %s", r.Method, r.URL.Path)
+	}
+	return true
+}
+
 func handleBrowse(localEnabled bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		log.Printf("browse: %s %s", r.Method, r.URL.Path)
@@ -193,7 +261,7 @@ func setContentType(next http.Handler) http.Handler {
 	})
 }
 
-func handleAnalyze(store storage.Storage, caCollector *cacert.Collector, localEnabled bool) http.HandlerFunc {
+func handleAnalyze(store storage.Storage, caCollector *cacert.Collector, contentCache *cache.Store, workers int, hostLimits map[string]int, localEnabled bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		r.Body = http.MaxBytesReader(w, r.Body, maxAnalyzeBodyBytes)
 		var req AnalyzeRequest
@@ -231,7 +299,7 @@ func handleAnalyze(store storage.Storage, caCollector *cacert.Collector, localEn
 			log.Printf("Analyzing repository: %s", truncateForLog(req.URL, 256))
 
 			var err error
-			repoInfo, err = repository.DetectRepository(req.URL, "")
+			repoInfo, err = repository.DetectRepository(r.Context(), req.URL, "")
 			if err != nil {
 				respondError(w, http.StatusBadRequest, err.Error())
 				return
@@ -243,12 +311,23 @@ func handleAnalyze(store storage.Storage, caCollector *cacert.Collector, localEn
 				token = req.GitHubToken
 			case repository.GitLab:
 				token = req.GitLabToken
+			case repository.Bitbucket:
+				token = req.BitbucketToken
+			case repository.Gitea:
+				token = req.GiteaToken
+			case repository.AzureDevOps:
+				token = req.AzureToken
+			case repository.Git:
+				token = req.GitToken
 			}
 
 			if repoInfo.AmbiguousPath != "" {
 				log.Printf("Resolving ambiguous path: %s", repoInfo.AmbiguousPath)
-				branch, path, err := repository.ResolveBranchAndPath(repoInfo, repoInfo.AmbiguousPath, token)
+				branch, path, err := repository.ResolveBranchAndPath(r.Context(), repoInfo, repoInfo.AmbiguousPath, token)
 				if err != nil {
+					if requestAborted(w, r) {
+						return
+					}
 					respondError(w, http.StatusBadRequest, fmt.Sprintf("failed to resolve branch: %v", err))
 					return
 				}
@@ -257,6 +336,11 @@ func handleAnalyze(store storage.Storage, caCollector *cacert.Collector, localEn
 				log.Printf("✅ Resolved: branch=%s, path=%s", branch, path)
 			}
 
+			// No branch or tag in the URL: analyze the repository's default branch
+			if err := repository.ResolveDefaultBranch(r.Context(), repoInfo, token); err != nil {
+				log.Printf("Warning: could not resolve default branch, using %s: %v", repoInfo.Ref, err)
+			}
+
 			searchPath = repoInfo.Path
 		}
 		log.Printf("✅ Detected: %s", repoInfo.String())
@@ -272,10 +356,21 @@ func handleAnalyze(store storage.Storage, caCollector *cacert.Collector, localEn
 		p := parser.NewParser(f, repoInfo)
 		p.SetToken(repository.GitHub, req.GitHubToken)
 		p.SetToken(repository.GitLab, req.GitLabToken)
-
-		graph, err := p.Parse(searchPath)
+		p.SetToken(repository.Bitbucket, req.BitbucketToken)
+		p.SetToken(repository.Gitea, req.GiteaToken)
+		p.SetToken(repository.AzureDevOps, req.AzureToken)
+		p.SetToken(repository.Git, req.GitToken)
+		p.SetCache(contentCache)
+		p.SetConcurrency(workers, hostLimits)
+
+		// Only this analysis's requests (made with its tokens) count in its reported quotas
+		ctx, rateLimits := repository.WithRateLimits(r.Context())
+		graph, err := p.Parse(ctx, searchPath)
 		if err != nil {
 			log.Printf("Parse error: %v", err)
+			if requestAborted(w, r) {
+				return
+			}
 			respondError(w, http.StatusInternalServerError, "Failed to analyze repository")
 			return
 		}
@@ -300,7 +395,7 @@ func handleAnalyze(store storage.Storage, caCollector *cacert.Collector, localEn
 		log.Printf("✅ Graph saved with ID: %s (%d elements)", graph.ID, len(graph.Elements))
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(AnalyzeResponse{ID: graph.ID, Status: "success"})
+		json.NewEncoder(w).Encode(AnalyzeResponse{ID: graph.ID, Status: "success", RateLimits: rateLimits.All()})
 	}
 }
 
@@ -338,6 +433,18 @@ func handleGetGraph(store storage.Storage) http.HandlerFunc {
 			return
 		}
 		format := validation.ValidateFormat(r.URL.Query().Get("format"))
+		direction, err := validation.ValidateDirection(r.URL.Query().Get("direction"))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		opts := export.Options{Direction: direction}
+		if v := r.URL.Query().Get("hide_files"); v != "" {
+			if opts.HideFiles, err = strconv.ParseBool(v); err != nil {
+				respondError(w, http.StatusBadRequest, "invalid hide_files (want true or false)")
+				return
+			}
+		}
 		log.Printf("Retrieving graph: %s (format: %s)", graphID, format)
 
 		graph, err := store.GetGraph(graphID)
@@ -346,17 +453,22 @@ func handleGetGraph(store storage.Storage) http.HandlerFunc {
 			return
 		}
 
-		switch format {
-		case "mermaid":
-			mermaidCode := export.ToMermaid(graph)
-			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-			// graphID is already validated as UUID, safe for header
-			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=graph-%s.mmd", graphID))
-			w.Write([]byte(mermaidCode))
-		default:
+		exporter, ok := export.Formats[format]
+		if !ok {
 			w.Header().Set("Content-Type", "application/json")
 			json.NewEncoder(w).Encode(graph)
+			return
+		}
+		out, err := exporter.Render(graph, opts)
+		if err != nil {
+			log.Printf("Export %s error: %v", format, err)
+			respondError(w, http.StatusInternalServerError, "Failed to export graph")
+			return
 		}
+		w.Header().Set("Content-Type", exporter.ContentType)
+		// graphID is already validated as UUID, safe for header
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=graph-%s.%s", graphID, exporter.Extension))
+		w.Write([]byte(out))
 	}
 }
 
@@ -392,8 +504,12 @@ func handleGetNode(store storage.Storage) http.HandlerFunc {
 
 // BuildRequest is the optional JSON body for POST /api/v1/node/{graphID}/{nodeID}/build.
 type BuildRequest struct {
-	GitHubToken string `json:"github_token"`
-	GitLabToken string `json:"gitlab_token"`
+	GitHubToken    string `json:"github_token"`
+	GitLabToken    string `json:"gitlab_token"`
+	BitbucketToken string `json:"bitbucket_token"`
+	GiteaToken     string `json:"gitea_token"`
+	AzureToken     string `json:"azure_token"`
+	GitToken       string `json:"git_token"`
 }
 
 // BuildResponse is the JSON response for a successful build.
@@ -433,6 +549,10 @@ func handleBuildNode(store storage.Storage) http.HandlerFunc {
 			respondError(w, http.StatusBadRequest, "Build is not available for error nodes")
 			return
 		}
+		if nodeDetails.Type != "overlay" && nodeDetails.Type != "resource" {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("Build is not available for %s nodes", nodeDetails.Type))
+			return
+		}
 
 		r.Body = http.MaxBytesReader(w, r.Body, maxBuildBodyBytes)
 		var req BuildRequest
@@ -452,15 +572,24 @@ func handleBuildNode(store storage.Storage) http.HandlerFunc {
 		}
 
 		b := build.NewBuilder(req.GitHubToken, req.GitLabToken)
+		b.SetToken(repository.Bitbucket, req.BitbucketToken)
+		b.SetToken(repository.Gitea, req.GiteaToken)
+		b.SetToken(repository.AzureDevOps, req.AzureToken)
+		b.SetToken(repository.Git, req.GitToken)
 		localRoot := ""
 		if graph.LocalRootPaths != nil && graph.LocalRootPaths[decodedNodeID] != "" {
 			localRoot = graph.LocalRootPaths[decodedNodeID]
 		} else if graph.LocalRootPath != "" {
 			localRoot = graph.LocalRootPath
 		}
-		yamlOut, err := b.Build(decodedNodeID, baseURL, localRoot)
+		// Build the commit the graph was analyzed at, not the current branch head
+		commitSHA := graph.CommitSHAs[decodedNodeID]
+		yamlOut, err := b.Build(r.Context(), decodedNodeID, baseURL, graph.CloneURLs[decodedNodeID], localRoot, commitSHA)
 		if err != nil {
 			log.Printf("Build failed for node %s: %v", decodedNodeID, err)
+			if requestAborted(w, r) {
+				return
+			}
 			respondError(w, http.StatusUnprocessableEntity, "Build failed")
 			return
 		}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -73,6 +74,75 @@ func TestServer_GetGraph_Found(t *testing.T) {
 	}
 }
 
+func TestServer_GetGraph_Exports(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	graphID := uuid.New().String()
+	g := &types.Graph{ID: graphID, Created: "2025-01-01", Elements: []types.Element{
+		{Group: "nodes", Data: types.ElementData{ID: "github:o/r/overlay@main", Label: "overlay", Type: "overlay"}},
+	}}
+	store.SaveGraph(g)
+	r := New(store, fstestMapFS{}, nil, nil)
+
+	cases := []struct {
+		format, contentType, filename, prefix string
+	}{
+		{"dot", "text/vnd.graphviz; charset=utf-8", "graph-" + graphID + ".dot", "digraph kustomap {"},
+		{"graphml", "application/graphml+xml; charset=utf-8", "graph-" + graphID + ".graphml", "<?xml"},
+		{"jgf", "application/vnd.jgf+json", "graph-" + graphID + ".jgf.json", "{"},
+		{"plantuml", "text/plain; charset=utf-8", "graph-" + graphID + ".puml", "@startuml"},
+		{"d2", "text/plain; charset=utf-8", "graph-" + graphID + ".d2", "direction: down"},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/graph/"+graphID+"?format="+c.format, nil)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("GET ?format=%s status = %d, want 200", c.format, rec.Code)
+			continue
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != c.contentType {
+			t.Errorf("?format=%s Content-Type = %q, want %q", c.format, ct, c.contentType)
+		}
+		if cd := rec.Header().Get("Content-Disposition"); !strings.HasSuffix(cd, "filename="+c.filename) {
+			t.Errorf("?format=%s Content-Disposition = %q, want filename %s", c.format, cd, c.filename)
+		}
+		if !strings.HasPrefix(rec.Body.String(), c.prefix) {
+			t.Errorf("?format=%s body = %q, want prefix %q", c.format, rec.Body.String(), c.prefix)
+		}
+	}
+}
+
+func TestServer_GetGraph_MermaidOptions(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	graphID := uuid.New().String()
+	g := &types.Graph{ID: graphID, Created: "2025-01-01", Elements: []types.Element{
+		{Group: "nodes", Data: types.ElementData{ID: "github:o/r/overlay@main", Label: "overlay", Type: "overlay", Path: "overlay"}},
+		{Group: "nodes", Data: types.ElementData{ID: "github:o/r/overlay/cm.yaml@main", Label: "cm.yaml", Type: "resource", Path: "overlay/cm.yaml"}},
+	}}
+	store.SaveGraph(g)
+	r := New(store, fstestMapFS{}, nil, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/graph/"+graphID+"?format=mermaid&direction=lr&hide_files=true", nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if body := rec.Body.String(); !strings.HasPrefix(body, "flowchart LR") || strings.Contains(body, "cm.yaml") {
+		t.Errorf("body = %q, want LR flowchart without file nodes", body)
+	}
+
+	for _, query := range []string{"direction=RL", "hide_files=maybe"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/graph/"+graphID+"?format=mermaid&"+query, nil)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("?%s status = %d, want 400", query, rec.Code)
+		}
+	}
+}
+
 func TestServer_GetCABundle_NotFound(t *testing.T) {
 	store := storage.NewMemoryStorage()
 	webRoot := fstestMapFS{}
@@ -169,6 +239,20 @@ func TestServer_Browse_POST(t *testing.T) {
 	}
 }
 
+func TestWithTimeout_RespondsGatewayTimeout(t *testing.T) {
+	h := withTimeout(10 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done() // a fetch outliving the deadline
+		if !requestAborted(w, r) {
+			t.Error("requestAborted = false after the deadline")
+		}
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/analyze", nil))
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("status = %d, want 504", rec.Code)
+	}
+}
+
 // fstestMapFS is a minimal fs.FS for tests (avoids importing testing/fstest in production).
 type fstestMapFS struct{}
 
@@ -78,13 +78,17 @@ func (s *MemoryStorage) GetNode(graphID, nodeID string) (*types.NodeDetails, err
 
 	// Build NodeDetails with relationships
 	details := &types.NodeDetails{
-		ID:       nodeData.ID,
-		Label:    nodeData.Label,
-		Type:     nodeData.Type,
-		Path:     nodeData.Path,
-		Content:  nodeData.Content,
-		Parents:  []string{},
-		Children: []string{},
+		ID:            nodeData.ID,
+		Label:         nodeData.Label,
+		Type:          nodeData.Type,
+		Path:          nodeData.Path,
+		Content:       nodeData.Content,
+		Warnings:      nodeData.Warnings,
+		FloatingRef:   nodeData.FloatingRef,
+		CommitSHA:     graph.CommitSHAs[nodeID],
+		ErrorCategory: nodeData.ErrorCategory,
+		Parents:       []string{},
+		Children:      []string{},
 	}
 
 	// Find parent and child nodes
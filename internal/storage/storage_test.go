@@ -89,3 +89,42 @@ func TestMemoryStorage_GetNode_NotFound(t *testing.T) {
 		t.Fatal("GetNode(missing-graph) should error")
 	}
 }
+
+func TestMemoryStorage_GetNode_Warnings(t *testing.T) {
+	s := NewMemoryStorage()
+	g := &types.Graph{
+		ID: "g1",
+		Elements: []types.Element{
+			{Group: "nodes", Data: types.ElementData{ID: "n1", Type: "component", Warnings: []string{"kind mismatch"}}},
+		},
+	}
+	if err := s.SaveGraph(g); err != nil {
+		t.Fatalf("SaveGraph: %v", err)
+	}
+	details, err := s.GetNode("g1", "n1")
+	if err != nil {
+		t.Fatalf("GetNode: %v", err)
+	}
+	if len(details.Warnings) != 1 || details.Warnings[0] != "kind mismatch" {
+		t.Errorf("GetNode warnings = %v, want [kind mismatch]", details.Warnings)
+	}
+}
+
+func TestMemoryStorage_GetNode_CommitSHA(t *testing.T) {
+	s := NewMemoryStorage()
+	g := &types.Graph{
+		ID:         "g1",
+		Elements:   []types.Element{{Group: "nodes", Data: types.ElementData{ID: "n1", Type: "overlay"}}},
+		CommitSHAs: map[string]string{"n1": "abc123"},
+	}
+	if err := s.SaveGraph(g); err != nil {
+		t.Fatalf("SaveGraph: %v", err)
+	}
+	details, err := s.GetNode("g1", "n1")
+	if err != nil {
+		t.Fatalf("GetNode: %v", err)
+	}
+	if details.CommitSHA != "abc123" {
+		t.Errorf("GetNode CommitSHA = %q, want abc123", details.CommitSHA)
+	}
+}
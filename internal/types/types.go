@@ -7,6 +7,15 @@ type Graph struct {
 	Created  string            `json:"created"`
 	// BaseURLs maps node ID -> repo base URL (e.g. https://gitlab.example.com) for build
 	BaseURLs map[string]string `json:"base_urls,omitempty"`
+	// CommitSHAs maps node ID -> commit SHA the node's ref pointed to at analysis time.
+	// Build fetches that commit so its output matches the graph.
+	CommitSHAs map[string]string `json:"commit_shas,omitempty"`
+	// CloneURLs maps node ID -> clone URL of plain git remotes (e.g. git@host:group/sub/repo.git),
+	// whose owner may contain "/" and so cannot be told apart from the path in the node ID.
+	CloneURLs map[string]string `json:"clone_urls,omitempty"`
+	// Repos maps node ID -> repository of the node as type:owner/repo (e.g.
+	// gitlab:group/subgroup/project): owners may contain "/", which the node ID cannot tell.
+	Repos map[string]string `json:"repos,omitempty"`
 
 	// CABundle is the concatenated PEM of CA certs from all hosts in the overlay stack.
 	// Used for Argo CD when repos use self-signed or corporate CA certificates.
@@ -32,6 +41,10 @@ type Graph struct {
 	LocalRootPaths map[string]string `json:"-"`
 }
 
+// ErrorRateLimited is the ErrorCategory of nodes that could not be fetched because the
+// host's API quota was exhausted: the file may well exist.
+const ErrorRateLimited = "rate_limited"
+
 // Element can be a node or an edge
 type Element struct {
 	Group string      `json:"group"` // "nodes" ou "edges"
@@ -44,23 +57,36 @@ type ElementData struct {
 
 	// For nodes
 	Label   string                 `json:"label,omitempty"`
-	Type    string                 `json:"type,omitempty"` // "resource", "overlay", "component"
+	Type    string                 `json:"type,omitempty"` // "resource", "overlay", "component", "patch", "generator", "chart", "plugin", "file", "error"
 	Path    string                 `json:"path,omitempty"`
 	Content map[string]interface{} `json:"content,omitempty"` // kustomization.yaml content
+	// Warnings are problems kustomize would reject at build time (e.g. kind mismatch)
+	Warnings []string `json:"warnings,omitempty"`
+	// FloatingRef is true when the node's ref is the repo default branch (no branch/tag in the URL)
+	FloatingRef bool `json:"floatingRef,omitempty"`
+	// ErrorCategory qualifies error nodes whose cause is not the file itself (e.g. ErrorRateLimited)
+	ErrorCategory string `json:"errorCategory,omitempty"`
 
 	// For edges
 	Source   string `json:"source,omitempty"`
 	Target   string `json:"target,omitempty"`
-	EdgeType string `json:"edgeType,omitempty"` // "base", "resource", "patch"
+	EdgeType string `json:"edgeType,omitempty"` // "resource", "component", "patch", "generator", "source", "merge", "replace", "chart", "values", "transformer", "validator", "replacement", "crd", "openapi", "configuration"
 }
 
 // NodeDetails for details endpoint
 type NodeDetails struct {
-	ID      string                 `json:"id"`
-	Label   string                 `json:"label"`
-	Type    string                 `json:"type"`
-	Path    string                 `json:"path"`
-	Content map[string]interface{} `json:"content"`
+	ID       string                 `json:"id"`
+	Label    string                 `json:"label"`
+	Type     string                 `json:"type"`
+	Path     string                 `json:"path"`
+	Content  map[string]interface{} `json:"content"`
+	Warnings []string               `json:"warnings,omitempty"`
+	// FloatingRef is true when the node follows the repo default branch
+	FloatingRef bool `json:"floatingRef,omitempty"`
+	// CommitSHA is the commit the node's ref pointed to at analysis time (see Graph.CommitSHAs)
+	CommitSHA string `json:"commitSha,omitempty"`
+	// ErrorCategory qualifies error nodes (see ElementData.ErrorCategory)
+	ErrorCategory string `json:"errorCategory,omitempty"`
 
 	// Relations
 	Parents  []string `json:"parents"`  // Nodes pointing to current node
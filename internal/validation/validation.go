@@ -94,8 +94,13 @@ func rejectPrivateOrReservedHost(host string) error {
 
 // Format for graph export (whitelist to prevent injection).
 var validFormats = map[string]bool{
-	"json":    true,
-	"mermaid": true,
+	"json":     true,
+	"mermaid":  true,
+	"dot":      true,
+	"graphml":  true,
+	"jgf":      true,
+	"plantuml": true,
+	"d2":       true,
 }
 
 // ValidateFormat returns the format if it is allowed, or "json" as default.
@@ -108,6 +113,18 @@ func ValidateFormat(format string) string {
 	return "json"
 }
 
+// ValidateDirection returns the diagram direction ("TD" or "LR", case-insensitive), or
+// "TD" when empty. Used for the ?direction= query parameter.
+func ValidateDirection(direction string) (string, error) {
+	switch d := strings.ToUpper(strings.TrimSpace(direction)); d {
+	case "":
+		return "TD", nil
+	case "TD", "LR":
+		return d, nil
+	}
+	return "", fmt.Errorf("invalid direction (want TD or LR)")
+}
+
 // ValidateGraphID returns an error if id is not a valid UUID format.
 // Prevents header injection (e.g. CRLF in Content-Disposition) and ensures
 // consistent identifier format.
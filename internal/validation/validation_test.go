@@ -42,6 +42,18 @@ func TestValidateFormat(t *testing.T) {
 	if got := ValidateFormat("mermaid"); got != "mermaid" {
 		t.Errorf("ValidateFormat(\"mermaid\") = %q, want mermaid", got)
 	}
+	if got := ValidateFormat("dot"); got != "dot" {
+		t.Errorf("ValidateFormat(\"dot\") = %q, want dot", got)
+	}
+	if got := ValidateFormat("GraphML"); got != "graphml" {
+		t.Errorf("ValidateFormat(\"GraphML\") = %q, want graphml", got)
+	}
+	if got := ValidateFormat("PlantUML"); got != "plantuml" {
+		t.Errorf("ValidateFormat(\"PlantUML\") = %q, want plantuml", got)
+	}
+	if got := ValidateFormat("d2"); got != "d2" {
+		t.Errorf("ValidateFormat(\"d2\") = %q, want d2", got)
+	}
 	if got := ValidateFormat("JSON"); got != "json" {
 		t.Errorf("ValidateFormat(\"JSON\") = %q, want json", got)
 	}
@@ -50,6 +62,18 @@ func TestValidateFormat(t *testing.T) {
 	}
 }
 
+func TestValidateDirection(t *testing.T) {
+	for in, want := range map[string]string{"": "TD", "td": "TD", "LR": "LR", " lr ": "LR"} {
+		got, err := ValidateDirection(in)
+		if err != nil || got != want {
+			t.Errorf("ValidateDirection(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	if _, err := ValidateDirection("RL"); err == nil {
+		t.Error("ValidateDirection(\"RL\") expected error")
+	}
+}
+
 func TestValidateGraphID(t *testing.T) {
 	validUUID := "550e8400-e29b-41d4-a716-446655440000"
 	tests := []struct {
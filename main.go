@@ -10,8 +10,11 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/cjeanner/kustomap/internal/cacert"
+	"github.com/cjeanner/kustomap/internal/cache"
+	"github.com/cjeanner/kustomap/internal/parser"
 	"github.com/cjeanner/kustomap/internal/server"
 	"github.com/cjeanner/kustomap/internal/storage"
 )
@@ -26,9 +29,60 @@ const maxPort = 65535
 var webFS embed.FS
 
 func main() {
-	portFlag := flag.String("port", "", "HTTP listener port (default 3000, or set PORT env)")
-	enableLocal := flag.Bool("enable-local", false, "Enable local repository browsing (paths under $HOME)")
-	flag.Parse()
+	args := os.Args[1:]
+	cmd := "serve" // no subcommand (or only flags): start the server, as before subcommands existed
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
+	}
+
+	switch cmd {
+	case "serve":
+		serve(args)
+	case "analyze":
+		os.Exit(runAnalyze(args, os.Stdout, os.Stderr))
+	case "build":
+		os.Exit(runBuild(args, os.Stdout, os.Stderr))
+	case "help":
+		fmt.Fprint(os.Stdout, usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", cmd, usage)
+		os.Exit(exitUsage)
+	}
+}
+
+// fetchFlags are the flags tuning fetches, shared by serve and analyze.
+type fetchFlags struct {
+	cacheDir   *string
+	cacheMaxMB *int64
+	workers    *int
+	hostLimits *string
+}
+
+func addFetchFlags(flags *flag.FlagSet) *fetchFlags {
+	return &fetchFlags{
+		cacheDir:   flags.String("cache-dir", "", "On-disk content cache directory (or set KUSTOMAP_CACHE_DIR; default: no cache)"),
+		cacheMaxMB: flags.Int64("cache-max-mb", cache.DefaultMaxBytes>>20, "Content cache size limit in MB"),
+		workers:    flags.Int("workers", parser.DefaultWorkers, "Kustomization references fetched in parallel"),
+		hostLimits: flags.String("host-concurrency", "", "Per-host parallel fetch limits, e.g. github.com=4,gitlab.example.com=2"),
+	}
+}
+
+// cacheDirOrEnv returns the cache directory flag, falling back to KUSTOMAP_CACHE_DIR.
+func (f *fetchFlags) cacheDirOrEnv() string {
+	if *f.cacheDir != "" {
+		return *f.cacheDir
+	}
+	return os.Getenv("KUSTOMAP_CACHE_DIR")
+}
+
+// serve runs the web UI and API server.
+func serve(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	portFlag := flags.String("port", "", "HTTP listener port (default 3000, or set PORT env)")
+	enableLocal := flags.Bool("enable-local", false, "Enable local repository browsing (paths under $HOME)")
+	fetch := addFetchFlags(flags)
+	requestTimeout := flags.Duration("request-timeout", server.DefaultRequestTimeout, "Deadline of analyze and build requests (e.g. 2m)")
+	flags.Parse(args)
 
 	portStr := *portFlag
 	if portStr == "" {
@@ -45,7 +99,19 @@ func main() {
 	store := storage.NewMemoryStorage()
 	caCollector := cacert.NewCollector(cacert.DefaultTTL)
 	webRoot, _ := fs.Sub(webFS, "web")
-	cfg := &server.Config{LocalEnabled: *enableLocal, Port: port}
+	hostLimits, err := parseHostLimits(*fetch.hostLimits)
+	if err != nil {
+		log.Fatalf("invalid host concurrency: %v", err)
+	}
+	cfg := &server.Config{
+		LocalEnabled:   *enableLocal,
+		Port:           port,
+		CacheDir:       fetch.cacheDirOrEnv(),
+		CacheMaxBytes:  *fetch.cacheMaxMB << 20,
+		Workers:        *fetch.workers,
+		HostLimits:     hostLimits,
+		RequestTimeout: *requestTimeout,
+	}
 	r := server.New(store, webRoot, caCollector, cfg)
 
 	addr := ":" + strconv.Itoa(cfg.Port)
@@ -70,3 +136,25 @@ func parsePort(s string) (int, error) {
 	}
 	return n, nil
 }
+
+// parseHostLimits parses per-host concurrency limits: a comma-separated list of
+// host=limit pairs with positive limits. An empty string yields no limits.
+func parseHostLimits(s string) (map[string]int, error) {
+	limits := make(map[string]int)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		host, limit, ok := strings.Cut(pair, "=")
+		if !ok || host == "" {
+			return nil, fmt.Errorf("expected host=limit, got %q", pair)
+		}
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("limit for %s must be a positive integer", host)
+		}
+		limits[host] = n
+	}
+	return limits, nil
+}
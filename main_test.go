@@ -4,6 +4,7 @@ import (
 	"io/fs"
 	"net"
 	"net/http"
+	"reflect"
 	"strconv"
 	"testing"
 
@@ -95,3 +96,22 @@ func TestServerListensOnPort(t *testing.T) {
 		t.Errorf("GET /api/v1/graph/%s status = %d, want 404", nonexistentUUID, resp.StatusCode)
 	}
 }
+
+func TestParseHostLimits(t *testing.T) {
+	got, err := parseHostLimits("github.com=4, gitlab.example.com=2")
+	if err != nil {
+		t.Fatalf("parseHostLimits: %v", err)
+	}
+	want := map[string]int{"github.com": 4, "gitlab.example.com": 2}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseHostLimits = %v, want %v", got, want)
+	}
+	if got, err := parseHostLimits(""); err != nil || len(got) != 0 {
+		t.Errorf("parseHostLimits(\"\") = %v, %v; want no limits", got, err)
+	}
+	for _, in := range []string{"github.com", "=4", "github.com=0", "github.com=x"} {
+		if _, err := parseHostLimits(in); err == nil {
+			t.Errorf("parseHostLimits(%q) expected error", in)
+		}
+	}
+}
@@ -149,6 +149,43 @@ function renderGraph(container, graphData, onNodeClick) {
                     'background-color': '#3498db'
                 }
             },
+            {
+                selector: 'node[type="patch"]',
+                style: {
+                    'background-color': '#e67e22',
+                    'shape': 'tag'
+                }
+            },
+            {
+                selector: 'node[type="generator"]',
+                style: {
+                    'background-color': '#1abc9c',
+                    'shape': 'hexagon'
+                }
+            },
+            {
+                selector: 'node[type="chart"]',
+                style: {
+                    'background-color': '#0f1689',
+                    'color': 'white',
+                    'shape': 'barrel'
+                }
+            },
+            {
+                selector: 'node[type="plugin"]',
+                style: {
+                    'background-color': '#34495e',
+                    'color': 'white',
+                    'shape': 'cut-rectangle'
+                }
+            },
+            {
+                selector: 'node[type="file"]',
+                style: {
+                    'background-color': '#bdc3c7',
+                    'height': 32
+                }
+            },
             {
                 selector: 'node[type="error"]',
                 style: {
@@ -169,6 +206,53 @@ function renderGraph(container, graphData, onNodeClick) {
                     'arrow-scale': 1.2
                 }
             },
+            {
+                selector: 'edge[edgeType="patch"]',
+                style: {
+                    'line-style': 'dashed',
+                    'line-color': '#e67e22',
+                    'target-arrow-color': '#e67e22'
+                }
+            },
+            {
+                selector: 'edge[edgeType="merge"], edge[edgeType="replace"]',
+                style: {
+                    'line-style': 'dotted',
+                    'line-color': '#1abc9c',
+                    'target-arrow-color': '#1abc9c'
+                }
+            },
+            {
+                selector: 'edge[edgeType="replacement"]',
+                style: {
+                    'line-style': 'dashed',
+                    'line-color': '#8e44ad',
+                    'target-arrow-color': '#8e44ad'
+                }
+            },
+            {
+                selector: 'node[errorCategory = "rate_limited"]',
+                style: {
+                    'background-color': '#e67e22',
+                    'border-color': '#d35400'
+                }
+            },
+            {
+                selector: 'node[?floatingRef]',
+                style: {
+                    'border-width': 3,
+                    'border-style': 'double',
+                    'border-color': '#3498db'
+                }
+            },
+            {
+                selector: 'node[warnings]',
+                style: {
+                    'border-width': 3,
+                    'border-style': 'dashed',
+                    'border-color': '#f39c12'
+                }
+            },
             {
                 selector: 'node:selected',
                 style: {
@@ -234,6 +318,11 @@ class App {
         this.exportPngBtn = document.getElementById('export-png-btn');
         this.exportSvgBtn = document.getElementById('export-svg-btn');
         this.exportMermaidBtn = document.getElementById('export-mermaid-btn');
+        this.exportDotBtn = document.getElementById('export-dot-btn');
+        this.exportGraphMLBtn = document.getElementById('export-graphml-btn');
+        this.exportJGFBtn = document.getElementById('export-jgf-btn');
+        this.exportPlantUMLBtn = document.getElementById('export-plantuml-btn');
+        this.exportD2Btn = document.getElementById('export-d2-btn');
         this.downloadCABundleBtn = document.getElementById('download-ca-bundle-btn');
         this.localBranchBadge = document.getElementById('local-branch-badge');
         this.localBranchValue = document.getElementById('local-branch-value');
@@ -259,6 +348,11 @@ class App {
         this.exportPngBtn.addEventListener('click', () => this.exportPNG());
         this.exportSvgBtn.addEventListener('click', () => this.exportSVG());
         this.exportMermaidBtn.addEventListener('click', () => this.exportMermaid());
+        this.exportDotBtn.addEventListener('click', () => this.exportDOT());
+        this.exportGraphMLBtn.addEventListener('click', () => this.exportText('graphml', 'graphml', 'GraphML'));
+        this.exportJGFBtn.addEventListener('click', () => this.exportText('jgf', 'jgf.json', 'JGF'));
+        this.exportPlantUMLBtn.addEventListener('click', () => this.exportText('plantuml', 'puml', 'PlantUML'));
+        this.exportD2Btn.addEventListener('click', () => this.exportText('d2', 'd2', 'D2'));
         this.downloadCABundleBtn.addEventListener('click', () => this.downloadCABundle());
 
         this.loadTokensFromStorage();
@@ -730,11 +824,18 @@ class App {
 
             // Build overlay button: only for directories (overlay/resource dirs), not single .yaml/.yml files or components
             const pathIsFile = (p) => p && (p.toLowerCase().endsWith('.yaml') || p.toLowerCase().endsWith('.yml'));
-            const canBuild = nodeDetails.type !== 'component' && nodeDetails.type !== 'error' && !pathIsFile(nodeDetails.path);
+            const canBuild = (nodeDetails.type === 'overlay' || nodeDetails.type === 'resource') && !pathIsFile(nodeDetails.path);
             const buildButtonHtml = canBuild
                 ? `<p class="node-info-actions"><button type="button" class="build-overlay-btn" data-node-id="${nodeDetails.id}" data-node-label="${nodeDetails.label || nodeDetails.id}">Build overlay</button></p>`
                 : '';
 
+            // Objects defined by a YAML resource, or by a kustomization's own YAML resources
+            const content = nodeDetails.content || {};
+            const objectCount = Array.isArray(content.objects) ? content.objects.length : content.objectCount;
+            const objectCountHtml = objectCount !== undefined
+                ? `<p><strong>Objects:</strong> ${objectCount}</p>`
+                : '';
+
             // Afficher les détails complets
             let html = `
             <h2>${nodeDetails.label || nodeDetails.id}</h2>
@@ -742,10 +843,23 @@ class App {
                 <p><strong>ID:</strong> ${nodeDetails.id}</p>
                 <p><strong>Type:</strong> <span class="badge badge-${nodeDetails.type}">${nodeDetails.type}</span></p>
                 ${nodeDetails.path ? `<p><strong>Path:</strong> <code>${nodeDetails.path}</code></p>` : ''}
+                ${nodeDetails.commitSha ? `<p><strong>Commit:</strong> <code>${nodeDetails.commitSha}</code></p>` : ''}
+                ${nodeDetails.floatingRef ? '<p><strong>Ref:</strong> <span class="badge badge-floating">floating default branch</span></p>' : ''}
+                ${nodeDetails.errorCategory === 'rate_limited' ? '<p><strong>Error:</strong> <span class="badge badge-rate-limited">rate limited</span> (API quota exhausted; analyze again later)</p>' : ''}
+                ${objectCountHtml}
                 ${buildButtonHtml}
             </div>
         `;
 
+            // Warnings (e.g. kind mismatch that kustomize rejects at build time)
+            if (nodeDetails.warnings && nodeDetails.warnings.length > 0) {
+                html += '<div class="warning-message">';
+                nodeDetails.warnings.forEach(warning => {
+                    html += `<p>⚠️ ${warning}</p>`;
+                });
+                html += '</div>';
+            }
+
             // Relations - Parents
             if (nodeDetails.parents && nodeDetails.parents.length > 0) {
                 html += '<div class="relations-section">';
@@ -770,11 +884,20 @@ class App {
                 html += '</div>';
             }
 
+            // Effective transformers (final image tags, namespace, ... once bases are applied)
+            const { effective, ...otherContent } = content;
+            if (effective) {
+                html += '<div class="content-section">';
+                html += '<h3>🎯 Effective</h3>';
+                html += `<pre><code>${JSON.stringify(effective, null, 2)}</code></pre>`;
+                html += '</div>';
+            }
+
             // Content
-            if (nodeDetails.content && Object.keys(nodeDetails.content).length > 0) {
+            if (Object.keys(otherContent).length > 0) {
                 html += '<div class="content-section">';
                 html += '<h3>📄 Content</h3>';
-                html += `<pre><code>${JSON.stringify(nodeDetails.content, null, 2)}</code></pre>`;
+                html += `<pre><code>${JSON.stringify(otherContent, null, 2)}</code></pre>`;
                 html += '</div>';
             }
 
@@ -893,21 +1016,30 @@ class App {
     }
 
     async exportMermaid() {
+        await this.exportText('mermaid', 'mmd', 'Mermaid');
+    }
+
+    async exportDOT() {
+        await this.exportText('dot', 'dot', 'DOT');
+    }
+
+    // Download the graph in a text format rendered by the server (?format=).
+    async exportText(format, extension, name) {
         if (!this.currentGraphId) return;
 
         try {
-            const res = await fetch(`/api/v1/graph/${this.currentGraphId}?format=mermaid`);
+            const res = await fetch(`/api/v1/graph/${this.currentGraphId}?format=${format}`);
             if (!res.ok) throw new Error(res.statusText);
             const text = await res.text();
             const blob = new Blob([text], { type: 'text/plain;charset=utf-8' });
             const url = URL.createObjectURL(blob);
             const link = document.createElement('a');
             link.href = url;
-            link.download = `kustomize-graph-${this.currentGraphId}.mmd`;
+            link.download = `kustomize-graph-${this.currentGraphId}.${extension}`;
             link.click();
             URL.revokeObjectURL(url);
         } catch (e) {
-            this.showError('Failed to export Mermaid: ' + (e.message || String(e)));
+            this.showError(`Failed to export ${name}: ` + (e.message || String(e)));
         }
     }
 